@@ -0,0 +1,828 @@
+// Package checker verifies finished signed tableaux without trusting
+// the code in package tableaux that built them. It re-parses every
+// formula, and has its own table of Smullyan's signed tableau rules.
+// It checks Fitch-style natural deduction proofs and Hilbert-style
+// axiomatic proofs too.
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/parser"
+	"tableaux-in-go/src/tableaux"
+	"tableaux-in-go/src/truthtable"
+)
+
+// StepError describes one invalid step in a tableau.
+type StepError struct {
+	Line   int // Line number of the invalid formula, -1 for the tableau as a whole
+	Reason string
+}
+
+func (e *StepError) Error() string {
+	if e.Line < 0 {
+		return e.Reason
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// Check verifies a proof, giving back the first invalid step, in
+// order of line number, or nil if every step is valid.
+func Check(proof *tableaux.JSONProof) error {
+	if errs := Verify(proof); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// CheckTnode verifies a tableau built in memory, given the formula(s)
+// it's supposed to decide: a single formula, or hypotheses followed by a
+// consequence, the way tableaux.Setup takes them.
+func CheckTnode(formulas []string, root *tableaux.Tnode, closed bool) error {
+	proof := &tableaux.JSONProof{Unsigned: root.Unsigned(), Closed: closed, Tableau: root.JSON()}
+	if len(formulas) == 1 {
+		proof.Formula = formulas[0]
+	} else if len(formulas) > 1 {
+		proof.Hypotheses = formulas[:len(formulas)-1]
+		proof.Consequence = formulas[len(formulas)-1]
+	}
+	return Check(proof)
+}
+
+// ReadJSON decodes a proof, as written by "tableaux -json" or
+// given back by the /prove and /consequence endpoints of
+// "tableaux serve".
+func ReadJSON(r io.Reader) (*tableaux.JSONProof, error) {
+	proof := &tableaux.JSONProof{}
+	if err := json.NewDecoder(r).Decode(proof); err != nil {
+		return nil, err
+	}
+	if proof.Tableau == nil {
+		return nil, fmt.Errorf("no tableau in proof")
+	}
+	return proof, nil
+}
+
+// signed is a signed formula, with a canonical string representation
+// of its parse tree for comparisons. A formula of an unsigned tableau
+// gets checked as a signed formula: ~X as F: X, any other X as T: X.
+// A formula of a modal tableau has a world as well. A formula of a
+// many-signed tableau has values, its sign proper.
+type signed struct {
+	sign     bool
+	tree     *node.Node
+	text     string
+	unsigned bool
+	world    string
+	values   truthtable.Set
+}
+
+func newSigned(sign bool, tree *node.Node) signed {
+	return signed{sign: sign, tree: tree, text: node.ExpressionToString(tree)}
+}
+
+// newUnsigned gives back the signed equivalent of formula tree
+// of an unsigned tableau, or of ~tree if sign is false.
+func newUnsigned(sign bool, tree *node.Node) signed {
+	if sign && tree.Op == lexer.NOT {
+		sign, tree = false, tree.Left
+	}
+	s := newSigned(sign, tree)
+	s.unsigned = true
+	return s
+}
+
+func (s signed) String() string {
+	var text string
+	switch {
+	case s.unsigned && s.sign:
+		text = s.text
+	case s.unsigned:
+		not := node.NewOpNode(lexer.NOT)
+		not.Left = s.tree
+		text = node.ExpressionToString(not)
+	case s.values != 0:
+		text = s.values.String() + ": " + s.text
+	case s.sign:
+		text = "T: " + s.text
+	default:
+		text = "F: " + s.text
+	}
+	if s.world != "" {
+		return s.world + " " + text
+	}
+	return text
+}
+
+// complement gives back the formula that contradicts s.
+func (s signed) complement() signed {
+	c := newSigned(!s.sign, s.tree)
+	c.unsigned = s.unsigned
+	c.world = s.world
+	return c
+}
+
+func (s signed) equal(t signed) bool {
+	return s.sign == t.sign && s.text == t.text && s.world == t.world && s.values == t.values
+}
+
+// components gives back the alternatives that Smullyan's rules allow
+// subjoining for signed formula s: one alternative for alpha-type and
+// negation rules, two for beta-type and equivalence rules, none at all
+// for a signed identifier.
+func components(s signed) [][]signed {
+	alternatives := signedComponents(s)
+	for _, alternative := range alternatives {
+		for i, c := range alternative {
+			if s.unsigned {
+				c = newUnsigned(c.sign, c.tree)
+			}
+			c.world = s.world
+			alternative[i] = c
+		}
+	}
+	return alternatives
+}
+
+func signedComponents(s signed) [][]signed {
+	t := s.tree
+	switch t.Op {
+	case lexer.NOT:
+		return [][]signed{{newSigned(!s.sign, t.Left)}}
+	case lexer.AND:
+		if s.sign {
+			return [][]signed{{newSigned(true, t.Left), newSigned(true, t.Right)}}
+		}
+		return [][]signed{{newSigned(false, t.Left)}, {newSigned(false, t.Right)}}
+	case lexer.OR:
+		if s.sign {
+			return [][]signed{{newSigned(true, t.Left)}, {newSigned(true, t.Right)}}
+		}
+		return [][]signed{{newSigned(false, t.Left), newSigned(false, t.Right)}}
+	case lexer.IMPLIES:
+		if s.sign {
+			return [][]signed{{newSigned(false, t.Left)}, {newSigned(true, t.Right)}}
+		}
+		return [][]signed{{newSigned(true, t.Left), newSigned(false, t.Right)}}
+	case lexer.EQUIV:
+		if s.sign {
+			return [][]signed{
+				{newSigned(true, t.Left), newSigned(true, t.Right)},
+				{newSigned(false, t.Left), newSigned(false, t.Right)},
+			}
+		}
+		return [][]signed{
+			{newSigned(true, t.Left), newSigned(false, t.Right)},
+			{newSigned(false, t.Left), newSigned(true, t.Right)},
+		}
+	}
+	return nil
+}
+
+// entry is one line of the tableau under verification.
+type entry struct {
+	j        *tableaux.JSONTnode
+	formula  signed
+	parsed   bool
+	parent   *entry
+	children []*entry
+}
+
+func (e *entry) leaf() bool { return len(e.children) == 0 }
+
+// onBranch returns true if formula s appears on the branch
+// from e up to the root, e included.
+func (e *entry) onBranch(s signed) bool {
+	for p := e; p != nil; p = p.parent {
+		if p.parsed && p.formula.equal(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ancestor finds the entry for line number line on the branch
+// from e up to the root, e included, or nil if no such entry exists.
+func (e *entry) ancestor(line int) *entry {
+	for p := e; p != nil; p = p.parent {
+		if p.j.Line == line {
+			return p
+		}
+	}
+	return nil
+}
+
+type verifier struct {
+	errs       []*StepError
+	lines      map[int]*entry
+	problemEnd *entry // Last of the problem formulas
+	unsigned   bool
+	modal      string // K, T, S4, S5, or "" for classical logic
+	logic      truthtable.Logic
+}
+
+// formula gives back the signed formula a line of the tableau has.
+func (v *verifier) formula(sign bool, tree *node.Node) signed {
+	if v.unsigned {
+		return newUnsigned(sign, tree)
+	}
+	return newSigned(sign, tree)
+}
+
+func (v *verifier) fail(line int, format string, args ...interface{}) {
+	v.errs = append(v.errs, &StepError{Line: line, Reason: fmt.Sprintf(format, args...)})
+}
+
+// Verify checks every step of a proof, giving back all the invalid
+// steps it finds, ordered by line number. Problems with the tableau as
+// a whole (line -1) come first.
+func Verify(proof *tableaux.JSONProof) []*StepError {
+	v := &verifier{lines: make(map[int]*entry), unsigned: proof.Unsigned, modal: proof.Modal}
+	switch proof.Modal {
+	case "", "K", "T", "S4", "S5", "intuitionistic":
+	default:
+		v.fail(-1, "unknown modal logic %q", proof.Modal)
+	}
+	logic, err := truthtable.ParseLogic(proof.Logic)
+	if err != nil {
+		v.fail(-1, "%v", err)
+	}
+	if logic != truthtable.Classical && (proof.Modal != "" || proof.Unsigned) {
+		v.fail(-1, "three-valued logic %s in a modal or unsigned tableau", logic)
+	}
+	v.logic = logic
+	root := v.build(proof.Tableau, nil)
+
+	v.checkProblem(proof, root)
+	v.checkSteps(root)
+	v.checkVerdict(proof, root)
+
+	sort.SliceStable(v.errs, func(i, j int) bool { return v.errs[i].Line < v.errs[j].Line })
+	return v.errs
+}
+
+// build re-parses every formula in a JSON tableau, and links
+// up entries so that branches can be followed toward the root.
+func (v *verifier) build(j *tableaux.JSONTnode, parent *entry) *entry {
+	e := &entry{j: j, parent: parent}
+	if _, ok := v.lines[j.Line]; ok {
+		v.fail(j.Line, "line number appears more than once")
+	}
+	v.lines[j.Line] = e
+
+	tree, err := parser.ParseString(j.Formula)
+	if err != nil {
+		v.fail(j.Line, "%v", err)
+	} else {
+		e.formula = v.formula(j.Sign, tree)
+		e.formula.world = j.World
+		e.parsed = true
+		v.setValues(e)
+		if v.unsigned && !j.Sign {
+			v.fail(j.Line, "formula signed F in an unsigned tableau")
+		}
+	}
+	switch {
+	case v.modal == "" && j.World != "":
+		v.fail(j.Line, "world %s in a tableau without modal logic", j.World)
+	case v.modal != "" && !validWorld(j.World):
+		v.fail(j.Line, "%q is not a world", j.World)
+	}
+
+	if len(j.Children) > 2 {
+		v.fail(j.Line, "more than two branches below a formula")
+	}
+	for _, child := range j.Children {
+		e.children = append(e.children, v.build(child, e))
+	}
+	return e
+}
+
+// checkProblem compares the formulas at the top of the tableau, which
+// have no premises, to the problem the tableau is supposed to decide.
+func (v *verifier) checkProblem(proof *tableaux.JSONProof, root *entry) {
+	// Problem formulas: a single branch of formulas without premises.
+	var found []*entry
+	for e := root; e != nil && e.j.Premise == nil; {
+		found = append(found, e)
+		v.problemEnd = e
+		if len(e.children) != 1 {
+			break
+		}
+		e = e.children[0]
+	}
+
+	var formulas []string
+	var signs []bool
+	if proof.Formula != "" {
+		formulas, signs = []string{proof.Formula}, []bool{false}
+	} else if proof.Consequence != "" {
+		for _, h := range proof.Hypotheses {
+			formulas, signs = append(formulas, h), append(signs, true)
+		}
+		formulas, signs = append(formulas, proof.Consequence), append(signs, false)
+	} else {
+		v.fail(-1, "proof states no problem")
+		return
+	}
+
+	for i, formula := range formulas {
+		tree, err := parser.ParseString(formula)
+		if err != nil {
+			v.fail(-1, "problem: %v", err)
+			return
+		}
+		want := v.formula(signs[i], tree)
+		if v.modal != "" {
+			want.world = "1"
+		}
+		if v.logic != truthtable.Classical {
+			want.values = v.logic.Designated()
+			if !signs[i] {
+				want.values = truthtable.All &^ want.values
+			}
+		}
+		if i >= len(found) {
+			if len(found) > 0 && found[len(found)-1].j.Closed && len(found[len(found)-1].children) == 0 {
+				break // Problem formulas that closed the branch, like ~p below p unsigned
+			}
+			v.fail(-1, "tableau lacks problem formula %s", want)
+			continue
+		}
+		if found[i].parsed && !found[i].formula.equal(want) {
+			v.fail(found[i].j.Line, "expected problem formula %s, found %s", want, found[i].formula)
+		}
+	}
+	for _, extra := range found[min(len(found), len(formulas)):] {
+		v.fail(extra.j.Line, "formula not inferred from anything")
+	}
+}
+
+// checkSteps walks the tableau, checking that every formula below the
+// problem formulas is part of a correct application of a rule to a
+// formula above it on the same branch, and that every closed branch
+// ends in a formula contradicting one above it.
+func (v *verifier) checkSteps(e *entry) {
+	v.checkClosure(e)
+	if v.problemFormula(e) && e != v.problemEnd {
+		// checkProblem looked at these
+		v.checkSteps(e.children[0])
+		return
+	}
+	v.checkBelow(e)
+}
+
+// checkBelow checks the rule application that starts immediately
+// below e, if e has anything below it.
+func (v *verifier) checkBelow(e *entry) {
+	if !e.leaf() {
+		v.checkApplication(e, e.children)
+	}
+}
+
+// problemFormula returns true if e is one of the formulas
+// without premises at the top of the tableau.
+func (v *verifier) problemFormula(e *entry) bool {
+	for p := v.problemEnd; p != nil; p = p.parent {
+		if p == e {
+			return true
+		}
+	}
+	return false
+}
+
+// checkApplication checks the inferences subjoined directly below
+// e: one run of formulas for an alpha-type rule, two for beta-type.
+// Then it checks everything below those inferences.
+func (v *verifier) checkApplication(e *entry, starts []*entry) {
+	first := starts[0]
+	if first.j.Premise == nil {
+		v.fail(first.j.Line, "formula not inferred from anything")
+		v.skipRuns(starts)
+		return
+	}
+	premiseLine := *first.j.Premise
+
+	premise := e.ancestor(premiseLine)
+	if premise == nil {
+		v.fail(first.j.Line, "premise %d is not above it on its branch", premiseLine)
+		v.skipRuns(starts)
+		return
+	}
+	if !premise.parsed {
+		v.skipRuns(starts)
+		return
+	}
+	if v.logic != truthtable.Classical {
+		v.checkValued(e, starts, premise)
+		return
+	}
+	if lexer.Quantifier(premise.formula.tree.Op) {
+		v.checkInstance(e, starts[0], premise, len(starts))
+		return
+	}
+	alternatives := v.components(premise.formula)
+	worlds, necessary := v.worldComponents(premise.formula)
+	if worlds != nil {
+		alternatives = worlds
+	}
+	switch {
+	case len(alternatives) == 0:
+		v.fail(first.j.Line, "no inferences can be made from line %d, %s", premiseLine, premise.formula)
+		v.skipRuns(starts)
+		return
+	case len(alternatives) != len(starts):
+		if len(alternatives) == 1 {
+			v.fail(starts[1].j.Line, "line %d, %s, doesn't split branches", premiseLine, premise.formula)
+		} else {
+			v.fail(first.j.Line, "line %d, %s, has to split branches", premiseLine, premise.formula)
+		}
+		v.skipRuns(starts)
+		return
+	}
+	if worlds != nil && !v.checkWorld(e, starts, premise, alternatives, necessary) {
+		v.skipRuns(starts)
+		return
+	}
+
+	used := make([]bool, len(alternatives))
+	for _, start := range starts {
+		if start.j.Premise == nil || *start.j.Premise != premiseLine {
+			v.fail(start.j.Line, "both branches of a split have to come from line %d", premiseLine)
+			v.skipRuns([]*entry{start})
+			continue
+		}
+		last := v.checkRun(start, premise, alternatives, used)
+		v.checkBelow(last)
+	}
+}
+
+// checkRun matches the run of formulas starting at start, each with
+// the same premise, against one of the unused alternatives of that
+// premise's rule, giving back the last entry of the run.
+func (v *verifier) checkRun(start, premise *entry, alternatives [][]signed, used []bool) *entry {
+	line := premise.j.Line
+
+	// Find the alternative that start belongs to.
+	which := -1
+	for i, alt := range alternatives {
+		if used[i] {
+			continue
+		}
+		for _, c := range alt {
+			if start.parsed && c.equal(start.formula) {
+				which = i
+			}
+		}
+		if which >= 0 {
+			break
+		}
+	}
+	if which < 0 {
+		v.fail(start.j.Line, "%s does not follow from line %d, %s", start.formula, line, premise.formula)
+		v.checkClosure(start)
+		return start
+	}
+	used[which] = true
+
+	remaining := append([]signed(nil), alternatives[which]...)
+	e := start
+	for {
+		matched := false
+		for i, c := range remaining {
+			if e.parsed && c.equal(e.formula) {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(e.j.Line, "%s does not follow from line %d, %s", e.formula, line, premise.formula)
+			v.checkClosure(e)
+			return e
+		}
+		v.checkClosure(e)
+		if len(remaining) == 0 || len(e.children) != 1 {
+			break
+		}
+		next := e.children[0]
+		if next.j.Premise == nil || *next.j.Premise != line {
+			break
+		}
+		e = next
+	}
+
+	// A regular tableau leaves out components already on the branch.
+	for i := 0; i < len(remaining); {
+		if v.onBranch(e, remaining[i]) {
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			continue
+		}
+		i++
+	}
+
+	if len(remaining) > 0 && !(e.leaf() && e.j.Closed) {
+		var missing []string
+		for _, c := range remaining {
+			missing = append(missing, c.String())
+		}
+		v.fail(e.j.Line, "inference from line %d is missing %v", line, missing)
+	}
+	return e
+}
+
+// checkInstance checks that start, subjoined below e, is an instance of
+// quantified formula premise. Universal formulas signed T, and
+// existential formulas signed F, can have instances for any term. The
+// others need a new parameter, one that doesn't appear above start.
+func (v *verifier) checkInstance(e, start, premise *entry, branches int) {
+	line := premise.j.Line
+	defer func() {
+		v.checkClosure(start)
+		v.checkBelow(start)
+	}()
+	if branches != 1 {
+		v.fail(start.j.Line, "line %d, %s, doesn't split branches", line, premise.formula)
+		return
+	}
+	if !start.parsed {
+		return
+	}
+
+	tree, ok := v.sameSign(start, premise)
+	if !ok {
+		v.fail(start.j.Line, "%s does not follow from line %d, %s", start.formula, line, premise.formula)
+		return
+	}
+	if start.formula.world != premise.formula.world {
+		v.fail(start.j.Line, "%s is not in the same world as line %d, %s", start.formula, line, premise.formula)
+		return
+	}
+
+	q := premise.formula.tree
+	term, ok := node.MatchInstance(q.Left, q.Ident, tree)
+	if !ok {
+		v.fail(start.j.Line, "%s is not an instance of line %d, %s", start.formula, line, premise.formula)
+		return
+	}
+	if (q.Op == lexer.FORALL) == premise.formula.sign || term == nil {
+		return // γ, or the variable doesn't occur
+	}
+	if len(term.Args) > 0 {
+		v.fail(start.j.Line, "instance of line %d, %s, needs a new parameter, not %s", line, premise.formula, node.ExpressionToString(term))
+		return
+	}
+	names := make(map[string]bool)
+	for p := e; p != nil; p = p.parent {
+		if p.parsed {
+			node.Names(p.formula.tree, names)
+		}
+	}
+	if names[term.Ident] {
+		v.fail(start.j.Line, "instance of line %d, %s, needs a new parameter, %s appears above it", line, premise.formula, term.Ident)
+	}
+}
+
+// sameSign gives back the parse tree of start's formula, with the
+// sign of premise. An unsigned tableau has T: ~X as F: X. SameSign
+// gives back false if start can't have premise's sign.
+func (v *verifier) sameSign(start, premise *entry) (*node.Node, bool) {
+	tree := start.formula.tree
+	if start.formula.sign != premise.formula.sign {
+		if !v.unsigned || start.formula.sign {
+			return nil, false
+		}
+		tree = node.NewOpNode(lexer.NOT)
+		tree.Left = start.formula.tree
+	}
+	return tree, true
+}
+
+// worldComponents gives back the alternatives that s's rule allows
+// subjoining in other worlds, and true if they go in a world that s's
+// world sees, false if they go in a new one. Modal formulas have rules
+// like that, and so do intuitionistic ~X and X > Y. WorldComponents
+// gives back nil for formulas whose rules stay in s's world.
+func (v *verifier) worldComponents(s signed) ([][]signed, bool) {
+	t := s.tree
+	intuitionistic := v.modal == "intuitionistic"
+	var alternatives [][]signed
+	var necessary bool
+	switch {
+	case lexer.Modality(t.Op):
+		necessary = (t.Op == lexer.BOX) == s.sign
+		alternatives = [][]signed{{newSigned(s.sign, t.Left)}}
+	case intuitionistic && t.Op == lexer.NOT:
+		necessary = s.sign
+		alternatives = [][]signed{{newSigned(!s.sign, t.Left)}}
+	case intuitionistic && t.Op == lexer.IMPLIES && s.sign:
+		necessary = true
+		alternatives = [][]signed{{newSigned(false, t.Left)}, {newSigned(true, t.Right)}}
+	case intuitionistic && t.Op == lexer.IMPLIES:
+		alternatives = [][]signed{{newSigned(true, t.Left), newSigned(false, t.Right)}}
+	default:
+		return nil, false
+	}
+	if s.unsigned {
+		for _, alternative := range alternatives {
+			for i, c := range alternative {
+				alternative[i] = newUnsigned(c.sign, c.tree)
+			}
+		}
+	}
+	return alternatives, necessary
+}
+
+// components gives back the alternatives that the rule for s allows
+// subjoining in s's world. Intuitionistic X = Y is (X > Y) & (Y > X).
+func (v *verifier) components(s signed) [][]signed {
+	if v.modal != "intuitionistic" || s.tree.Op != lexer.EQUIV {
+		return components(s)
+	}
+	forward := node.NewOpNode(lexer.IMPLIES)
+	forward.Left, forward.Right = s.tree.Left, s.tree.Right
+	backward := node.NewOpNode(lexer.IMPLIES)
+	backward.Left, backward.Right = s.tree.Right, s.tree.Left
+	var alternatives [][]signed
+	if s.sign {
+		alternatives = [][]signed{{newSigned(true, forward), newSigned(true, backward)}}
+	} else {
+		alternatives = [][]signed{{newSigned(false, forward)}, {newSigned(false, backward)}}
+	}
+	for _, alternative := range alternatives {
+		for i := range alternative {
+			alternative[i].world = s.world
+		}
+	}
+	return alternatives
+}
+
+// checkWorld checks that the inferences subjoined below e, starting
+// at starts, are all in one world, a world on the branch that premise's
+// world sees if necessary is true, a new world that premise's world sees
+// otherwise. CheckWorld puts that world in alternatives, giving back
+// false if the world is wrong.
+func (v *verifier) checkWorld(e *entry, starts []*entry, premise *entry, alternatives [][]signed, necessary bool) bool {
+	line := premise.j.Line
+	world, from := starts[0].j.World, premise.formula.world
+	for _, start := range starts[1:] {
+		if start.j.World != world {
+			v.fail(start.j.Line, "both branches of a split from line %d have to be in world %s", line, world)
+			return false
+		}
+	}
+
+	onBranch := false
+	for p := e; p != nil; p = p.parent {
+		if p.j.World == world {
+			onBranch = true
+		}
+	}
+	if necessary && (!onBranch || !sees(v.modal, from, world)) {
+		v.fail(starts[0].j.Line, "world %s of line %d doesn't see world %s on the branch", from, line, world)
+		return false
+	}
+	if !necessary && (onBranch || !strings.HasPrefix(world, from+".") || strings.Contains(world[len(from)+1:], ".")) {
+		v.fail(starts[0].j.Line, "line %d, %s, needs a new world that world %s sees, not %s", line, premise.formula, from, world)
+		return false
+	}
+
+	for _, alternative := range alternatives {
+		for i := range alternative {
+			alternative[i].world = world
+		}
+	}
+	return true
+}
+
+// validWorld returns true if world is 1, or a world like 1.2.1.
+func validWorld(world string) bool {
+	parts := strings.Split(world, ".")
+	if parts[0] != "1" {
+		return false
+	}
+	for _, part := range parts[1:] {
+		if n, err := strconv.Atoi(part); err != nil || n < 1 || part != strconv.Itoa(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// sees returns true if world sees other in modal logic system:
+// in K, other has to be a world made up for world, T adds world
+// itself, S4 any world made up for other worlds it sees, S5 anything.
+// Intuitionistic logic has worlds seeing each other the S4 way.
+func sees(system, world, other string) bool {
+	child := strings.HasPrefix(other, world+".")
+	switch system {
+	case "K":
+		return child && !strings.Contains(other[len(world)+1:], ".")
+	case "T":
+		return other == world || child && !strings.Contains(other[len(world)+1:], ".")
+	case "S4", "intuitionistic":
+		return other == world || child
+	}
+	return true
+}
+
+// skipRuns keeps checking below formulas whose inference was wrong.
+func (v *verifier) skipRuns(starts []*entry) {
+	for _, start := range starts {
+		v.checkClosure(start)
+		v.checkBelow(start)
+	}
+}
+
+// checkClosure verifies a claim that e closes its branch.
+func (v *verifier) checkClosure(e *entry) {
+	if e.j.Contradicts == nil {
+		if e.j.Closed {
+			v.fail(e.j.Line, "claims to close its branch without contradicting anything")
+		}
+		return
+	}
+	if !e.leaf() {
+		v.fail(e.j.Line, "closes its branch, but has formulas below it")
+	}
+	other := e.parent.ancestorOrNil(*e.j.Contradicts)
+	if other == nil {
+		v.fail(e.j.Line, "contradicts line %d, which is not above it on its branch", *e.j.Contradicts)
+		return
+	}
+	if !e.parsed || !other.parsed {
+		return
+	}
+	if v.logic != truthtable.Classical {
+		// Signs of the same formula with no value in common.
+		if e.formula.text != other.formula.text || values(e, e.formula.text) != 0 {
+			v.fail(e.j.Line, "%s does not contradict line %d, %s", e.formula, other.j.Line, other.formula)
+		}
+		return
+	}
+	world := e.formula.world == other.formula.world
+	if v.modal == "intuitionistic" {
+		// T: X holds in every world its world sees.
+		t, f := e, other
+		if !t.formula.sign {
+			t, f = f, t
+		}
+		world = sees(v.modal, t.formula.world, f.formula.world)
+	}
+	if e.formula.sign == other.formula.sign || e.formula.text != other.formula.text || !world {
+		v.fail(e.j.Line, "%s does not contradict line %d, %s", e.formula, other.j.Line, other.formula)
+	}
+}
+
+// onBranch returns true if formula s appears on the branch from e up
+// to the root, e included. In intuitionistic logic, T: s in a world that
+// sees s's world counts.
+func (v *verifier) onBranch(e *entry, s signed) bool {
+	if v.modal != "intuitionistic" || !s.sign {
+		return e.onBranch(s)
+	}
+	for p := e; p != nil; p = p.parent {
+		f := p.formula
+		if p.parsed && f.sign && f.text == s.text && sees(v.modal, f.world, s.world) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *entry) ancestorOrNil(line int) *entry {
+	if e == nil {
+		return nil
+	}
+	return e.ancestor(line)
+}
+
+// checkVerdict compares the proof's claim about every branch closing
+// with the leaves of the tableau.
+func (v *verifier) checkVerdict(proof *tableaux.JSONProof, root *entry) {
+	openLeaves := 0
+	var count func(e *entry)
+	count = func(e *entry) {
+		if e.leaf() && e.j.Contradicts == nil {
+			openLeaves++
+		}
+		for _, child := range e.children {
+			count(child)
+		}
+	}
+	count(root)
+
+	if proof.Closed && openLeaves > 0 {
+		v.fail(-1, "proof claims every branch closed, but %d branch(es) are open", openLeaves)
+	}
+	if !proof.Closed && openLeaves == 0 {
+		v.fail(-1, "proof claims an open branch, but every branch closed")
+	}
+}
@@ -0,0 +1,180 @@
+package checker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/parser"
+	"tableaux-in-go/src/tableaux"
+	"tableaux-in-go/src/truthtable"
+)
+
+// openTestdata opens file name in testdata, failing the test if
+// it can't.
+func openTestdata(t *testing.T, name string) *os.File {
+	t.Helper()
+	fin, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fin
+}
+
+// readProof reads a proof that "tableaux -json" wrote in testdata.
+func readProof(t *testing.T, name string) *tableaux.JSONProof {
+	t.Helper()
+	fin := openTestdata(t, name)
+	defer fin.Close()
+	proof, err := ReadJSON(fin)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return proof
+}
+
+// line finds the formula numbered line in the tableau below j.
+func line(j *tableaux.JSONTnode, n int) *tableaux.JSONTnode {
+	if j.Line == n {
+		return j
+	}
+	for _, child := range j.Children {
+		if found := line(child, n); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// checkMistakes fails the test unless mistakes are the ones in want,
+// in order, each containing the corresponding string.
+func checkMistakes(t *testing.T, name string, mistakes []*StepError, want []string) {
+	t.Helper()
+	if len(mistakes) != len(want) {
+		t.Errorf("%s: mistakes %v, want %q", name, mistakes, want)
+		return
+	}
+	for i, mistake := range mistakes {
+		if !strings.Contains(mistake.Error(), want[i]) {
+			t.Errorf("%s: %v, want %q", name, mistake, want[i])
+		}
+	}
+}
+
+var proofFiles = []string{"syllogism.json", "open.json", "unsigned.json", "s4.json"}
+
+func TestVerifyFiles(t *testing.T) {
+	for _, name := range proofFiles {
+		if errs := Verify(readProof(t, name)); len(errs) > 0 {
+			t.Errorf("%s: %v", name, errs)
+		}
+	}
+}
+
+// Tampered proofs, and what Verify finds wrong with them first.
+var tamperTests = []struct {
+	file   string
+	tamper func(*tableaux.JSONProof)
+	want   string
+}{
+	{"syllogism.json", func(p *tableaux.JSONProof) { p.Closed = false }, "claims an open branch"},
+	{"open.json", func(p *tableaux.JSONProof) { p.Closed = true }, "claims every branch closed"},
+	{"syllogism.json", func(p *tableaux.JSONProof) { p.Consequence = "r > p" }, "expected problem formula"},
+	{"syllogism.json", func(p *tableaux.JSONProof) { line(p.Tableau, 3).Formula = "q" }, "line 3: F: q does not follow from line 0"},
+	{"syllogism.json", func(p *tableaux.JSONProof) { line(p.Tableau, 9).Sign = false }, "line 9: F: p does not follow from line 2"},
+	{"syllogism.json", func(p *tableaux.JSONProof) {
+		contradicts := 5
+		line(p.Tableau, 9).Contradicts = &contradicts
+	}, "line 9: T: p does not contradict line 5"},
+	{"open.json", func(p *tableaux.JSONProof) { line(p.Tableau, 4).Closed = true }, "line 4: claims to close its branch"},
+	{"open.json", func(p *tableaux.JSONProof) { p.Modal = "K4" }, `unknown modal logic "K4"`},
+	{"unsigned.json", func(p *tableaux.JSONProof) { line(p.Tableau, 0).Sign = false }, "line 0: formula signed F in an unsigned tableau"},
+	{"s4.json", func(p *tableaux.JSONProof) { p.Modal = "K" }, "line 3: world 1 of line 1 doesn't see world 1"},
+}
+
+func TestVerifyTampered(t *testing.T) {
+	for _, test := range tamperTests {
+		proof := readProof(t, test.file)
+		test.tamper(proof)
+		err := Check(proof)
+		if err == nil {
+			t.Errorf("%s: tampered proof checks out, want %q", test.file, test.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: %v, want %q", test.file, err, test.want)
+		}
+	}
+}
+
+// Tableaux the tableaux package builds, in every kind of tableau,
+// check out, whether they close or not, pruned or not.
+func TestVerifyBuilt(t *testing.T) {
+	problems := [][]string{
+		{"p > p"},
+		{"p > q"},
+		{"~(p & q) = (~p | ~q)"},
+		{"p > q", "q > r", "p > r"},
+		{"p > q", "q", "p"},
+		{"p", "~p", "q"},
+	}
+	modal := [][]string{{"[]p > p"}, {"[]p > [][]p"}, {"<>p > []<>p"}, {"p | ~p"}, {"~~~p > ~p"}}
+	firstOrder := [][]string{{"forall x.P(x) > P(c)"}, {"exists x. P(x) > forall x. P(x)"}}
+
+	opts := []tableaux.Options{{}, {Unsigned: true}, {Regular: true}, {Unsigned: true, Regular: true}}
+	for _, system := range []tableaux.System{tableaux.SystemK, tableaux.SystemT, tableaux.SystemS4, tableaux.SystemS5, tableaux.Intuitionistic} {
+		opts = append(opts, tableaux.Options{Modal: system})
+	}
+	for _, l := range []truthtable.Logic{truthtable.StrongKleene, truthtable.Lukasiewicz, truthtable.LP} {
+		opts = append(opts, tableaux.Options{Logic: l})
+	}
+
+	for _, o := range opts {
+		formulas := problems
+		if o.Modal != tableaux.NonModal {
+			formulas = modal
+		}
+		if o == (tableaux.Options{}) {
+			formulas = append(formulas, firstOrder...)
+		}
+		for _, problem := range formulas {
+			var trees []*node.Node
+			for _, formula := range problem {
+				tree, err := parser.ParseString(formula)
+				if err != nil {
+					t.Fatalf("%q: %v", formula, err)
+				}
+				trees = append(trees, tree)
+			}
+			root, _ := tableaux.Setup(trees, o)
+			result, _ := root.ExpandContext(context.Background(), tableaux.Limits{MaxInstances: tableaux.DefaultMaxInstances})
+			proof := &tableaux.JSONProof{
+				Unsigned: o.Unsigned,
+				Modal:    o.Modal.String(),
+				Logic:    o.Logic.String(),
+				Closed:   result == tableaux.Closed,
+				Tableau:  root.JSON(),
+			}
+			if len(problem) == 1 {
+				proof.Formula = problem[0]
+			} else {
+				proof.Hypotheses = problem[:len(problem)-1]
+				proof.Consequence = problem[len(problem)-1]
+			}
+			if err := Check(proof); err != nil {
+				t.Errorf("%q, %+v: %v", problem, o, err)
+			}
+			if o.Logic != truthtable.Classical {
+				continue // Many-signed tableaux don't get pruned.
+			}
+			root.Prune()
+			proof.Tableau = root.JSON()
+			if err := Check(proof); err != nil {
+				t.Errorf("%q, %+v, pruned: %v", problem, o, err)
+			}
+		}
+	}
+}
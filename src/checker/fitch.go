@@ -0,0 +1,553 @@
+package checker
+
+// Fitch-style natural deduction proofs, in a text format like this.
+// See README.md for a description with examples.
+//
+//   # Comments start with '#'
+//   assume: p > q
+//   prove: ~q > ~p
+//   1. p > q          Premise
+//   2. | ~q           Assumption
+//   3. | | p          Assumption
+//   4. | | q          →E 1, 3
+//   5. | | ⊥          ¬E 4, 2
+//   6. | ~p           ¬I 3-5
+//   7. ~q > ~p        →I 2-6
+//
+// Each "|" in front of a formula puts it one subproof further in. An
+// Assumption starts a subproof, an Assumption right after a subproof
+// at the same depth starts another one. The rule comes after the
+// formula, then the lines it cites, "N" for a line, "N-M" for the
+// subproof from line N to line M. The parser doesn't know ⊥, so it
+// has to be a formula all by itself, written "⊥" or "#".
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/parser"
+)
+
+// FitchProof is a natural deduction proof, as ReadFitch reads it.
+type FitchProof struct {
+	Premises []string // From "assume:" lines
+	Goal     string   // From the "prove:" line
+	Lines    []*FitchLine
+}
+
+// FitchLine is one numbered line of a natural deduction proof.
+type FitchLine struct {
+	Number    int
+	Depth     int // How many subproofs the line is in
+	Formula   string
+	Rule      string // The way the proof writes it, like "→E" or "MP"
+	Citations []Citation
+}
+
+// Citation is a line a rule cites, or a subproof, lines From to To.
+type Citation struct {
+	From, To int
+	Subproof bool
+}
+
+func (c Citation) String() string {
+	if c.Subproof {
+		return fmt.Sprintf("%d-%d", c.From, c.To)
+	}
+	return strconv.Itoa(c.From)
+}
+
+var (
+	fitchLineRE = regexp.MustCompile(`^(\d+)\.\s*(.*)$`)
+	ruleRE      = regexp.MustCompile(`^(.*\S)\s+([^\s\d,][^\s,]*)(\s+\d[\d\s,–-]*)?$`)
+	citationRE  = regexp.MustCompile(`^(\d+)(?:\s*[-–]\s*(\d+))?$`)
+)
+
+// ReadFitch parses a natural deduction proof in the text format,
+// giving back a proof that CheckFitch can look at.
+func ReadFitch(r io.Reader) (*FitchProof, error) {
+	proof := &FitchProof{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if key, value, ok := keyword(text); ok {
+			switch key {
+			case "assume":
+				proof.Premises = append(proof.Premises, value)
+			case "prove":
+				proof.Goal = value
+			default:
+				return nil, fmt.Errorf("input line %d: natural deduction proofs don't have a %s", lineNo, key)
+			}
+			continue
+		}
+
+		line, err := parseFitchLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("input line %d: %v", lineNo, err)
+		}
+		if want := len(proof.Lines) + 1; line.Number != want {
+			return nil, fmt.Errorf("input line %d: line %d should be line %d", lineNo, line.Number, want)
+		}
+		proof.Lines = append(proof.Lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(proof.Lines) == 0 {
+		return nil, fmt.Errorf("no proof")
+	}
+	if proof.Goal == "" {
+		return nil, fmt.Errorf("no \"prove:\" line stating the goal")
+	}
+	return proof, nil
+}
+
+// parseFitchLine parses "4. | | q  →E 1, 3" into a FitchLine.
+func parseFitchLine(text string) (*FitchLine, error) {
+	m := fitchLineRE.FindStringSubmatch(text)
+	if m == nil {
+		return nil, fmt.Errorf("expected \"N. formula rule\", found %q", text)
+	}
+	line := &FitchLine{}
+	line.Number, _ = strconv.Atoi(m[1])
+	rest := m[2]
+	for strings.HasPrefix(rest, "|") {
+		line.Depth++
+		rest = strings.TrimSpace(rest[1:])
+	}
+
+	j := ruleRE.FindStringSubmatch(rest)
+	if j == nil {
+		return nil, fmt.Errorf("no rule for %q", rest)
+	}
+	line.Formula, line.Rule = j[1], j[2]
+	if strings.TrimSpace(j[3]) == "" {
+		return line, nil
+	}
+	for _, cited := range strings.Split(j[3], ",") {
+		c := citationRE.FindStringSubmatch(strings.TrimSpace(cited))
+		if c == nil {
+			return nil, fmt.Errorf("can't make out citation %q", strings.TrimSpace(cited))
+		}
+		from, _ := strconv.Atoi(c[1])
+		citation := Citation{From: from, To: from}
+		if c[2] != "" {
+			citation.To, _ = strconv.Atoi(c[2])
+			citation.Subproof = true
+		}
+		line.Citations = append(line.Citations, citation)
+	}
+	return line, nil
+}
+
+// parseFitchFormula parses a formula of a natural deduction proof,
+// ⊥ included.
+func parseFitchFormula(text string) (*node.Node, error) {
+	if text == "⊥" || text == "#" {
+		return node.Truth(false), nil
+	}
+	return parser.ParseString(text)
+}
+
+// fitchEntry is a line of a proof being checked.
+type fitchEntry struct {
+	line  *FitchLine
+	tree  *node.Node // nil if the formula doesn't parse
+	scope []int      // Assumptions of the subproofs the line is in, outermost first
+}
+
+// subproof runs from an assumption to the last line in it, not
+// counting lines of subproofs inside it.
+type subproof struct {
+	first, last *fitchEntry
+}
+
+// fitchRule checks a rule application: conclusion from the cited lines
+// and subproofs, in the order the proof cites them.
+type fitchRule struct {
+	lines, subproofs int
+	check            func(conclusion *node.Node, lines []*node.Node, subproofs []*subproof) bool
+}
+
+// fitchRules has the rules of natural deduction for propositional
+// logic, introduction and elimination rules for each connective, ⊥E,
+// RAA, ¬¬E and reiteration.
+var fitchRules = map[string]fitchRule{
+	"∧I": {2, 0, func(c *node.Node, l []*node.Node, _ []*subproof) bool {
+		return c.Op == lexer.AND && (same(c.Left, l[0]) && same(c.Right, l[1]) || same(c.Left, l[1]) && same(c.Right, l[0]))
+	}},
+	"∧E": {1, 0, func(c *node.Node, l []*node.Node, _ []*subproof) bool {
+		return l[0].Op == lexer.AND && (same(c, l[0].Left) || same(c, l[0].Right))
+	}},
+	"∨I": {1, 0, func(c *node.Node, l []*node.Node, _ []*subproof) bool {
+		return c.Op == lexer.OR && (same(c.Left, l[0]) || same(c.Right, l[0]))
+	}},
+	"∨E": {1, 2, func(c *node.Node, l []*node.Node, s []*subproof) bool {
+		x, y := s[0], s[1]
+		if l[0].Op != lexer.OR || !same(x.last.tree, c) || !same(y.last.tree, c) {
+			return false
+		}
+		return same(x.first.tree, l[0].Left) && same(y.first.tree, l[0].Right) ||
+			same(x.first.tree, l[0].Right) && same(y.first.tree, l[0].Left)
+	}},
+	"→I": {0, 1, func(c *node.Node, _ []*node.Node, s []*subproof) bool {
+		return c.Op == lexer.IMPLIES && same(c.Left, s[0].first.tree) && same(c.Right, s[0].last.tree)
+	}},
+	"→E": {2, 0, func(c *node.Node, l []*node.Node, _ []*subproof) bool {
+		return either(l, func(x, y *node.Node) bool {
+			return x.Op == lexer.IMPLIES && same(x.Left, y) && same(x.Right, c)
+		})
+	}},
+	"↔I": {0, 2, func(c *node.Node, _ []*node.Node, s []*subproof) bool {
+		if c.Op != lexer.EQUIV {
+			return false
+		}
+		x, y := s[0], s[1]
+		return same(x.first.tree, c.Left) && same(x.last.tree, c.Right) && same(y.first.tree, c.Right) && same(y.last.tree, c.Left) ||
+			same(x.first.tree, c.Right) && same(x.last.tree, c.Left) && same(y.first.tree, c.Left) && same(y.last.tree, c.Right)
+	}},
+	"↔E": {2, 0, func(c *node.Node, l []*node.Node, _ []*subproof) bool {
+		return either(l, func(x, y *node.Node) bool {
+			return x.Op == lexer.EQUIV && (same(x.Left, y) && same(x.Right, c) || same(x.Right, y) && same(x.Left, c))
+		})
+	}},
+	"¬I": {0, 1, func(c *node.Node, _ []*node.Node, s []*subproof) bool {
+		return c.Op == lexer.NOT && same(c.Left, s[0].first.tree) && falsum(s[0].last.tree)
+	}},
+	"¬E": {2, 0, func(c *node.Node, l []*node.Node, _ []*subproof) bool {
+		return falsum(c) && either(l, func(x, y *node.Node) bool {
+			return x.Op == lexer.NOT && same(x.Left, y)
+		})
+	}},
+	"⊥E": {1, 0, func(c *node.Node, l []*node.Node, _ []*subproof) bool {
+		return falsum(l[0])
+	}},
+	"RAA": {0, 1, func(c *node.Node, _ []*node.Node, s []*subproof) bool {
+		assumption := s[0].first.tree
+		return assumption.Op == lexer.NOT && same(assumption.Left, c) && falsum(s[0].last.tree)
+	}},
+	"¬¬E": {1, 0, func(c *node.Node, l []*node.Node, _ []*subproof) bool {
+		return l[0].Op == lexer.NOT && l[0].Left.Op == lexer.NOT && same(l[0].Left.Left, c)
+	}},
+	"R": {1, 0, func(c *node.Node, l []*node.Node, _ []*subproof) bool {
+		return same(c, l[0])
+	}},
+}
+
+// ruleNames has other ways to write the rules, in upper case,
+// ASCII connectives and traditional names.
+var ruleNames = map[string]string{
+	"&I":         "∧I",
+	"&E":         "∧E",
+	"|I":         "∨I",
+	"VI":         "∨I",
+	"|E":         "∨E",
+	"VE":         "∨E",
+	">I":         "→I",
+	"->I":        "→I",
+	"CP":         "→I",
+	">E":         "→E",
+	"->E":        "→E",
+	"MP":         "→E",
+	"=I":         "↔I",
+	"<->I":       "↔I",
+	"=E":         "↔E",
+	"<->E":       "↔E",
+	"~I":         "¬I",
+	"~E":         "¬E",
+	"#E":         "⊥E",
+	"X":          "⊥E",
+	"EFQ":        "⊥E",
+	"IP":         "RAA",
+	"~~E":        "¬¬E",
+	"DNE":        "¬¬E",
+	"REIT":       "R",
+	"PR":         "Premise",
+	"PREMISE":    "Premise",
+	"AS":         "Assumption",
+	"ASSUME":     "Assumption",
+	"ASSUMPTION": "Assumption",
+	"HYP":        "Assumption",
+}
+
+// ruleName gives back the name fitchRules has for rule, or "Premise"
+// or "Assumption".
+func ruleName(rule string) string {
+	if name, ok := ruleNames[strings.ToUpper(rule)]; ok {
+		return name
+	}
+	return strings.ToUpper(rule)
+}
+
+func same(x, y *node.Node) bool {
+	return node.ExpressionToString(x) == node.ExpressionToString(y)
+}
+
+func falsum(x *node.Node) bool {
+	value, ok := x.Constant()
+	return ok && !value
+}
+
+// either returns true if match holds for two lines in either order.
+func either(lines []*node.Node, match func(x, y *node.Node) bool) bool {
+	return match(lines[0], lines[1]) || match(lines[1], lines[0])
+}
+
+// fitchChecker keeps track of a proof while CheckFitch goes through it.
+type fitchChecker struct {
+	errs      []*StepError
+	entries   []*fitchEntry
+	subproofs map[int]*subproof // By assumption's line number
+}
+
+func (f *fitchChecker) fail(line int, format string, args ...interface{}) {
+	f.errs = append(f.errs, &StepError{Line: line, Reason: fmt.Sprintf(format, args...)})
+}
+
+// CheckFitch checks every line of a natural deduction proof: that the
+// rule it names gives its formula from the lines and subproofs it
+// cites, that it only cites lines and subproofs it can see, and that
+// the proof ends with the goal outside of any subproof. It gives back
+// every mistake it finds, ordered by line number. Problems with the
+// proof as a whole (line -1) come first.
+func CheckFitch(proof *FitchProof) []*StepError {
+	f := &fitchChecker{subproofs: make(map[int]*subproof)}
+
+	var premises []*node.Node
+	for _, text := range proof.Premises {
+		tree, err := parser.ParseString(text)
+		if err != nil {
+			f.fail(-1, "premise %q: %v", text, err)
+			continue
+		}
+		premises = append(premises, tree)
+	}
+	goal, err := parser.ParseString(proof.Goal)
+	if err != nil {
+		f.fail(-1, "goal %q: %v", proof.Goal, err)
+	}
+
+	var stack []*subproof // Subproofs open at the current line
+	for i, line := range proof.Lines {
+		e := &fitchEntry{line: line}
+		f.entries = append(f.entries, e)
+		if e.tree, err = parseFitchFormula(line.Formula); err != nil {
+			f.fail(line.Number, "%v", err)
+			e.tree = nil
+		}
+
+		rule := ruleName(line.Rule)
+		assumption := rule == "Assumption"
+		switch {
+		case assumption && line.Depth == len(stack)+1:
+		case assumption && line.Depth == len(stack) && len(stack) > 0:
+			stack = stack[:len(stack)-1] // A subproof right after another
+		case assumption:
+			f.fail(line.Number, "an assumption starts a subproof, one | in from the line before")
+		case line.Depth > len(stack):
+			f.fail(line.Number, "no assumption starts this subproof")
+		case line.Depth < len(stack):
+			stack = stack[:line.Depth]
+		}
+		if assumption {
+			stack = append(stack, &subproof{first: e})
+			f.subproofs[line.Number] = stack[len(stack)-1]
+		}
+		for _, s := range stack {
+			e.scope = append(e.scope, s.first.line.Number)
+		}
+		if len(stack) > 0 {
+			stack[len(stack)-1].last = e
+		}
+
+		switch rule {
+		case "Premise":
+			f.checkPremise(e, premises, i)
+		case "Assumption":
+			if len(line.Citations) > 0 {
+				f.fail(line.Number, "an assumption doesn't cite anything")
+			}
+		default:
+			f.checkRule(e, rule)
+		}
+	}
+
+	last := f.entries[len(f.entries)-1]
+	switch {
+	case len(last.scope) > 0:
+		f.fail(-1, "proof ends inside the subproof starting at line %d", last.scope[0])
+	case goal != nil && last.tree != nil && !same(last.tree, goal):
+		f.fail(-1, "proof ends with %s, not the goal, %s", node.ExpressionToString(last.tree), node.ExpressionToString(goal))
+	}
+
+	sort.SliceStable(f.errs, func(i, j int) bool { return f.errs[i].Line < f.errs[j].Line })
+	return f.errs
+}
+
+// checkPremise checks that premise line e, the ith line of the proof,
+// comes before any other kind of line, and is one of the premises.
+func (f *fitchChecker) checkPremise(e *fitchEntry, premises []*node.Node, i int) {
+	if len(e.line.Citations) > 0 {
+		f.fail(e.line.Number, "a premise doesn't cite anything")
+	}
+	if i > 0 && ruleName(f.entries[i-1].line.Rule) != "Premise" || e.line.Depth > 0 {
+		f.fail(e.line.Number, "premises come first, outside of any subproof")
+	}
+	if e.tree == nil {
+		return
+	}
+	for _, premise := range premises {
+		if same(e.tree, premise) {
+			return
+		}
+	}
+	f.fail(e.line.Number, "%s isn't one of the premises", node.ExpressionToString(e.tree))
+}
+
+// checkRule checks that rule gives line e's formula from the lines and
+// subproofs it cites.
+func (f *fitchChecker) checkRule(e *fitchEntry, rule string) {
+	r, ok := fitchRules[rule]
+	if !ok {
+		f.fail(e.line.Number, "unknown rule %q", e.line.Rule)
+		return
+	}
+
+	var lines []*node.Node
+	var subproofs []*subproof
+	cited := true // Every citation checks out and has a formula
+	for _, c := range e.line.Citations {
+		if !c.Subproof {
+			p := f.citeLine(e, c.From)
+			if p == nil || p.tree == nil {
+				cited = false
+				continue
+			}
+			lines = append(lines, p.tree)
+			continue
+		}
+		s := f.citeSubproof(e, c)
+		if s == nil || s.first.tree == nil || s.last.tree == nil {
+			cited = false
+			continue
+		}
+		subproofs = append(subproofs, s)
+	}
+
+	nLines, nSubproofs := 0, 0
+	for _, c := range e.line.Citations {
+		if c.Subproof {
+			nSubproofs++
+		} else {
+			nLines++
+		}
+	}
+	if nLines != r.lines || nSubproofs != r.subproofs {
+		f.fail(e.line.Number, "%s cites %s", rule, count(r.lines, r.subproofs))
+		return
+	}
+	if !cited || e.tree == nil {
+		return
+	}
+	if !r.check(e.tree, lines, subproofs) {
+		var texts []string
+		for _, c := range e.line.Citations {
+			texts = append(texts, c.String())
+		}
+		f.fail(e.line.Number, "%s doesn't give %s from %s", rule, node.ExpressionToString(e.tree), strings.Join(texts, ", "))
+	}
+}
+
+// count describes how many lines and subproofs a rule cites.
+func count(lines, subproofs int) string {
+	var parts []string
+	switch lines {
+	case 0:
+	case 1:
+		parts = append(parts, "1 line")
+	default:
+		parts = append(parts, fmt.Sprintf("%d lines", lines))
+	}
+	switch subproofs {
+	case 0:
+	case 1:
+		parts = append(parts, "1 subproof")
+	default:
+		parts = append(parts, fmt.Sprintf("%d subproofs", subproofs))
+	}
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, " and ")
+}
+
+// citeLine gives back the line numbered number, if line e can cite it:
+// an earlier line, not inside a subproof that's over by line e.
+func (f *fitchChecker) citeLine(e *fitchEntry, number int) *fitchEntry {
+	if number < 1 || number >= e.line.Number {
+		f.fail(e.line.Number, "can't cite line %d, only lines before this one", number)
+		return nil
+	}
+	p := f.entries[number-1]
+	if !within(p.scope, e.scope) {
+		f.fail(e.line.Number, "can't cite line %d, its subproof is over", number)
+		return nil
+	}
+	return p
+}
+
+// citeSubproof gives back the subproof c cites, if line e can cite it:
+// one that's over by line e, in a subproof line e is in.
+func (f *fitchChecker) citeSubproof(e *fitchEntry, c Citation) *subproof {
+	if c.To >= e.line.Number {
+		f.fail(e.line.Number, "can't cite %s, only lines before this one", c)
+		return nil
+	}
+	s, ok := f.subproofs[c.From]
+	if !ok {
+		f.fail(e.line.Number, "can't cite %s, no subproof starts at line %d", c, c.From)
+		return nil
+	}
+	if within(s.first.scope, e.scope) {
+		f.fail(e.line.Number, "can't cite subproof %s from inside it", c)
+		return nil
+	}
+	if s.last.line.Number != c.To {
+		f.fail(e.line.Number, "can't cite %s, the subproof starting at line %d ends at line %d", c, c.From, s.last.line.Number)
+		return nil
+	}
+	if !within(s.first.scope[:len(s.first.scope)-1], e.scope) {
+		f.fail(e.line.Number, "can't cite subproof %s, the subproof it's in is over", c)
+		return nil
+	}
+	return s
+}
+
+// within returns true if the subproofs of scope are all still open in
+// the subproofs of inner, scope is a prefix of inner.
+func within(scope, inner []int) bool {
+	if len(scope) > len(inner) {
+		return false
+	}
+	for i := range scope {
+		if scope[i] != inner[i] {
+			return false
+		}
+	}
+	return true
+}
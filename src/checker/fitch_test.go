@@ -0,0 +1,48 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+// Natural deduction proofs in testdata, and the mistakes CheckFitch
+// finds in them.
+var fitchTests = []struct {
+	file     string
+	mistakes []string
+}{
+	{"contrapositive.fitch", nil},
+	{"or-commutes.fitch", nil},
+	{"double-negation.fitch", nil},
+	{"wrong-rule.fitch", []string{"line 3: →E doesn't give p from 1, 2"}},
+	{"closed-subproof.fitch", []string{"line 5: can't cite line 2, its subproof is over"}},
+	{"not-the-goal.fitch", []string{"proof ends inside the subproof starting at line 1"}},
+	{"wrong-goal.fitch", []string{"proof ends with p, not the goal, q"}},
+	{"unknown-rule.fitch", []string{`line 1: unknown rule "Magic"`}},
+}
+
+func TestCheckFitch(t *testing.T) {
+	for _, test := range fitchTests {
+		fin := openTestdata(t, test.file)
+		proof, err := ReadFitch(fin)
+		fin.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", test.file, err)
+		}
+		checkMistakes(t, test.file, CheckFitch(proof), test.mistakes)
+	}
+}
+
+// Text that isn't a natural deduction proof.
+var badFitch = []string{
+	"prove: p\n1. p\n",
+	"prove: p\n2. p Premise\n",
+}
+
+func TestReadFitchErrors(t *testing.T) {
+	for _, text := range badFitch {
+		if _, err := ReadFitch(strings.NewReader(text)); err == nil {
+			t.Errorf("%q reads as a proof", text)
+		}
+	}
+}
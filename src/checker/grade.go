@@ -0,0 +1,164 @@
+package checker
+
+// Grading hand-written tableaux: beyond whether every step is valid,
+// did the student close every branch that could close, expand every
+// formula on open branches, and get the right answer?
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/parser"
+	"tableaux-in-go/src/tableaux"
+	"tableaux-in-go/src/truthtable"
+)
+
+// Report is the result of grading a tableau.
+type Report struct {
+	Mistakes      []*StepError // Invalid steps, missed closures, unexpanded formulas, etc
+	ClaimedClosed bool         // Student's verdict: every branch closes
+	Valid         bool         // Whether the problem really is a tautology or consequence
+	Decided       bool         // Whether Grade found out if it's Valid
+}
+
+// VerdictRight returns true if the student's verdict is the correct one.
+func (r *Report) VerdictRight() bool {
+	return r.Decided && r.ClaimedClosed == r.Valid
+}
+
+// Grade checks a tableau the way Verify does, and also looks for open
+// branches that contain a contradiction the student missed, and
+// formulas on open branches that never got expanded. It decides the
+// problem with a tableau of its own, independently of the student's,
+// to judge the student's verdict.
+func Grade(proof *tableaux.JSONProof) *Report {
+	v := &verifier{lines: make(map[int]*entry), unsigned: proof.Unsigned}
+	root := v.build(proof.Tableau, nil)
+
+	v.checkProblem(proof, root)
+	v.checkSteps(root)
+	v.checkVerdict(proof, root)
+	v.checkOpenBranches(root)
+
+	sort.SliceStable(v.errs, func(i, j int) bool { return v.errs[i].Line < v.errs[j].Line })
+
+	report := &Report{Mistakes: v.errs, ClaimedClosed: proof.Closed}
+	valid, err := valid(proof)
+	switch {
+	case err != nil:
+		report.Mistakes = append(report.Mistakes, &StepError{Line: -1, Reason: fmt.Sprintf("can't grade the verdict: %v", err)})
+	case proof.Closed != valid && valid:
+		report.Mistakes = append(report.Mistakes, &StepError{Line: -1, Reason: "verdict wrong: the problem has a closed tableau"})
+	case proof.Closed != valid:
+		report.Mistakes = append(report.Mistakes, &StepError{Line: -1, Reason: "verdict wrong: the problem has no closed tableau"})
+	}
+	report.Valid, report.Decided = valid, err == nil
+	return report
+}
+
+// checkOpenBranches looks at every branch not claimed closed for pairs
+// of contradictory formulas, and for formulas never expanded.
+func (v *verifier) checkOpenBranches(e *entry) {
+	for _, child := range e.children {
+		v.checkOpenBranches(child)
+	}
+	if !e.leaf() || e.j.Contradicts != nil {
+		return
+	}
+
+	seen := make(map[string]*entry)
+	for p := e; p != nil; p = p.parent {
+		if !p.parsed {
+			continue
+		}
+		seen[p.formula.String()] = p
+	}
+	for p := e; p != nil; p = p.parent {
+		if !p.parsed {
+			continue
+		}
+		complement := p.formula.complement()
+		if q, ok := seen[complement.String()]; ok && p.formula.sign {
+			v.fail(e.j.Line, "branch should close: line %d, %s, contradicts line %d, %s", p.j.Line, p.formula, q.j.Line, q.formula)
+			return
+		}
+	}
+
+	// Formulas with an inference from them somewhere on the branch.
+	expanded := make(map[int]bool)
+	for p := e; p != nil; p = p.parent {
+		if p.j.Premise != nil {
+			expanded[*p.j.Premise] = true
+		}
+	}
+	for p := e; p != nil; p = p.parent {
+		if p.parsed && p.formula.tree.Op != lexer.IDENT && !expanded[p.j.Line] && !e.subsumes(p.formula) {
+			v.fail(e.j.Line, "open branch never expands line %d, %s", p.j.Line, p.formula)
+		}
+	}
+}
+
+// subsumes returns true if the branch ending at e already has all the
+// components of one alternative of s's rule, so expanding s would add
+// nothing new, as in a regular tableau.
+func (e *entry) subsumes(s signed) bool {
+	for _, alternative := range components(s) {
+		all := true
+		for _, c := range alternative {
+			if !e.onBranch(c) {
+				all = false
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+// valid decides the problem with a tableau, the way "tableaux" would,
+// in the logic the proof is in, with the usual limit on instances of γ
+// formulas. It gives back an error if the tableau gives up.
+func valid(proof *tableaux.JSONProof) (bool, error) {
+	system, err := tableaux.ParseSystem(proof.Modal)
+	if err != nil {
+		return false, err
+	}
+	logic, err := truthtable.ParseLogic(proof.Logic)
+	if err != nil {
+		return false, err
+	}
+
+	formulas := append(append([]string(nil), proof.Hypotheses...), proof.Consequence)
+	if proof.Formula != "" {
+		formulas = []string{proof.Formula}
+	}
+	var trees []*node.Node
+	for _, formula := range formulas {
+		tree, err := parser.ParseString(formula)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case tree.Temporal():
+			return false, fmt.Errorf("%s has temporal operators", formula)
+		case tree.Modal() && system == tableaux.NonModal:
+			return false, fmt.Errorf("%s has modal operators, but the proof has no modal logic", formula)
+		case (tree.Modal() || tree.FirstOrder()) && system == tableaux.Intuitionistic:
+			return false, fmt.Errorf("intuitionistic logic is propositional, without modal operators, not %s", formula)
+		case (tree.Modal() || tree.FirstOrder()) && logic != truthtable.Classical:
+			return false, fmt.Errorf("three-valued logic is propositional, without modal operators, not %s", formula)
+		}
+		trees = append(trees, tree)
+	}
+
+	root, _ := tableaux.Setup(trees, tableaux.Options{Modal: system, Logic: logic})
+	result, err := root.ExpandContext(context.Background(), tableaux.Limits{MaxInstances: tableaux.DefaultMaxInstances})
+	if result == tableaux.Unknown {
+		return false, err
+	}
+	return result == tableaux.Closed, nil
+}
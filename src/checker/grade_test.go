@@ -0,0 +1,59 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+// Hand-written tableaux in testdata, the mistakes Grade finds in them,
+// and whether their verdicts are right.
+var gradeTests = []struct {
+	file         string
+	mistakes     []string
+	verdictRight bool
+}{
+	{"contrapositive.txt", nil, true},
+	{"unsigned.txt", nil, true},
+	{"wrong-verdict.txt", []string{
+		"proof claims an open branch, but every branch closed",
+		"verdict wrong: the problem has a closed tableau",
+	}, false},
+	{"missed-closure.txt", []string{
+		"line 7: branch should close: line 6, T: p, contradicts line 7, F: p",
+		"verdict wrong: the problem has a closed tableau",
+	}, false},
+	{"unexpanded.txt", []string{"line 3: open branch never expands line 2, F: p & q"}, true},
+	{"bad-step.txt", []string{"line 4: T: r does not follow from line 2, T: p & q"}, true},
+}
+
+func TestGrade(t *testing.T) {
+	for _, test := range gradeTests {
+		fin := openTestdata(t, test.file)
+		proof, err := ReadText(fin)
+		fin.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", test.file, err)
+		}
+		report := Grade(proof)
+		checkMistakes(t, test.file, report.Mistakes, test.mistakes)
+		if right := report.VerdictRight(); right != test.verdictRight {
+			t.Errorf("%s: verdict right %v, want %v", test.file, right, test.verdictRight)
+		}
+	}
+}
+
+// Text that isn't a tableau.
+var badTexts = []string{
+	"prove: p > p\n1. F: p > p\n3. T: p (1)\n4. F: p (1) x 3\nverdict: sideways\n",
+	"prove: p > p\n1. F: p > p\nfoo\nverdict: open\n",
+	"prove: p > p\nverdict: open\n",
+	"prove: p > p\n1. F: p > p\n",
+}
+
+func TestReadTextErrors(t *testing.T) {
+	for _, text := range badTexts {
+		if _, err := ReadText(strings.NewReader(text)); err == nil {
+			t.Errorf("%q reads as a tableau", text)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package checker
+
+// Hilbert-style axiomatic proofs, in a text format like this. See
+// README.md for a description with examples.
+//
+//   # Comments start with '#'
+//   axioms: Mendelson
+//   assume: p
+//   prove: q > p
+//   1. p                  Hyp
+//   2. p > (q > p)        A1
+//   3. q > p              MP 1, 2
+//
+// Each line is a hypothesis, an instance of an axiom schema, or follows
+// by modus ponens from two earlier lines. "axioms:" picks the schemas,
+// "axiom NAME: schema" adds one of the proof's own.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/parser"
+)
+
+// Axiom is an axiom schema, every identifier in it a schema variable.
+type Axiom struct {
+	Name   string
+	Schema string
+}
+
+// Axiom systems for implication and negation.
+var (
+	// Łukasiewicz's third system
+	Lukasiewicz = []Axiom{
+		{"A1", "(A > B) > ((B > C) > (A > C))"},
+		{"A2", "(~A > A) > A"},
+		{"A3", "A > (~A > B)"},
+	}
+	// Mendelson's system L, from "Introduction to Mathematical Logic"
+	Mendelson = []Axiom{
+		{"A1", "A > (B > A)"},
+		{"A2", "(A > (B > C)) > ((A > B) > (A > C))"},
+		{"A3", "(~B > ~A) > ((~B > A) > B)"},
+	}
+)
+
+// AxiomSystem gives back the axiom schemas of the named system,
+// Lukasiewicz (or Łukasiewicz) or Mendelson.
+func AxiomSystem(name string) ([]Axiom, error) {
+	switch strings.ToLower(name) {
+	case "lukasiewicz", "łukasiewicz":
+		return Lukasiewicz, nil
+	case "mendelson":
+		return Mendelson, nil
+	}
+	return nil, fmt.Errorf("unknown axiom system %q, not Lukasiewicz or Mendelson", name)
+}
+
+// HilbertProof is an axiomatic proof, as ReadHilbert reads it.
+type HilbertProof struct {
+	Axioms     []Axiom
+	Hypotheses []string // From "assume:" lines
+	Goal       string   // From the "prove:" line
+	Lines      []*HilbertLine
+}
+
+// HilbertLine is one numbered line of an axiomatic proof.
+type HilbertLine struct {
+	Number    int
+	Formula   string
+	Rule      string // An axiom's name, MP or Hyp
+	Citations []int
+}
+
+// ReadHilbert parses an axiomatic proof in the text format, giving
+// back a proof that CheckHilbert can look at. Without an "axioms:"
+// line or axioms of its own, the proof gets Mendelson's axioms.
+func ReadHilbert(r io.Reader) (*HilbertProof, error) {
+	proof := &HilbertProof{}
+	var system []Axiom
+	var own []Axiom
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if i := strings.Index(text, ":"); i > 0 && !fitchLineRE.MatchString(text) {
+			fields, value := strings.Fields(text[:i]), strings.TrimSpace(text[i+1:])
+			switch key := strings.ToLower(fields[0]); {
+			case len(fields) == 1 && key == "assume":
+				proof.Hypotheses = append(proof.Hypotheses, value)
+			case len(fields) == 1 && key == "prove":
+				proof.Goal = value
+			case len(fields) == 1 && key == "axioms":
+				var err error
+				if system, err = AxiomSystem(value); err != nil {
+					return nil, fmt.Errorf("input line %d: %v", lineNo, err)
+				}
+			case len(fields) <= 2 && key == "axiom":
+				name := fmt.Sprintf("A%d", len(own)+1)
+				if len(fields) == 2 {
+					name = fields[1]
+				}
+				own = append(own, Axiom{Name: name, Schema: value})
+			default:
+				return nil, fmt.Errorf("input line %d: expected \"assume:\", \"prove:\", \"axioms:\" or \"axiom NAME:\", found %q", lineNo, text)
+			}
+			continue
+		}
+
+		line, err := parseHilbertLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("input line %d: %v", lineNo, err)
+		}
+		if want := len(proof.Lines) + 1; line.Number != want {
+			return nil, fmt.Errorf("input line %d: line %d should be line %d", lineNo, line.Number, want)
+		}
+		proof.Lines = append(proof.Lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if system == nil && own == nil {
+		system = Mendelson
+	}
+	proof.Axioms = append(append([]Axiom(nil), system...), own...)
+	names := make(map[string]bool)
+	for _, axiom := range proof.Axioms {
+		if names[strings.ToUpper(axiom.Name)] {
+			return nil, fmt.Errorf("two axioms named %s", axiom.Name)
+		}
+		names[strings.ToUpper(axiom.Name)] = true
+	}
+
+	if len(proof.Lines) == 0 {
+		return nil, fmt.Errorf("no proof")
+	}
+	if proof.Goal == "" {
+		return nil, fmt.Errorf("no \"prove:\" line stating the goal")
+	}
+	return proof, nil
+}
+
+// parseHilbertLine parses "3. q > p  MP 1, 2" into a HilbertLine, the
+// way parseFitchLine would, if it has no subproofs.
+func parseHilbertLine(text string) (*HilbertLine, error) {
+	f, err := parseFitchLine(text)
+	if err != nil {
+		return nil, err
+	}
+	if f.Depth > 0 {
+		return nil, fmt.Errorf("axiomatic proofs don't have subproofs")
+	}
+	line := &HilbertLine{Number: f.Number, Formula: f.Formula, Rule: f.Rule}
+	for _, c := range f.Citations {
+		if c.Subproof {
+			return nil, fmt.Errorf("axiomatic proofs cite lines, not %s", c)
+		}
+		line.Citations = append(line.Citations, c.From)
+	}
+	return line, nil
+}
+
+// CheckHilbert checks every line of an axiomatic proof: that it's an
+// instance of the axiom schema it names, one of the hypotheses, or
+// follows by modus ponens from the two earlier lines it cites, and
+// that the proof ends with the goal. It gives back every mistake it
+// finds, ordered by line number. Problems with the proof as a whole
+// (line -1) come first.
+func CheckHilbert(proof *HilbertProof) []*StepError {
+	var errs []*StepError
+	fail := func(line int, format string, args ...interface{}) {
+		errs = append(errs, &StepError{Line: line, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	schemas := make(map[string]*node.Node)
+	for _, axiom := range proof.Axioms {
+		tree, err := parser.ParseString(axiom.Schema)
+		if err != nil {
+			fail(-1, "axiom %s, %q: %v", axiom.Name, axiom.Schema, err)
+			continue
+		}
+		schemas[strings.ToUpper(axiom.Name)] = tree
+	}
+	var hypotheses []*node.Node
+	for _, text := range proof.Hypotheses {
+		tree, err := parser.ParseString(text)
+		if err != nil {
+			fail(-1, "hypothesis %q: %v", text, err)
+			continue
+		}
+		hypotheses = append(hypotheses, tree)
+	}
+	goal, err := parser.ParseString(proof.Goal)
+	if err != nil {
+		fail(-1, "goal %q: %v", proof.Goal, err)
+	}
+
+	trees := make([]*node.Node, len(proof.Lines)) // nil if a formula doesn't parse
+	for i, line := range proof.Lines {
+		if trees[i], err = parser.ParseString(line.Formula); err != nil {
+			fail(line.Number, "%v", err)
+			trees[i] = nil
+			continue
+		}
+		tree := trees[i]
+
+		rule := strings.ToUpper(line.Rule)
+		if schema, ok := schemas[rule]; ok {
+			if len(line.Citations) > 0 {
+				fail(line.Number, "an axiom doesn't cite anything")
+			}
+			bindings := make(map[string]*node.Node)
+			if !node.MatchSchema(schema, tree, bindings) || !same(node.Instantiate(schema, bindings), tree) {
+				fail(line.Number, "%s isn't an instance of %s, %s", line.Formula, line.Rule, node.ExpressionToString(schema))
+			}
+			continue
+		}
+
+		switch rule {
+		case "HYP", "HYPOTHESIS", "PREMISE", "PR":
+			if len(line.Citations) > 0 {
+				fail(line.Number, "a hypothesis doesn't cite anything")
+			}
+			found := false
+			for _, hypothesis := range hypotheses {
+				found = found || same(tree, hypothesis)
+			}
+			if !found {
+				fail(line.Number, "%s isn't one of the hypotheses", line.Formula)
+			}
+		case "MP", "→E", ">E":
+			if len(line.Citations) != 2 {
+				fail(line.Number, "MP cites 2 lines")
+				continue
+			}
+			var cited []*node.Node
+			for _, number := range line.Citations {
+				if number < 1 || number >= line.Number {
+					fail(line.Number, "can't cite line %d, only lines before this one", number)
+				} else if trees[number-1] != nil {
+					cited = append(cited, trees[number-1])
+				}
+			}
+			if len(cited) < 2 {
+				continue
+			}
+			if !either(cited, func(x, y *node.Node) bool {
+				return x.Op == lexer.IMPLIES && same(x.Left, y) && same(x.Right, tree)
+			}) {
+				fail(line.Number, "MP doesn't give %s from %d, %d", line.Formula, line.Citations[0], line.Citations[1])
+			}
+		default:
+			fail(line.Number, "unknown rule %q, not an axiom, MP or Hyp", line.Rule)
+		}
+	}
+
+	last := len(proof.Lines) - 1
+	if goal != nil && trees[last] != nil && !same(trees[last], goal) {
+		fail(-1, "proof ends with %s, not the goal, %s", node.ExpressionToString(trees[last]), node.ExpressionToString(goal))
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return errs
+}
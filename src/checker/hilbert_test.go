@@ -0,0 +1,53 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+// Axiomatic proofs in testdata, and the mistakes CheckHilbert finds
+// in them.
+var hilbertTests = []struct {
+	file     string
+	mistakes []string
+}{
+	{"identity.hilbert", nil},
+	{"hypothetical.hilbert", nil},
+	{"own-axiom.hilbert", nil},
+	{"lukasiewicz.hilbert", nil},
+	{"not-an-instance.hilbert", []string{"line 1: p > (q > r) isn't an instance of A1, A > (B > A)"}},
+	{"bad-mp.hilbert", []string{"line 3: MP doesn't give p from 1, 2"}},
+	{"not-assumed.hilbert", []string{"line 1: q isn't one of the hypotheses"}},
+	{"later-line.hilbert", []string{
+		"line 1: can't cite line 2, only lines before this one",
+		"line 1: can't cite line 3, only lines before this one",
+	}},
+	{"wrong-goal.hilbert", []string{"proof ends with p, not the goal, q"}},
+	{"own-axioms-only.hilbert", []string{`line 1: unknown rule "A1"`}},
+}
+
+func TestCheckHilbert(t *testing.T) {
+	for _, test := range hilbertTests {
+		fin := openTestdata(t, test.file)
+		proof, err := ReadHilbert(fin)
+		fin.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", test.file, err)
+		}
+		checkMistakes(t, test.file, CheckHilbert(proof), test.mistakes)
+	}
+}
+
+// Text that isn't an axiomatic proof.
+var badHilbert = []string{
+	"axioms: Frege\nprove: p > p\n1. p > p A1\n",
+	"1. p > (q > p) A1\n",
+}
+
+func TestReadHilbertErrors(t *testing.T) {
+	for _, text := range badHilbert {
+		if _, err := ReadHilbert(strings.NewReader(text)); err == nil {
+			t.Errorf("%q reads as a proof", text)
+		}
+	}
+}
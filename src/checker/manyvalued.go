@@ -0,0 +1,175 @@
+package checker
+
+// Many-signed tableaux. Rather than having rules of its own to compare
+// inferences to, the checker makes sure that inferences follow from
+// their premise, by the logic's truth tables: that any values of the
+// premise's operands that give the premise a value in its sign, that
+// the branch above allows, give every formula of some run of
+// inferences a value in that formula's sign.
+
+import (
+	"fmt"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/truthtable"
+)
+
+// checkValued checks the inferences from premise subjoined directly
+// below e in a many-signed tableau, one run of formulas for each of
+// starts, then everything below those inferences.
+func (v *verifier) checkValued(e *entry, starts []*entry, premise *entry) {
+	line := premise.j.Line
+	tree := premise.formula.tree
+	if tree.Op == lexer.IDENT {
+		v.fail(starts[0].j.Line, "no inferences can be made from line %d, %s", line, premise.formula)
+		v.skipRuns(starts)
+		return
+	}
+	operands := []*node.Node{tree.Left}
+	if tree.Op != lexer.NOT {
+		operands = append(operands, tree.Right)
+	}
+
+	var runs [][]*entry
+	for _, start := range starts {
+		if start.j.Premise == nil || *start.j.Premise != line {
+			v.fail(start.j.Line, "both branches of a split have to come from line %d", line)
+			v.skipRuns([]*entry{start})
+			continue
+		}
+		run := []*entry{start}
+		for p := start; len(p.children) == 1; p = p.children[0] {
+			next := p.children[0]
+			if next.j.Premise == nil || *next.j.Premise != line {
+				break
+			}
+			run = append(run, next)
+		}
+		for _, p := range run {
+			v.checkClosure(p)
+			if p.parsed && operand(operands, p.formula.text) < 0 {
+				v.fail(p.j.Line, "%s does not follow from line %d, %s", p.formula, line, premise.formula)
+			}
+		}
+		runs = append(runs, run)
+	}
+
+	if missing := v.uncovered(e, premise, operands, runs); missing != "" {
+		v.fail(starts[0].j.Line, "inferences from line %d, %s, leave out %s", line, premise.formula, missing)
+	}
+	for _, run := range runs {
+		v.checkBelow(run[len(run)-1])
+	}
+}
+
+// uncovered gives back values of premise's operands, like "p=U q=T",
+// that the branch ending at e allows, that give premise a value in its
+// sign, but that leave some formula of every run outside its sign, or
+// "" if there are none.
+func (v *verifier) uncovered(e, premise *entry, operands []*node.Node, runs [][]*entry) string {
+	var texts []string
+	var allowed []truthtable.Set
+	for _, o := range operands {
+		text := node.ExpressionToString(o)
+		texts = append(texts, text)
+		allowed = append(allowed, values(e, text))
+	}
+
+	var missing []string
+	assigned := make([]truthtable.Value, len(operands))
+	var try func(i int)
+	try = func(i int) {
+		if i == 1 && len(texts) > 1 && texts[1] == texts[0] {
+			// X & X and so on: both operands have X's value.
+			assigned[1] = assigned[0]
+			try(2)
+			return
+		}
+		if i < len(operands) {
+			for _, a := range truthtable.Values {
+				if allowed[i].Has(a) {
+					assigned[i] = a
+					try(i + 1)
+				}
+			}
+			return
+		}
+		b := truthtable.False
+		if len(assigned) > 1 {
+			b = assigned[1]
+		}
+		if !premise.formula.values.Has(v.logic.Apply(premise.formula.tree.Op, assigned[0], b)) {
+			return
+		}
+		for _, run := range runs {
+			if satisfies(run, texts, assigned) {
+				return
+			}
+		}
+		var s []string
+		for i, text := range texts {
+			s = append(s, fmt.Sprintf("%s=%v", text, assigned[i]))
+		}
+		missing = append(missing, strings.Join(s, " "))
+	}
+	try(0)
+	if len(missing) == 0 {
+		return ""
+	}
+	return missing[0]
+}
+
+// satisfies returns true if every formula of run, one of the operands
+// whose texts are texts, has a value in its sign when those operands
+// have values.
+func satisfies(run []*entry, texts []string, values []truthtable.Value) bool {
+	for _, p := range run {
+		if !p.parsed {
+			continue
+		}
+		for i, text := range texts {
+			if p.formula.text == text && !p.formula.values.Has(values[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// operand gives back which of operands has text, -1 for none.
+func operand(operands []*node.Node, text string) int {
+	for i, o := range operands {
+		if node.ExpressionToString(o) == text {
+			return i
+		}
+	}
+	return -1
+}
+
+// values gives back the values formula text can have on the branch
+// from e up to the root, according to the signs it has there.
+func values(e *entry, text string) truthtable.Set {
+	values := truthtable.All
+	for p := e; p != nil; p = p.parent {
+		if p.parsed && p.formula.text == text {
+			values &= p.formula.values
+		}
+	}
+	return values
+}
+
+// setValues reads the sign of e's formula, in a many-signed tableau.
+func (v *verifier) setValues(e *entry) {
+	switch {
+	case v.logic == truthtable.Classical && e.j.Values != "":
+		v.fail(e.j.Line, "values %s in a tableau without three-valued logic", e.j.Values)
+	case v.logic != truthtable.Classical:
+		values, err := truthtable.ParseSet(e.j.Values)
+		if err != nil || values == 0 {
+			v.fail(e.j.Line, "%q is not a sign of a many-signed tableau", e.j.Values)
+		}
+		e.formula.values = values
+	}
+}
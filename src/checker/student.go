@@ -0,0 +1,243 @@
+package checker
+
+// Text format for hand-written signed tableaux. See README.md
+// for a description with examples.
+//
+//   # Comments start with '#'
+//   assume: p > q
+//   prove: ~q > ~p
+//   1. T: p > q
+//   2. F: ~q > ~p
+//   3. T: ~q (2)
+//   4. F: ~p (2)
+//   5. F: q (3)
+//   6. T: p (4)
+//   - 7. F: p (1) x 6
+//   - 8. T: q (1) x 5
+//   verdict: closed
+//
+// A line starting with "- " starts a new branch below the most recent
+// formula that's less indented. Lines continuing a branch line up with
+// the line numbers of that branch. "x N" (or "✗ N") at the end of a
+// formula claims it closes its branch by contradicting line N,
+// "○" (or "open") claims its branch is open. Leaving out every "T:" and
+// "F:" makes an unsigned tableau.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"tableaux-in-go/src/tableaux"
+)
+
+var (
+	lineRE    = regexp.MustCompile(`^(\d+)\.\s*(?:(T|F|true|false)\s*:)?\s*(.*)$`)
+	closedRE  = regexp.MustCompile(`\s+(?:x|X|✗)\s*(\d+)\s*$`)
+	openRE    = regexp.MustCompile(`\s+(?:○|open)\s*$`)
+	premiseRE = regexp.MustCompile(`\s*\((\d+)(?:[,\s][^()]*)?\)\s*$`)
+)
+
+// branch is a run of formulas all lined up in the same column.
+type branch struct {
+	column int
+	last   *tableaux.JSONTnode
+}
+
+// ReadText parses a hand-written tableau in the text format,
+// giving back a proof that Check, Verify or Grade can look at.
+func ReadText(r io.Reader) (*tableaux.JSONProof, error) {
+	proof := &tableaux.JSONProof{}
+	var stack []*branch
+	var consequence string
+	verdict := false
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := expandTabs(scanner.Text())
+		text := strings.TrimSpace(raw)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if key, value, ok := keyword(text); ok {
+			switch key {
+			case "assume":
+				proof.Hypotheses = append(proof.Hypotheses, value)
+			case "prove":
+				consequence = value
+			case "verdict":
+				closed, err := parseVerdict(value)
+				if err != nil {
+					return nil, fmt.Errorf("input line %d: %v", lineNo, err)
+				}
+				proof.Closed = closed
+				verdict = true
+			}
+			continue
+		}
+
+		column := len(raw) - len(strings.TrimLeft(raw, " "))
+		newBranch := false
+		if strings.HasPrefix(text, "- ") {
+			newBranch = true
+			text = strings.TrimSpace(text[2:])
+			column = strings.Index(raw, text)
+		}
+
+		t, signed, err := parseFormulaLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("input line %d: %v", lineNo, err)
+		}
+		if proof.Tableau != nil && signed == proof.Unsigned {
+			return nil, fmt.Errorf("input line %d: signed and unsigned formulas in the same tableau", lineNo)
+		}
+
+		if proof.Tableau == nil {
+			if newBranch {
+				return nil, fmt.Errorf("input line %d: tableau can't start with a branch", lineNo)
+			}
+			proof.Tableau = t
+			proof.Unsigned = !signed
+			stack = []*branch{{column: column, last: t}}
+			continue
+		}
+
+		// Close off any branches to the right of this line.
+		for len(stack) > 0 && (stack[len(stack)-1].column > column || (newBranch && stack[len(stack)-1].column == column)) {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			return nil, fmt.Errorf("input line %d: indented less than the first formula", lineNo)
+		}
+		top := stack[len(stack)-1]
+
+		if newBranch {
+			if len(top.last.Children) >= 2 {
+				return nil, fmt.Errorf("input line %d: more than two branches below line %d", lineNo, top.last.Line)
+			}
+			top.last.Children = append(top.last.Children, t)
+			stack = append(stack, &branch{column: column, last: t})
+			continue
+		}
+
+		if top.column != column {
+			return nil, fmt.Errorf("input line %d: doesn't line up with the branch it continues", lineNo)
+		}
+		if len(top.last.Children) > 0 {
+			return nil, fmt.Errorf("input line %d: line %d already has branches below it", lineNo, top.last.Line)
+		}
+		top.last.Children = append(top.last.Children, t)
+		top.last = t
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if proof.Tableau == nil {
+		return nil, fmt.Errorf("no tableau")
+	}
+	if !verdict {
+		return nil, fmt.Errorf("no verdict")
+	}
+
+	switch {
+	case consequence != "" && len(proof.Hypotheses) > 0:
+		proof.Consequence = consequence
+	case consequence != "":
+		proof.Formula = consequence
+	case len(proof.Hypotheses) > 0:
+		return nil, fmt.Errorf("hypotheses, but nothing to prove")
+	default:
+		return nil, fmt.Errorf("no \"prove:\" line stating the problem")
+	}
+
+	return proof, nil
+}
+
+// keyword splits "assume: p > q" and the like into keyword and value.
+func keyword(text string) (string, string, bool) {
+	i := strings.Index(text, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	key := strings.ToLower(strings.TrimSpace(text[:i]))
+	switch key {
+	case "assume", "prove", "verdict":
+		return key, strings.TrimSpace(text[i+1:]), true
+	}
+	return "", "", false
+}
+
+func parseVerdict(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "closed", "valid", "tautology", "consequence", "proved":
+		return true, nil
+	case "open", "invalid", "not a tautology", "not a consequence", "not proved":
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown verdict %q", value)
+}
+
+// parseFormulaLine parses "3. F: p > q (1) x 2" into a JSONTnode,
+// also saying whether the line had a sign. "3. ~(p > q) (1) x 2"
+// parses as a formula of an unsigned tableau, with sign true.
+func parseFormulaLine(text string) (*tableaux.JSONTnode, bool, error) {
+	m := lineRE.FindStringSubmatch(text)
+	if m == nil {
+		return nil, false, fmt.Errorf("expected \"N. T: formula\", \"N. F: formula\" or \"N. formula\", found %q", text)
+	}
+	line, _ := strconv.Atoi(m[1])
+	t := &tableaux.JSONTnode{
+		Line: line,
+		Sign: m[2] != "F" && m[2] != "false",
+	}
+	rest := m[3]
+
+	if c := closedRE.FindStringSubmatchIndex(rest); c != nil {
+		contradicts, _ := strconv.Atoi(rest[c[2]:c[3]])
+		t.Contradicts = &contradicts
+		t.Closed = true
+		rest = rest[:c[0]]
+	} else if o := openRE.FindStringIndex(rest); o != nil {
+		t.Open = true
+		rest = rest[:o[0]]
+	}
+
+	if p := premiseRE.FindStringSubmatchIndex(rest); p != nil && p[0] > 0 {
+		premise, _ := strconv.Atoi(rest[p[2]:p[3]])
+		t.Premise = &premise
+		rest = rest[:p[0]]
+	}
+
+	t.Formula = strings.TrimSpace(rest)
+	if t.Formula == "" {
+		return nil, false, fmt.Errorf("no formula in %q", text)
+	}
+	return t, m[2] != "", nil
+}
+
+// expandTabs replaces tabs with spaces, to 8-column tab stops.
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var b strings.Builder
+	column := 0
+	for _, r := range s {
+		if r == '\t' {
+			n := 8 - column%8
+			b.WriteString(strings.Repeat(" ", n))
+			column += n
+			continue
+		}
+		b.WriteRune(r)
+		column++
+	}
+	return b.String()
+}
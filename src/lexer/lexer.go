@@ -38,6 +38,24 @@ const (
 	RPAREN  TokenType = iota
 	EOL     TokenType = iota
 	EOF     TokenType = iota
+	COMMA   TokenType = iota
+	FORALL  TokenType = iota
+	EXISTS  TokenType = iota
+	BOX     TokenType = iota
+	DIAMOND TokenType = iota
+
+	// Linear temporal logic operators. The lexer never gives these
+	// back: X, F, G, U and R are identifiers too, and only the parser
+	// knows which they are from where they appear. Parse trees use
+	// them as operators, the way they use FORALL for "Ax".
+	NEXT       TokenType = iota
+	EVENTUALLY TokenType = iota
+	ALWAYS     TokenType = iota
+	UNTIL      TokenType = iota
+	RELEASE    TokenType = iota
+
+	// The dot after a quantifier's variable, "forall x. P(x)".
+	DOT TokenType = iota
 )
 
 // NewFromFile creates a lexer that reads text from an io.Reader
@@ -130,6 +148,18 @@ func (p *Lexer) nextToken() (string, TokenType) {
 		return token, EQUIV
 	case "\n":
 		return token, EOL
+	case ",":
+		return token, COMMA
+	case ".":
+		return token, DOT
+	case "∀", "forall":
+		return token, FORALL
+	case "∃", "exists":
+		return token, EXISTS
+	case "□", "[]":
+		return token, BOX
+	case "◇", "<>":
+		return token, DIAMOND
 	}
 
 	return token, IDENT
@@ -160,6 +190,28 @@ func TokenName(t TokenType) string {
 		r = "EOL"
 	case EOF:
 		r = "EOF"
+	case COMMA:
+		r = "COMMA"
+	case FORALL:
+		r = "FORALL"
+	case EXISTS:
+		r = "EXISTS"
+	case BOX:
+		r = "BOX"
+	case DIAMOND:
+		r = "DIAMOND"
+	case NEXT:
+		r = "NEXT"
+	case EVENTUALLY:
+		r = "EVENTUALLY"
+	case ALWAYS:
+		r = "ALWAYS"
+	case UNTIL:
+		r = "UNTIL"
+	case RELEASE:
+		r = "RELEASE"
+	case DOT:
+		r = "DOT"
 	}
 	return r
 }
@@ -174,12 +226,28 @@ func plSplitter(data []byte, atEOF bool) (advance int, token []byte, err error)
 		end := advance + w
 
 		switch c {
-		case '(', ')', '&', '~', '|', '=', '>':
+		case '(', ')', '&', '~', '|', '=', '>', ',', '.', '∀', '∃', '□', '◇':
 			if len(token) == 0 {
 				token = append(token, data[advance:end]...)
 				advance = end
 			}
 			foundToken = true
+		case '[', '<':
+			// [] and <>, two characters
+			if len(token) > 0 {
+				foundToken = true
+				break
+			}
+			if end >= len(data) && !atEOF {
+				return 0, nil, nil // Need the next character
+			}
+			if end < len(data) && (c == '[' && data[end] == ']' || c == '<' && data[end] == '>') {
+				token = append(token, data[advance:end+1]...)
+				advance = end + 1
+				foundToken = true
+			} else {
+				advance = end // Meaningless by itself
+			}
 		case ' ', '\t':
 			if len(token) > 0 {
 				foundToken = true
@@ -213,3 +281,44 @@ func BinaryOperator(t TokenType) bool {
 	}
 	return false
 }
+
+// Quantifier returns true if you pass it one of the
+// first order logic quantifiers, ∀ or ∃.
+func Quantifier(t TokenType) bool {
+	return t == FORALL || t == EXISTS
+}
+
+// Modality returns true if you pass it one of the
+// modal operators, □ or ◇.
+func Modality(t TokenType) bool {
+	return t == BOX || t == DIAMOND
+}
+
+// Temporal returns true if you pass it one of the linear
+// temporal logic operators, X, F, G, U or R.
+func Temporal(t TokenType) bool {
+	switch t {
+	case NEXT, EVENTUALLY, ALWAYS, UNTIL, RELEASE:
+		return true
+	}
+	return false
+}
+
+// TemporalOperator gives back the linear temporal logic operator
+// that an identifier token spells, if it spells one: X is NEXT,
+// F EVENTUALLY, G ALWAYS, U UNTIL and R RELEASE.
+func TemporalOperator(token string) (TokenType, bool) {
+	switch token {
+	case "X":
+		return NEXT, true
+	case "F":
+		return EVENTUALLY, true
+	case "G":
+		return ALWAYS, true
+	case "U":
+		return UNTIL, true
+	case "R":
+		return RELEASE, true
+	}
+	return IDENT, false
+}
@@ -0,0 +1,101 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+)
+
+// LaTeX writes the formula with parse tree p for LaTeX math mode, with
+// the same parentheses Print writes. Identifiers longer than a letter
+// get \mathit, so that LaTeX doesn't space them out like a product.
+func (p *Node) LaTeX(w io.Writer) {
+	switch p.Op {
+	case lexer.NOT:
+		fmt.Fprintf(w, `\lnot `)
+	case lexer.BOX:
+		fmt.Fprintf(w, `\Box `)
+	case lexer.DIAMOND:
+		fmt.Fprintf(w, `\Diamond `)
+	case lexer.FORALL:
+		fmt.Fprintf(w, `\forall %s\, `, latexIdentifier(p.Ident))
+	case lexer.EXISTS:
+		fmt.Fprintf(w, `\exists %s\, `, latexIdentifier(p.Ident))
+	case lexer.NEXT:
+		fmt.Fprintf(w, `\mathsf{X}\, `)
+	case lexer.EVENTUALLY:
+		fmt.Fprintf(w, `\mathsf{F}\, `)
+	case lexer.ALWAYS:
+		fmt.Fprintf(w, `\mathsf{G}\, `)
+	}
+	if p.Left != nil {
+		p.Left.latexOperand(w)
+	}
+
+	switch p.Op {
+	case lexer.IMPLIES:
+		fmt.Fprintf(w, ` \to `)
+	case lexer.AND:
+		fmt.Fprintf(w, ` \land `)
+	case lexer.OR:
+		fmt.Fprintf(w, ` \lor `)
+	case lexer.EQUIV:
+		fmt.Fprintf(w, ` \leftrightarrow `)
+	case lexer.UNTIL:
+		fmt.Fprintf(w, ` \mathbin{\mathsf{U}} `)
+	case lexer.RELEASE:
+		fmt.Fprintf(w, ` \mathbin{\mathsf{R}} `)
+	case lexer.IDENT:
+		if value, ok := p.Constant(); ok {
+			if value {
+				fmt.Fprintf(w, `\top`)
+			} else {
+				fmt.Fprintf(w, `\bot`)
+			}
+			break
+		}
+		fmt.Fprintf(w, "%s", latexIdentifier(p.Ident))
+		for i, arg := range p.Args {
+			if i == 0 {
+				fmt.Fprintf(w, "(")
+			} else {
+				fmt.Fprintf(w, ", ")
+			}
+			arg.LaTeX(w)
+		}
+		if len(p.Args) > 0 {
+			fmt.Fprintf(w, ")")
+		}
+	}
+
+	if p.Right != nil {
+		p.Right.latexOperand(w)
+	}
+}
+
+func (p *Node) latexOperand(w io.Writer) {
+	if p.prefix() {
+		p.LaTeX(w)
+		return
+	}
+	fmt.Fprintf(w, "(")
+	p.LaTeX(w)
+	fmt.Fprintf(w, ")")
+}
+
+func latexIdentifier(ident string) string {
+	ident = strings.ReplaceAll(ident, "_", `\_`)
+	if len([]rune(ident)) > 1 {
+		return `\mathit{` + ident + `}`
+	}
+	return ident
+}
+
+// LaTeXString gives back the LaTeX math mode text for parse tree root.
+func LaTeXString(root *Node) string {
+	var sb strings.Builder
+	root.LaTeX(&sb)
+	return sb.String()
+}
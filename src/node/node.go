@@ -14,15 +14,23 @@ import (
 // Node has all elements exported, everything reaches inside instances
 // of Node to find things out, or to change Left and Right. Private
 // elements would cost me gross ol' getter and setter boilerplate.
+// First order logic uses Node for terms as well as formulas: a predicate
+// or function applied to arguments is an IDENT node with Args, a
+// variable or constant is an IDENT node without. A quantifier node has
+// its variable in Ident, and the formula it quantifies as Left.
+// Modal operators □ and ◇ have their operand as Left, like "~".
+// So do linear temporal logic's X, F and G, while U and R have
+// Left and Right operands like "&" does.
 type Node struct {
 	Op    lexer.TokenType
 	Ident string
 	Left  *Node
 	Right *Node
+	Args  []*Node
 }
 
 // NewOpNode creates interior nodes of a parse tree, which will
-// all have a &, ~, |, >, = operator associated.
+// all have a &, ~, |, >, =, □, ◇ operator associated.
 func NewOpNode(op lexer.TokenType) *Node {
 	return &Node{Op: op}
 }
@@ -36,18 +44,39 @@ func NewIdentNode(identifier string) *Node {
 	}
 }
 
+// NewQuantifierNode creates a ∀ or ∃ node, quantifying body
+// over variable.
+func NewQuantifierNode(op lexer.TokenType, variable string, body *Node) *Node {
+	return &Node{Op: op, Ident: variable, Left: body}
+}
+
 // Print puts a human-readable, nicely formatted string representation
 // of a parse tree onto the io.Writer, w.  Essentially just an in-order
 // traversal of a binary tree, with accommodating a few oddities, like
 // parenthesization, and the "~" (not) operator being a prefix.
 func (p *Node) Print(w io.Writer) {
 
-	if p.Op == lexer.NOT {
+	switch p.Op {
+	case lexer.NOT:
 		fmt.Fprintf(w, "~")
+	case lexer.BOX:
+		fmt.Fprintf(w, "[]")
+	case lexer.DIAMOND:
+		fmt.Fprintf(w, "<>")
+	case lexer.FORALL:
+		fmt.Fprintf(w, "A%s ", p.Ident)
+	case lexer.EXISTS:
+		fmt.Fprintf(w, "E%s ", p.Ident)
+	case lexer.NEXT:
+		fmt.Fprintf(w, "X ")
+	case lexer.EVENTUALLY:
+		fmt.Fprintf(w, "F ")
+	case lexer.ALWAYS:
+		fmt.Fprintf(w, "G ")
 	}
 	if p.Left != nil {
 		printParen := false
-		if p.Left.Op != lexer.IDENT && p.Left.Op != lexer.NOT {
+		if !p.Left.prefix() {
 			fmt.Fprintf(w, "(")
 			printParen = true
 		}
@@ -67,6 +96,10 @@ func (p *Node) Print(w io.Writer) {
 		oper = '|'
 	case lexer.EQUIV:
 		oper = '='
+	case lexer.UNTIL:
+		oper = 'U'
+	case lexer.RELEASE:
+		oper = 'R'
 	}
 	if oper != 0 {
 		fmt.Fprintf(w, " %c ", oper)
@@ -74,11 +107,22 @@ func (p *Node) Print(w io.Writer) {
 
 	if p.Op == lexer.IDENT {
 		fmt.Fprintf(w, "%s", p.Ident)
+		for i, arg := range p.Args {
+			if i == 0 {
+				fmt.Fprintf(w, "(")
+			} else {
+				fmt.Fprintf(w, ", ")
+			}
+			arg.Print(w)
+		}
+		if len(p.Args) > 0 {
+			fmt.Fprintf(w, ")")
+		}
 	}
 
 	if p.Right != nil {
 		printParen := false
-		if p.Right.Op != lexer.IDENT && p.Right.Op != lexer.NOT {
+		if !p.Right.prefix() {
 			fmt.Fprintf(w, "(")
 			printParen = true
 		}
@@ -89,6 +133,16 @@ func (p *Node) Print(w io.Writer) {
 	}
 }
 
+// prefix returns true if p is an identifier, or starts with
+// a prefix operator, so that it never needs parentheses.
+func (p *Node) prefix() bool {
+	switch p.Op {
+	case lexer.IDENT, lexer.NOT, lexer.NEXT, lexer.EVENTUALLY, lexer.ALWAYS:
+		return true
+	}
+	return lexer.Quantifier(p.Op) || lexer.Modality(p.Op)
+}
+
 // ExpressionToString creates a Golang string with a human readable
 // representation of a parse tree in it.
 func ExpressionToString(root *Node) string {
@@ -97,13 +151,20 @@ func ExpressionToString(root *Node) string {
 	return sb.String()
 }
 
-func (p *Node) graphNode(w io.Writer) {
+// graphNode writes a dot node for p, and recursively for p's
+// children. Nodes get named in pre-order, by counting up from *serial,
+// so a parse tree always gets the same dot representation.
+func (p *Node) graphNode(w io.Writer, serial *int) int {
 
 	var label string
 
 	switch p.Op {
 	case lexer.IDENT:
-		label = p.Ident
+		label = ExpressionToString(p)
+	case lexer.FORALL:
+		label = "∀" + p.Ident
+	case lexer.EXISTS:
+		label = "∃" + p.Ident
 	case lexer.IMPLIES:
 		label = ">"
 	case lexer.AND:
@@ -114,24 +175,61 @@ func (p *Node) graphNode(w io.Writer) {
 		label = "="
 	case lexer.NOT:
 		label = "~"
+	case lexer.BOX:
+		label = "□"
+	case lexer.DIAMOND:
+		label = "◇"
+	case lexer.NEXT:
+		label = "X"
+	case lexer.EVENTUALLY:
+		label = "F"
+	case lexer.ALWAYS:
+		label = "G"
+	case lexer.UNTIL:
+		label = "U"
+	case lexer.RELEASE:
+		label = "R"
 	}
 
-	fmt.Fprintf(w, "n%p [label=\"%s\"];\n", p, label)
+	id := *serial
+	*serial++
+	fmt.Fprintf(w, "n%d [label=\"%s\"];\n", id, label)
 
 	if p.Left != nil {
-		p.Left.graphNode(w)
-		fmt.Fprintf(w, "n%p -> n%p;\n", p, p.Left)
+		left := p.Left.graphNode(w, serial)
+		fmt.Fprintf(w, "n%d -> n%d;\n", id, left)
 	}
 	if p.Right != nil {
-		p.Right.graphNode(w)
-		fmt.Fprintf(w, "n%p -> n%p;\n", p, p.Right)
+		right := p.Right.graphNode(w, serial)
+		fmt.Fprintf(w, "n%d -> n%d;\n", id, right)
 	}
+
+	return id
 }
 
 // GraphNode puts a dot-format text representation of
 // a parse tree on w io.Writer.
 func (p *Node) GraphNode(w io.Writer) {
 	fmt.Fprintf(w, "digraph g {\n")
-	p.graphNode(w)
+	serial := 0
+	p.graphNode(w, &serial)
 	fmt.Fprintf(w, "}\n")
 }
+
+// Modal returns true if the formula with parse tree p
+// has □ or ◇ in it.
+func (p *Node) Modal() bool {
+	if lexer.Modality(p.Op) {
+		return true
+	}
+	return (p.Left != nil && p.Left.Modal()) || (p.Right != nil && p.Right.Modal())
+}
+
+// Temporal returns true if the formula with parse tree p
+// has linear temporal logic operators in it.
+func (p *Node) Temporal() bool {
+	if lexer.Temporal(p.Op) {
+		return true
+	}
+	return (p.Left != nil && p.Left.Temporal()) || (p.Right != nil && p.Right.Temporal())
+}
@@ -0,0 +1,162 @@
+package node
+
+// Quantified boolean formulas: quantifiers that bind propositional
+// identifiers, like ∀p ∃q (p = q). The parser can't tell those from
+// first order quantifiers, so it's up to the program to ask for them.
+// Deciding a QBF puts the truth constants ⊤ and ⊥ in place of the
+// identifiers a quantifier binds. The lexer doesn't know ⊤ and ⊥, so
+// they can't clash with anybody's identifiers.
+
+import (
+	"fmt"
+
+	"tableaux-in-go/src/lexer"
+)
+
+// QBF returns true if the formula with parse tree p can be read as a
+// quantified boolean formula: no predicates with arguments, and no
+// modal or temporal operators. Propositional formulas are QBFs too.
+func (p *Node) QBF() bool {
+	if len(p.Args) > 0 || lexer.Modality(p.Op) || lexer.Temporal(p.Op) {
+		return false
+	}
+	return (p.Left == nil || p.Left.QBF()) && (p.Right == nil || p.Right.QBF())
+}
+
+// Truth gives back a new truth constant, ⊤ for true, ⊥ for false.
+func Truth(value bool) *Node {
+	if value {
+		return NewIdentNode("⊤")
+	}
+	return NewIdentNode("⊥")
+}
+
+// Constant returns the value of p and true if p is a truth constant,
+// false if it isn't.
+func (p *Node) Constant() (value bool, ok bool) {
+	if p.Op != lexer.IDENT || len(p.Args) > 0 {
+		return false, false
+	}
+	switch p.Ident {
+	case "⊤":
+		return true, true
+	case "⊥":
+		return false, true
+	}
+	return false, false
+}
+
+// Assign gives back a copy of formula tree with the truth constant
+// for value in place of every free occurrence of propositional
+// identifier variable.
+func Assign(tree *Node, variable string, value bool) *Node {
+	return replace(tree, variable, Truth(value))
+}
+
+// replace puts replacement in place of every free occurrence of
+// propositional identifier variable in tree. Unlike Substitute, it
+// copies all of tree.
+func replace(tree *Node, variable string, replacement *Node) *Node {
+	if lexer.Quantifier(tree.Op) && tree.Ident == variable {
+		return tree // Occurrences of variable below here aren't free.
+	}
+	if tree.Op == lexer.IDENT {
+		if tree.Ident == variable && len(tree.Args) == 0 {
+			return replacement
+		}
+		return tree
+	}
+	n := *tree
+	if tree.Left != nil {
+		n.Left = replace(tree.Left, variable, replacement)
+	}
+	if tree.Right != nil {
+		n.Right = replace(tree.Right, variable, replacement)
+	}
+	return &n
+}
+
+// FreeIdentifiers puts the propositional identifiers in formula tree
+// that no quantifier binds into seen. Truth constants don't count.
+func FreeIdentifiers(tree *Node, seen map[string]bool) {
+	freeIdentifiers(tree, make(map[string]int), seen)
+}
+
+func freeIdentifiers(tree *Node, bound map[string]int, seen map[string]bool) {
+	if lexer.Quantifier(tree.Op) {
+		bound[tree.Ident]++
+		freeIdentifiers(tree.Left, bound, seen)
+		bound[tree.Ident]--
+		return
+	}
+	if tree.Op == lexer.IDENT {
+		_, constant := tree.Constant()
+		if bound[tree.Ident] == 0 && len(tree.Args) == 0 && !constant {
+			seen[tree.Ident] = true
+		}
+		return
+	}
+	if tree.Left != nil {
+		freeIdentifiers(tree.Left, bound, seen)
+	}
+	if tree.Right != nil {
+		freeIdentifiers(tree.Right, bound, seen)
+	}
+}
+
+// Renamer renames the variables of quantified boolean formulas apart,
+// so that each quantifier binds a variable of its own, one that
+// doesn't occur free either. Then a variable's name says which
+// quantifier it goes with, which Skolem functions need. The first
+// quantifier of a variable keeps its name, later ones get q1, q2 and
+// so on, names that don't appear anywhere else.
+type Renamer struct {
+	names map[string]bool // Every identifier, made up ones included
+	free  map[string]bool
+	bound map[string]bool // Variables some quantifier already binds
+}
+
+// NewRenamer creates a Renamer for formulas that don't share any
+// variables yet.
+func NewRenamer() *Renamer {
+	return &Renamer{names: make(map[string]bool), free: make(map[string]bool), bound: make(map[string]bool)}
+}
+
+// Rename gives back a copy of tree with its variables renamed apart,
+// from each other and from the formulas r renamed before.
+func (r *Renamer) Rename(tree *Node) *Node {
+	Names(tree, r.names)
+	FreeIdentifiers(tree, r.free)
+	return r.rename(tree)
+}
+
+func (r *Renamer) rename(tree *Node) *Node {
+	n := *tree
+	if lexer.Quantifier(tree.Op) {
+		if r.bound[tree.Ident] || r.free[tree.Ident] {
+			n.Ident = r.fresh(tree.Ident)
+			n.Left = replace(tree.Left, tree.Ident, NewIdentNode(n.Ident))
+		}
+		r.bound[n.Ident] = true
+		n.Left = r.rename(n.Left)
+		return &n
+	}
+	if tree.Left != nil {
+		n.Left = r.rename(tree.Left)
+	}
+	if tree.Right != nil {
+		n.Right = r.rename(tree.Right)
+	}
+	return &n
+}
+
+// fresh makes up a name based on variable that no formula has.
+func (r *Renamer) fresh(variable string) string {
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s%d", variable, i)
+		if !r.names[name] {
+			r.names[name] = true
+			return name
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package node
+
+// Axiom schemas, like A > (B > A), where every propositional identifier
+// is a schema variable that stands for any formula at all.
+
+import (
+	"tableaux-in-go/src/lexer"
+)
+
+// Instantiate gives back a copy of schema with the formula bindings has
+// for each schema variable in place of the variable. Variables without
+// a binding stay the way they are.
+func Instantiate(schema *Node, bindings map[string]*Node) *Node {
+	if schema.Op == lexer.IDENT && len(schema.Args) == 0 {
+		if formula, ok := bindings[schema.Ident]; ok {
+			return formula
+		}
+		return schema
+	}
+	n := *schema
+	if schema.Left != nil {
+		n.Left = Instantiate(schema.Left, bindings)
+	}
+	if schema.Right != nil {
+		n.Right = Instantiate(schema.Right, bindings)
+	}
+	return &n
+}
+
+// MatchSchema puts into bindings the subformula of tree in the place of
+// each of schema's variables, the first place it appears, and returns
+// false if tree doesn't have schema's connectives. Variables that appear
+// more than once might not match the same subformula each time, so tree
+// is an instance of schema if Instantiate with bindings gives back tree.
+func MatchSchema(schema, tree *Node, bindings map[string]*Node) bool {
+	if schema.Op == lexer.IDENT && len(schema.Args) == 0 {
+		if _, ok := bindings[schema.Ident]; !ok {
+			bindings[schema.Ident] = tree
+		}
+		return true
+	}
+	if schema.Op == lexer.IDENT {
+		return ExpressionToString(schema) == ExpressionToString(tree) // A predicate
+	}
+	if schema.Op != tree.Op || schema.Ident != tree.Ident {
+		return false
+	}
+	if (schema.Left == nil) != (tree.Left == nil) || (schema.Right == nil) != (tree.Right == nil) {
+		return false
+	}
+	if schema.Left != nil && !MatchSchema(schema.Left, tree.Left, bindings) {
+		return false
+	}
+	return schema.Right == nil || MatchSchema(schema.Right, tree.Right, bindings)
+}
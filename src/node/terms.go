@@ -0,0 +1,182 @@
+package node
+
+// First order logic: terms, and substituting them for variables.
+// Variables and constants look the same, an identifier without
+// arguments. Whatever a quantifier binds is a variable, anything
+// else is a constant.
+
+import (
+	"tableaux-in-go/src/lexer"
+)
+
+// FirstOrder returns true if the formula with parse tree p has
+// quantifiers, or predicates with arguments.
+func (p *Node) FirstOrder() bool {
+	if lexer.Quantifier(p.Op) || len(p.Args) > 0 {
+		return true
+	}
+	return (p.Left != nil && p.Left.FirstOrder()) || (p.Right != nil && p.Right.FirstOrder())
+}
+
+// Substitute gives back a copy of formula tree with term in place of
+// every free occurrence of variable. Parts of tree without any free
+// occurrences don't get copied.
+func Substitute(tree *Node, variable string, term *Node) *Node {
+	if lexer.Quantifier(tree.Op) && tree.Ident == variable {
+		return tree // Occurrences of variable below here aren't free.
+	}
+	if tree.Op == lexer.IDENT && len(tree.Args) == 0 {
+		return tree // Propositional identifier, not a term
+	}
+	n := *tree
+	if tree.Left != nil {
+		n.Left = Substitute(tree.Left, variable, term)
+	}
+	if tree.Right != nil {
+		n.Right = Substitute(tree.Right, variable, term)
+	}
+	if tree.Args != nil {
+		n.Args = make([]*Node, len(tree.Args))
+		for i, arg := range tree.Args {
+			n.Args[i] = substituteTerm(arg, variable, term)
+		}
+	}
+	return &n
+}
+
+func substituteTerm(t *Node, variable string, term *Node) *Node {
+	if len(t.Args) == 0 {
+		if t.Ident == variable {
+			return term
+		}
+		return t
+	}
+	n := *t
+	n.Args = make([]*Node, len(t.Args))
+	for i, arg := range t.Args {
+		n.Args[i] = substituteTerm(arg, variable, term)
+	}
+	return &n
+}
+
+// GroundTerms gives back the terms appearing in formula tree that
+// have no variables in them, subterms included, each one once.
+func GroundTerms(tree *Node) []*Node {
+	var terms []*Node
+	seen := make(map[string]bool)
+	groundTerms(tree, make(map[string]int), seen, &terms)
+	return terms
+}
+
+// groundTerms adds terms of tree, that have none of the variables
+// bound with a count above 0, to terms.
+func groundTerms(tree *Node, bound map[string]int, seen map[string]bool, terms *[]*Node) {
+	if lexer.Quantifier(tree.Op) {
+		bound[tree.Ident]++
+		groundTerms(tree.Left, bound, seen, terms)
+		bound[tree.Ident]--
+		return
+	}
+	for _, arg := range tree.Args {
+		groundTerm(arg, bound, seen, terms)
+	}
+	if tree.Left != nil {
+		groundTerms(tree.Left, bound, seen, terms)
+	}
+	if tree.Right != nil {
+		groundTerms(tree.Right, bound, seen, terms)
+	}
+}
+
+// groundTerm adds t and its subterms to terms, if they have no
+// bound variables, returning true if t had none.
+func groundTerm(t *Node, bound map[string]int, seen map[string]bool, terms *[]*Node) bool {
+	ground := bound[t.Ident] == 0 || len(t.Args) > 0
+	for _, arg := range t.Args {
+		if !groundTerm(arg, bound, seen, terms) {
+			ground = false
+		}
+	}
+	if ground {
+		if s := ExpressionToString(t); !seen[s] {
+			seen[s] = true
+			*terms = append(*terms, t)
+		}
+	}
+	return ground
+}
+
+// Names puts every identifier in formula tree into seen: propositional
+// identifiers, predicates, functions, constants and variables.
+func Names(tree *Node, seen map[string]bool) {
+	if tree.Ident != "" {
+		seen[tree.Ident] = true
+	}
+	for _, arg := range tree.Args {
+		Names(arg, seen)
+	}
+	if tree.Left != nil {
+		Names(tree.Left, seen)
+	}
+	if tree.Right != nil {
+		Names(tree.Right, seen)
+	}
+}
+
+// MatchInstance finds the term that Substitute would have to put in
+// place of variable in formula body to get formula instance. It gives
+// back false if there's no such term, and a nil term if any term would
+// do, because variable doesn't occur free in body.
+func MatchInstance(body *Node, variable string, instance *Node) (*Node, bool) {
+	m := matcher{variable: variable}
+	if !m.formula(body, instance) {
+		return nil, false
+	}
+	return m.term, true
+}
+
+type matcher struct {
+	variable string
+	term     *Node
+}
+
+func (m *matcher) formula(body, instance *Node) bool {
+	if body.Op != instance.Op || body.Ident != instance.Ident || len(body.Args) != len(instance.Args) {
+		return false
+	}
+	if lexer.Quantifier(body.Op) && body.Ident == m.variable {
+		return ExpressionToString(body) == ExpressionToString(instance)
+	}
+	for i, arg := range body.Args {
+		if !m.match(arg, instance.Args[i]) {
+			return false
+		}
+	}
+	if (body.Left == nil) != (instance.Left == nil) || (body.Right == nil) != (instance.Right == nil) {
+		return false
+	}
+	if body.Left != nil && !m.formula(body.Left, instance.Left) {
+		return false
+	}
+	return body.Right == nil || m.formula(body.Right, instance.Right)
+}
+
+// match matches term t of the body with term u of the instance.
+func (m *matcher) match(t, u *Node) bool {
+	if len(t.Args) == 0 && t.Ident == m.variable {
+		if m.term == nil {
+			m.term = u
+			return true
+		}
+		return ExpressionToString(m.term) == ExpressionToString(u)
+	}
+	if t.Ident != u.Ident || len(t.Args) != len(u.Args) {
+		return false
+	}
+	for i, arg := range t.Args {
+		if !m.match(arg, u.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
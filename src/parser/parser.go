@@ -1,8 +1,12 @@
 package parser
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"tableaux-in-go/src/lexer"
 	"tableaux-in-go/src/node"
@@ -13,7 +17,8 @@ import (
 // io.Reader or a string in them, Parser instances have
 // no idea what they're parsing from.
 type Parser struct {
-	lexer *lexer.Lexer
+	lexer  *lexer.Lexer
+	errors io.Writer // Where complaints about syntax go
 }
 
 var nextOp [9]lexer.TokenType
@@ -29,7 +34,26 @@ func init() {
 // a prepared Lexer instance.
 func New(lxr *lexer.Lexer) *Parser {
 
-	return &Parser{lexer: lxr}
+	return &Parser{lexer: lxr, errors: os.Stderr}
+}
+
+// ParseString parses a single formula held in a string. Rather than
+// writing complaints about syntax to stderr, ParseString gives them
+// back in the error.
+func ParseString(formula string) (*node.Node, error) {
+	if strings.ContainsAny(formula, "\r\n") {
+		return nil, errors.New("formula contains end-of-line")
+	}
+
+	var complaints bytes.Buffer
+	psr := New(lexer.NewFromFile(bytes.NewBufferString(formula + "\n")))
+	psr.errors = &complaints
+
+	tree := psr.Parse()
+	if tree == nil {
+		return nil, fmt.Errorf("parsing %q: %s", formula, strings.TrimSpace(complaints.String()))
+	}
+	return tree, nil
 }
 
 // Parse creates a parse tree in the form of a
@@ -59,7 +83,7 @@ func (p *Parser) parseProduction(op lexer.TokenType) *node.Node {
 
 	nextProduction := p.parseProduction
 	if op == lexer.AND {
-		nextProduction = p.parseFactor
+		nextProduction = p.parseTemporal
 	}
 
 	no := nextOp[op]
@@ -70,12 +94,41 @@ func (p *Parser) parseProduction(op lexer.TokenType) *node.Node {
 			tmp := node.NewOpNode(op)
 			tmp.Left = newNode
 			tmp.Right = nextProduction(no) // p.parseProduction(no) or p.parseFactor(no)
+			if tmp.Right == nil {
+				// Binary operator without a right-hand operand
+				return nil
+			}
 			newNode = tmp
 		}
 	}
 	return newNode
 }
 
+// parseTemporal parses factors joined by the binary linear temporal
+// logic operators, U and R. They bind more tightly than "&", less
+// tightly than "~", and group to the right: p U q U r is p U (q U r).
+// An identifier U or R can't follow a factor any other way.
+func (p *Parser) parseTemporal(op lexer.TokenType) *node.Node {
+	left := p.parseFactor(op)
+	if left == nil {
+		return nil
+	}
+	token, typ := p.lexer.Next()
+	temporal, ok := lexer.TemporalOperator(token)
+	if typ != lexer.IDENT || !ok || (temporal != lexer.UNTIL && temporal != lexer.RELEASE) {
+		return left
+	}
+	p.lexer.Consume()
+	n := node.NewOpNode(temporal)
+	n.Left = left
+	n.Right = p.parseTemporal(op)
+	if n.Right == nil {
+		// Binary operator without a right-hand operand
+		return nil
+	}
+	return n
+}
+
 func (p *Parser) parseFactor(op lexer.TokenType) *node.Node {
 	var n *node.Node
 
@@ -84,33 +137,198 @@ func (p *Parser) parseFactor(op lexer.TokenType) *node.Node {
 	switch typ {
 	case lexer.IDENT:
 		p.lexer.Consume()
-		n = node.NewIdentNode(token)
+		_, next := p.lexer.Next()
+		switch {
+		case asciiQuantifier(token) && startsFactor(next):
+			// Ax P(x), Ey Q(y)
+			quantifier := lexer.FORALL
+			if token[0] == 'E' {
+				quantifier = lexer.EXISTS
+			}
+			n = p.parseQuantified(quantifier, token[1:], op)
+		case unaryTemporal(token) && next == lexer.LPAREN:
+			// F(p | q), or a predicate F(x)
+			n = p.parseTemporalOrApplication(token)
+		case unaryTemporal(token) && startsFactor(next):
+			// X p, F p, G p
+			temporal, _ := lexer.TemporalOperator(token)
+			n = node.NewOpNode(temporal)
+			n.Left = p.parseFactor(op)
+			if n.Left == nil {
+				n = nil
+			}
+		case next == lexer.LPAREN:
+			n = p.parseApplication(token)
+		default:
+			n = node.NewIdentNode(token)
+		}
+	case lexer.FORALL, lexer.EXISTS:
+		// ∀x P(x), forall x. P(x)
+		p.lexer.Consume()
+		variable, varType := p.lexer.Next()
+		if varType != lexer.IDENT || !isVariable(variable) {
+			fmt.Fprintf(p.errors, "Found %q after quantifier instead of a variable\n", variable)
+			return nil
+		}
+		p.lexer.Consume()
+		if _, next := p.lexer.Next(); next == lexer.DOT {
+			p.lexer.Consume()
+		}
+		n = p.parseQuantified(typ, variable, op)
 	case lexer.LPAREN:
 		p.lexer.Consume()
 		n = p.parseProduction(op)
 		if n != nil {
 			if !p.expect(lexer.RPAREN) {
-				fmt.Fprintf(os.Stderr, "Didn't find a right paren to match left parenthese\n")
+				fmt.Fprintf(p.errors, "Didn't find a right paren to match left parenthese\n")
 				n = nil
 			}
 		}
-	case lexer.NOT:
+	case lexer.NOT, lexer.BOX, lexer.DIAMOND:
 		p.lexer.Consume()
-		n = node.NewOpNode(lexer.NOT)
+		n = node.NewOpNode(typ)
 		n.Left = p.parseFactor(op)
+		if n.Left == nil {
+			n = nil
+		}
 	default:
-		fmt.Fprintf(os.Stderr, "Found token %q, type %s (%d) instead of IDENT|LPAREN|NOT\n", token, lexer.TokenName(typ), typ)
+		fmt.Fprintf(p.errors, "Found token %q, type %s (%d) instead of IDENT|LPAREN|NOT|BOX|DIAMOND|FORALL|EXISTS\n", token, lexer.TokenName(typ), typ)
 		n = nil
 	}
 	return n
 }
 
+// parseQuantified parses the formula a quantifier quantifies, which
+// binds as tightly as "~" does: Ax P(x) & Q is (Ax P(x)) & Q.
+func (p *Parser) parseQuantified(quantifier lexer.TokenType, variable string, op lexer.TokenType) *node.Node {
+	body := p.parseFactor(op)
+	if body == nil {
+		return nil
+	}
+	return node.NewQuantifierNode(quantifier, variable, body)
+}
+
+// parseApplication parses the parenthesized, comma-separated arguments
+// of a predicate or function named name.
+func (p *Parser) parseApplication(name string) *node.Node {
+	p.lexer.Consume() // Left paren
+	return p.parseArguments(node.NewIdentNode(name))
+}
+
+// parseTemporalOrApplication parses what follows X, F or G and a left
+// paren. A term and a right paren make the arguments of a predicate:
+// F(x) and F(x, y) are predicates, like they are in first order logic.
+// Anything else makes the parenthesized operand of a temporal operator,
+// F(p | q). An identifier operand has to go without parentheses, F p.
+func (p *Parser) parseTemporalOrApplication(name string) *node.Node {
+	p.lexer.Consume() // Left paren
+	inside := p.parseProduction(lexer.EQUIV)
+	if inside == nil {
+		return nil
+	}
+	token, typ := p.lexer.Next()
+	if typ == lexer.COMMA && inside.Op == lexer.IDENT {
+		p.lexer.Consume()
+		n := node.NewIdentNode(name)
+		n.Args = append(n.Args, inside)
+		return p.parseArguments(n)
+	}
+	if typ != lexer.RPAREN {
+		fmt.Fprintf(p.errors, "Found %q after %s( instead of RPAREN\n", token, name)
+		return nil
+	}
+	p.lexer.Consume()
+	if inside.Op == lexer.IDENT {
+		n := node.NewIdentNode(name)
+		n.Args = append(n.Args, inside)
+		return n
+	}
+	temporal, _ := lexer.TemporalOperator(name)
+	n := node.NewOpNode(temporal)
+	n.Left = inside
+	return n
+}
+
+// parseArguments parses terms separated by commas, up to a right paren,
+// as arguments of n, a predicate or function.
+func (p *Parser) parseArguments(n *node.Node) *node.Node {
+	for {
+		arg := p.parseTerm()
+		if arg == nil {
+			return nil
+		}
+		n.Args = append(n.Args, arg)
+		token, typ := p.lexer.Next()
+		p.lexer.Consume()
+		if typ == lexer.RPAREN {
+			return n
+		}
+		if typ != lexer.COMMA {
+			fmt.Fprintf(p.errors, "Found %q in arguments of %s instead of COMMA|RPAREN\n", token, n.Ident)
+			return nil
+		}
+	}
+}
+
+// parseTerm parses a variable, a constant, or a function applied
+// to arguments.
+func (p *Parser) parseTerm() *node.Node {
+	token, typ := p.lexer.Next()
+	if typ != lexer.IDENT {
+		fmt.Fprintf(p.errors, "Found token %q, type %s (%d) instead of a term\n", token, lexer.TokenName(typ), typ)
+		return nil
+	}
+	p.lexer.Consume()
+	if _, next := p.lexer.Next(); next == lexer.LPAREN {
+		return p.parseApplication(token)
+	}
+	return node.NewIdentNode(token)
+}
+
+// asciiQuantifier returns true if token could be a quantifier
+// written as A or E followed by a variable.
+func asciiQuantifier(token string) bool {
+	return len(token) > 1 && (token[0] == 'A' || token[0] == 'E') && isVariable(token[1:])
+}
+
+// unaryTemporal returns true if token could be a linear temporal
+// logic operator with a single operand, X, F or G.
+func unaryTemporal(token string) bool {
+	temporal, ok := lexer.TemporalOperator(token)
+	return ok && temporal != lexer.UNTIL && temporal != lexer.RELEASE
+}
+
+// isVariable returns true if token could name a variable: they
+// start with a lower case letter, and go on with letters, digits
+// and underscores, like any identifier.
+func isVariable(token string) bool {
+	if token == "" || token[0] < 'a' || 'z' < token[0] {
+		return false
+	}
+	for _, c := range token {
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// startsFactor returns true if a token of type typ can start a factor,
+// and so a quantified formula.
+func startsFactor(typ lexer.TokenType) bool {
+	switch typ {
+	case lexer.IDENT, lexer.LPAREN, lexer.NOT, lexer.BOX, lexer.DIAMOND, lexer.FORALL, lexer.EXISTS:
+		return true
+	}
+	return false
+}
+
 func (p *Parser) expect(expectedType lexer.TokenType) bool {
 	token, tokenType := p.lexer.Next()
 	if tokenType == expectedType {
 		p.lexer.Consume()
 	} else {
-		fmt.Fprintf(os.Stderr, "Expected token type %s, found %s (%q)\n", lexer.TokenName(expectedType), lexer.TokenName(tokenType), token)
+		fmt.Fprintf(p.errors, "Expected token type %s, found %s (%q)\n", lexer.TokenName(expectedType), lexer.TokenName(tokenType), token)
 		return false
 	}
 	return true
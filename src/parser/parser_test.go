@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"tableaux-in-go/src/node"
+)
+
+// Formulas that parse, and how they look fully parenthesized the way
+// ExpressionToString writes them.
+var parseTests = []struct {
+	formula, want string
+}{
+	{"p & q | r", "(p & q) | r"},
+	{"~(aa & bb) = (~aa | ~bb)", "~(aa & bb) = (~aa | ~bb)"},
+
+	// First order logic
+	{"Ax P(x)", "Ax P(x)"},
+	{"∀x P(x) > P(c)", "Ax P(x) > P(c)"},
+	{"forall x. P(x) > P(c)", "Ax P(x) > P(c)"},
+	{"forall x.P(x) > P(c)", "Ax P(x) > P(c)"},
+	{"∃y.Q(y)", "Ey Q(y)"},
+	{"exists y Q(y, f(y))", "Ey Q(y, f(y))"},
+	{"forall x. (P(x) > Q(x))", "Ax (P(x) > Q(x))"},
+	{"forall x1. P(x1)", "Ax1 P(x1)"},
+
+	// Modal logic
+	{"[]p > <>p", "[]p > <>p"},
+	{"□(p & q) = ◇~r", "[](p & q) = <>~r"},
+	{"<>~[][]p", "<>~[][]p"},
+
+	// Linear temporal logic. X, F and G are identifiers unless
+	// a formula follows them.
+	{"p U q > X F G p", "(p U q) > X F G p"},
+	{"~(p R q) = (~p U ~q)", "~(p R q) = (~p U ~q)"},
+	{"p U q U r", "p U (q U r)"},
+	{"G (p > X p)", "G (p > X p)"},
+	{"F > X", "F > X"},
+
+	// Quantified boolean formulas
+	{"Ap Eq (p = q)", "Ap Eq (p = q)"},
+	{"∀p ∃q (p = q)", "Ap Eq (p = q)"},
+	{"~Ap ~p", "~Ap ~p"},
+}
+
+func TestParseString(t *testing.T) {
+	for _, test := range parseTests {
+		tree, err := ParseString(test.formula)
+		if err != nil {
+			t.Errorf("%q: %v", test.formula, err)
+			continue
+		}
+		if got := node.ExpressionToString(tree); got != test.want {
+			t.Errorf("%q parses as %q, want %q", test.formula, got, test.want)
+		}
+	}
+}
+
+// Formulas that don't parse.
+var badFormulas = []string{
+	"p &",
+	"(p | q",
+	"p.q",
+	"forall X. P(X)",
+	"forall . P(x)",
+	"forall x. . P(x)",
+	"exists (x) P(x)",
+	"[] > p",
+	"p []",
+	"<>",
+	"p U",
+	"R q",
+}
+
+func TestParseStringErrors(t *testing.T) {
+	for _, formula := range badFormulas {
+		if tree, err := ParseString(formula); err == nil {
+			t.Errorf("%q parses, as %q", formula, node.ExpressionToString(tree))
+		}
+	}
+}
+
+// Parse tree dot output names nodes in pre-order, so the same
+// formula always gets the same graph.
+func TestGraphNode(t *testing.T) {
+	tree, err := ParseString("~p > Ax P(x) | []q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph g {
+n0 [label=">"];
+n1 [label="~"];
+n2 [label="p"];
+n1 -> n2;
+n0 -> n1;
+n3 [label="|"];
+n4 [label="∀x"];
+n5 [label="P(x)"];
+n4 -> n5;
+n3 -> n4;
+n6 [label="□"];
+n7 [label="q"];
+n6 -> n7;
+n3 -> n6;
+n0 -> n3;
+}
+`
+	var buf bytes.Buffer
+	tree.GraphNode(&buf)
+	if buf.String() != want {
+		t.Errorf("dot text:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,375 @@
+// Package repl lets a user build a tableau one expansion at a time,
+// choosing which formula's inferences get subjoined next.
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/parser"
+	"tableaux-in-go/src/tableaux"
+	"tableaux-in-go/src/truthtable"
+)
+
+const helpText = `Commands:
+  assume FORMULA   add a hypothesis, signed T, for the next "prove"
+  prove FORMULA    start a new tableau: hypotheses signed T, FORMULA signed F
+  expand N         subjoin inferences of unused formula on line N
+  auto             let the prover choose a formula to expand
+  finish           let the prover expand formulas until the tableau is done
+  hint             suggest a formula to expand
+  undo             take back the most recent expansion
+  show             draw the tableau again
+  help             print this text
+  quit             leave
+Unused formulas are marked with "*", open branches are numbered.
+`
+
+// REPL holds the state of one interactive session.
+type REPL struct {
+	out   io.Writer
+	width int
+	opts  tableaux.Options
+
+	hypotheses  []*node.Node
+	root        *tableaux.Tnode
+	final       *tableaux.Tnode
+	consequence string // final formula, unsigned
+	history     []*tableaux.Expansion
+}
+
+// New creates a REPL that writes to out, drawing tableaux
+// no wider than width columns. Every tableau it builds is
+// the kind opts describes.
+func New(out io.Writer, width int, opts tableaux.Options) *REPL {
+	return &REPL{out: out, width: width, opts: opts}
+}
+
+// Run carries out initial commands, then reads commands
+// from in until end of file or "quit".
+func (r *REPL) Run(in io.Reader, initial []string) {
+	fmt.Fprintf(r.out, "Analytic tableaux, one step at a time. Type \"help\" for commands.\n")
+	for _, line := range initial {
+		if !r.Command(line) {
+			return
+		}
+	}
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(r.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintf(r.out, "\n")
+			return
+		}
+		if !r.Command(scanner.Text()) {
+			return
+		}
+	}
+}
+
+// Command carries out a single line of input, returning
+// false if the user wants to quit.
+func (r *REPL) Command(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+	cmd, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		cmd, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch cmd {
+	case "quit", "exit":
+		return false
+	case "help", "?":
+		fmt.Fprintf(r.out, "%s", helpText)
+	case "assume":
+		r.assume(arg)
+	case "prove":
+		r.prove(arg)
+	case "expand":
+		r.expandLine(arg)
+	case "auto":
+		r.auto()
+	case "finish":
+		r.finish()
+	case "hint":
+		r.hint()
+	case "undo":
+		r.undo()
+	case "show":
+		r.show()
+	default:
+		fmt.Fprintf(r.out, "Unknown command %q, type \"help\" for commands\n", cmd)
+	}
+	return true
+}
+
+// parse parses a formula for "assume" or "prove", and turns down the
+// ones the REPL can't build a tableau for: expanding one formula at a
+// time never instantiates a γ formula more than once, applies a ν or π
+// formula in another world, or unwinds a temporal operator.
+func (r *REPL) parse(formula string) (*node.Node, error) {
+	tree, err := parser.ParseString(formula)
+	if err != nil {
+		return nil, err
+	}
+	if tree.Temporal() {
+		return nil, fmt.Errorf("%q has temporal operators, decide it with -ltl", node.ExpressionToString(tree))
+	}
+	if tree.Modal() {
+		return nil, fmt.Errorf("the interactive prover is for classical logic, without modal operators, not %q", node.ExpressionToString(tree))
+	}
+	if tree.FirstOrder() {
+		return nil, fmt.Errorf("the interactive prover is propositional, without predicates or quantifiers, not %q", node.ExpressionToString(tree))
+	}
+	return tree, nil
+}
+
+func (r *REPL) assume(formula string) {
+	tree, err := r.parse(formula)
+	if err != nil {
+		fmt.Fprintf(r.out, "%v\n", err)
+		return
+	}
+	r.hypotheses = append(r.hypotheses, tree)
+	fmt.Fprintf(r.out, "Hypothesis %d: %s\n", len(r.hypotheses), node.ExpressionToString(tree))
+}
+
+// prove sets up a tableau with tableaux.Start, which doesn't
+// subjoin any inferences: that's up to the user.
+func (r *REPL) prove(formula string) {
+	tree, err := r.parse(formula)
+	if err != nil {
+		fmt.Fprintf(r.out, "%v\n", err)
+		return
+	}
+
+	trees := append(r.hypotheses, tree)
+	r.hypotheses = nil
+	r.history = nil
+
+	r.root, r.final = tableaux.Start(trees, r.opts)
+	if r.final == nil {
+		r.final = r.root
+	}
+	r.consequence = node.ExpressionToString(tree)
+
+	r.show()
+}
+
+func (r *REPL) started() bool {
+	if r.root == nil {
+		fmt.Fprintf(r.out, "No tableau yet, use \"prove FORMULA\"\n")
+		return false
+	}
+	return true
+}
+
+// findLine finds the Tnode with a given line number, if one exists.
+func findLine(n *tableaux.Tnode, line int) *tableaux.Tnode {
+	if n == nil {
+		return nil
+	}
+	if n.LineNumber == line {
+		return n
+	}
+	if t := findLine(n.Left, line); t != nil {
+		return t
+	}
+	return findLine(n.Right, line)
+}
+
+// unusedFormulas finds the unused formulas on any open branch,
+// in order of line number.
+func (r *REPL) unusedFormulas() []*tableaux.Tnode {
+	seen := make(map[*tableaux.Tnode]bool)
+	var unused []*tableaux.Tnode
+	for _, leaf := range r.root.FindUnclosedLeaf() {
+		for p := leaf; p != nil; p = p.Parent {
+			if !p.Used && !seen[p] {
+				seen[p] = true
+				unused = append(unused, p)
+			}
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool { return unused[i].LineNumber < unused[j].LineNumber })
+	return unused
+}
+
+func (r *REPL) expandLine(arg string) {
+	if !r.started() {
+		return
+	}
+	line, err := strconv.Atoi(arg)
+	if err != nil {
+		fmt.Fprintf(r.out, "Need a line number to expand, not %q\n", arg)
+		return
+	}
+	formula := findLine(r.root, line)
+	if formula == nil {
+		fmt.Fprintf(r.out, "No line %d in tableau\n", line)
+		return
+	}
+	if formula.Used {
+		fmt.Fprintf(r.out, "Line %d already has its inferences subjoined\n", line)
+		return
+	}
+	if len(formula.FindUnclosedLeaf()) == 0 {
+		fmt.Fprintf(r.out, "Line %d is only on closed branches\n", line)
+		return
+	}
+	r.expand(formula)
+}
+
+func (r *REPL) auto() {
+	if !r.started() {
+		return
+	}
+	formula := r.root.NextUnused()
+	if formula == nil {
+		r.status()
+		return
+	}
+	fmt.Fprintf(r.out, "Expanding line %d\n", formula.LineNumber)
+	r.expand(formula)
+}
+
+func (r *REPL) finish() {
+	if !r.started() {
+		return
+	}
+	for formula := r.root.NextUnused(); formula != nil; formula = r.root.NextUnused() {
+		if len(r.root.FindUnclosedLeaf()) == 0 {
+			break
+		}
+		r.history = append(r.history, formula.Subjoin())
+	}
+	r.show()
+}
+
+func (r *REPL) expand(formula *tableaux.Tnode) {
+	x := formula.Subjoin()
+	r.history = append(r.history, x)
+	r.show()
+	for _, leaf := range x.Closed {
+		fmt.Fprintf(r.out, "Line %d closes its branch, contradicting line %d\n", leaf.LineNumber, leaf.Contradictory.LineNumber)
+	}
+}
+
+func (r *REPL) undo() {
+	if !r.started() {
+		return
+	}
+	if len(r.history) == 0 {
+		fmt.Fprintf(r.out, "Nothing to undo\n")
+		return
+	}
+	x := r.history[len(r.history)-1]
+	r.history = r.history[:len(r.history)-1]
+	x.Undo()
+	fmt.Fprintf(r.out, "Took back expansion of line %d\n", x.Formula.LineNumber)
+	r.show()
+}
+
+// hint suggests the unused formula whose expansion closes the most
+// branches, or failing that, one that doesn't bifurcate any branches,
+// by trying each expansion and undoing it.
+func (r *REPL) hint() {
+	if !r.started() {
+		return
+	}
+	unused := r.unusedFormulas()
+	if len(unused) == 0 {
+		r.status()
+		return
+	}
+
+	var best *tableaux.Tnode
+	bestClosed, bestSplits := -1, 0
+	for _, formula := range unused {
+		openBefore := len(r.root.FindUnclosedLeaf())
+		x := formula.Subjoin()
+		closed := len(x.Closed)
+		splits := len(r.root.FindUnclosedLeaf()) + closed - openBefore
+		x.Undo()
+		if closed > bestClosed || (closed == bestClosed && splits < bestSplits) {
+			best, bestClosed, bestSplits = formula, closed, splits
+		}
+	}
+
+	switch {
+	case bestClosed > 0:
+		fmt.Fprintf(r.out, "Try line %d: expanding it closes %d branch(es)\n", best.LineNumber, bestClosed)
+	case bestSplits == 0:
+		fmt.Fprintf(r.out, "Try line %d: expanding it doesn't split any branches\n", best.LineNumber)
+	default:
+		fmt.Fprintf(r.out, "Try line %d: every expansion splits branches, so any will do\n", best.LineNumber)
+	}
+}
+
+func (r *REPL) show() {
+	if !r.started() {
+		return
+	}
+	fmt.Fprintf(r.out, "\n")
+	tableaux.DrawTableaux(r.out, r.root, tableaux.DrawOptions{Width: r.width, NumberOpen: true, MarkUnused: true})
+	fmt.Fprintf(r.out, "\n")
+	r.status()
+}
+
+// status tells the user whether the tableau has closed, or is
+// complete with open branches, or neither, with a countermodel for
+// each open branch: three-valued, in a many-signed tableau.
+func (r *REPL) status() {
+	open := r.root.FindUnclosedLeaf()
+	consequence := r.final != r.root
+
+	if len(open) == 0 {
+		if consequence {
+			fmt.Fprintf(r.out, "Every branch closed: %s is a logical consequence of hypotheses\n", r.consequence)
+		} else {
+			fmt.Fprintf(r.out, "Every branch closed: formula is a tautology\n")
+		}
+		return
+	}
+
+	if len(r.unusedFormulas()) > 0 {
+		fmt.Fprintf(r.out, "%d open branch(es)\n", len(open))
+		return
+	}
+
+	if consequence {
+		fmt.Fprintf(r.out, "Tableau complete, %d open branch(es): %s is not a logical consequence of hypotheses\n", len(open), r.consequence)
+	} else {
+		fmt.Fprintf(r.out, "Tableau complete, %d open branch(es): formula is not a tautology\n", len(open))
+	}
+	for i, leaf := range open {
+		fmt.Fprintf(r.out, "Open branch %d:", i+1)
+		valuation := make(map[string]interface{})
+		if r.opts.Logic != truthtable.Classical {
+			for id, v := range leaf.ThreeValued() {
+				valuation[id] = v
+			}
+		} else {
+			for id, v := range leaf.Valuation() {
+				valuation[id] = v
+			}
+		}
+		var ids []string
+		for id := range valuation {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Fprintf(r.out, " %s=%v", id, valuation[id])
+		}
+		fmt.Fprintf(r.out, "\n")
+	}
+}
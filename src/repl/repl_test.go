@@ -0,0 +1,134 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"tableaux-in-go/src/tableaux"
+	"tableaux-in-go/src/truthtable"
+)
+
+// Sessions, and what they write, in order. Each string in want has
+// to turn up after the one before it.
+var sessionTests = []struct {
+	name     string
+	opts     tableaux.Options
+	commands []string
+	want     []string
+}{
+	{
+		"no tableau",
+		tableaux.Options{},
+		[]string{"expand 0", "auto", "undo", "show"},
+		[]string{
+			`No tableau yet, use "prove FORMULA"`,
+			`No tableau yet, use "prove FORMULA"`,
+			`No tableau yet, use "prove FORMULA"`,
+			`No tableau yet, use "prove FORMULA"`,
+		},
+	},
+	{
+		"expand and undo",
+		tableaux.Options{},
+		[]string{"assume p > q", "assume p", "prove q", "hint", "expand 0", "undo", "undo"},
+		[]string{
+			"Hypothesis 1: p > q",
+			"Hypothesis 2: p",
+			"0. T: p > q *\n1. T: p\n2. F: q\n○ open 1\n",
+			"1 open branch(es)",
+			"Try line 0: expanding it closes 2 branch(es)",
+			"3. F: p (0, β)    4. T: q (0, β)\n✗ contradicts 1   ✗ contradicts 2\n",
+			"Every branch closed: q is a logical consequence of hypotheses",
+			"Line 3 closes its branch, contradicting line 1",
+			"Line 4 closes its branch, contradicting line 2",
+			"Took back expansion of line 0",
+			"0. T: p > q *\n1. T: p\n2. F: q\n○ open 1\n",
+			"Nothing to undo",
+		},
+	},
+	{
+		"bad line numbers",
+		tableaux.Options{},
+		[]string{"prove p & q > p", "expand x", "expand 7", "expand 0", "expand 0"},
+		[]string{
+			`Need a line number to expand, not "x"`,
+			"No line 7 in tableau",
+			"1. T: p & q (0, α)",
+			"Line 0 already has its inferences subjoined",
+		},
+	},
+	{
+		// Hypotheses only go with the next tableau
+		"finish",
+		tableaux.Options{},
+		[]string{"assume q", "prove p | q", "prove p | q", "auto", "finish"},
+		[]string{
+			"0. T: q\n1. F: p | q *\n",
+			"0. F: p | q *\n○ open 1\n",
+			"Expanding line 0",
+			"Tableau complete, 1 open branch(es): formula is not a tautology",
+			"Open branch 1: p=false q=false",
+		},
+	},
+	{
+		"three-valued countermodel",
+		tableaux.Options{Logic: truthtable.StrongKleene},
+		[]string{"prove p | ~p", "finish"},
+		[]string{
+			"Tableau complete, 1 open branch(es): formula is not a tautology",
+			"Open branch 1: p=U",
+		},
+	},
+	{
+		"formulas it can't do",
+		tableaux.Options{},
+		[]string{"prove []p", "assume P(c)", "prove p U q", "bogus"},
+		[]string{
+			`the interactive prover is for classical logic, without modal operators, not "[]p"`,
+			`the interactive prover is propositional, without predicates or quantifiers, not "P(c)"`,
+			`"p U q" has temporal operators, decide it with -ltl`,
+			`Unknown command "bogus", type "help" for commands`,
+		},
+	},
+}
+
+func TestSession(t *testing.T) {
+	for _, test := range sessionTests {
+		var out bytes.Buffer
+		r := New(&out, 80, test.opts)
+		r.Run(strings.NewReader(strings.Join(test.commands, "\n")), nil)
+		text := out.String()
+		rest := text
+		for _, want := range test.want {
+			i := strings.Index(rest, want)
+			if i < 0 {
+				t.Errorf("%s: no %q where expected, in\n%s", test.name, want, text)
+				break
+			}
+			rest = rest[i+len(want):]
+		}
+	}
+}
+
+// Initial commands run before any input, and "quit" stops
+// reading commands.
+func TestRunQuit(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out, 80, tableaux.Options{})
+	r.Run(strings.NewReader("quit\nassume r\n"), []string{"assume p"})
+	text := out.String()
+	if !strings.Contains(text, "Hypothesis 1: p") {
+		t.Errorf("initial command didn't run:\n%s", text)
+	}
+	if strings.Contains(text, "Hypothesis 2") {
+		t.Errorf("command after quit ran:\n%s", text)
+	}
+
+	if r.Command("quit") || r.Command("exit") {
+		t.Errorf("quit or exit didn't stop the REPL")
+	}
+	if !r.Command("") || !r.Command("help") {
+		t.Errorf("empty line or help stopped the REPL")
+	}
+}
@@ -0,0 +1,790 @@
+// Package server puts the lexer, parser, tableaux and truthtable
+// packages behind a JSON-over-HTTP API, so that other programs can
+// prove formulas without running the tableaux command per formula.
+package server
+
+// All endpoints take a POSTed JSON object, and give back a JSON object,
+// except /render, which gives back GraphViz dot or SVG text.
+//
+//   /prove        {"formula": "p > (q > p)"}
+//   /consequence  {"hypotheses": ["p > q", "p"], "consequence": "q"}
+//   /satisfiable  {"formula": "p & ~q"}
+//   /truthtable   {"formula": "p | ~p"}
+//   /parse        {"formula": "p & q | r"}
+//   /render       {"formula": "p > p", "format": "svg"}
+//
+// /render takes "hypotheses" and "consequence" instead of "formula"
+// to draw a logical consequence tableau. /prove, /consequence,
+// /satisfiable and /render build unsigned tableaux given "unsigned": true,
+// and regular tableaux given "regular": true. Given "logic": "K3", "B3",
+// "L3" or "LP", they build many-signed tableaux for that three-valued
+// logic, and /truthtable gives back a table in it. /satisfiable decides
+// linear temporal logic formulas given "ltl": true, and whether closed
+// quantified boolean formulas are true given "qbf": true. /truthtable
+// expands the quantifiers of quantified boolean formulas. /consequence
+// gives back a Craig interpolant given "interpolate": true. /render
+// gives back the sequent calculus derivation of a closed tableau, as
+// text given "format": "sequent", or LaTeX given "format": "latex".
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/parser"
+	"tableaux-in-go/src/tableaux"
+	"tableaux-in-go/src/truthtable"
+)
+
+// Config holds limits and other settings for a Server.
+type Config struct {
+	MaxBodyBytes  int64         // Largest request body accepted
+	Timeout       time.Duration // Longest time spent on any one request
+	MaxConcurrent int           // Most proofs, etc, running at the same time
+	DotCommand    string        // GraphViz program for SVG rendering, "" means no SVG
+	Limits        tableaux.Limits
+}
+
+// DefaultConfig has limits suitable for a service on a local machine.
+var DefaultConfig = Config{
+	MaxBodyBytes:  64 * 1024,
+	Timeout:       10 * time.Second,
+	MaxConcurrent: 8,
+	DotCommand:    "dot",
+	Limits:        tableaux.Limits{MaxNodes: 100000, MaxInstances: tableaux.DefaultMaxInstances},
+}
+
+// Server handles HTTP requests to prove, render, etc, formulas.
+type Server struct {
+	config Config
+	mux    *http.ServeMux
+	slots  chan struct{} // Semaphore limiting concurrent work
+}
+
+// New creates a Server with the given limits.
+func New(config Config) *Server {
+	if config.MaxConcurrent < 1 {
+		config.MaxConcurrent = 1
+	}
+	s := &Server{
+		config: config,
+		mux:    http.NewServeMux(),
+		slots:  make(chan struct{}, config.MaxConcurrent),
+	}
+	s.mux.HandleFunc("/prove", s.handle(s.prove))
+	s.mux.HandleFunc("/consequence", s.handle(s.consequence))
+	s.mux.HandleFunc("/satisfiable", s.handle(s.satisfiable))
+	s.mux.HandleFunc("/truthtable", s.handle(s.truthTable))
+	s.mux.HandleFunc("/parse", s.handle(s.parse))
+	s.mux.HandleFunc("/render", s.render)
+	return s
+}
+
+// ServeHTTP makes Server an http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the HTTP API on TCP network address addr.
+func (s *Server) ListenAndServe(addr string) error {
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      s,
+		ReadTimeout:  s.config.Timeout,
+		WriteTimeout: 2 * s.config.Timeout,
+	}
+	log.Printf("Serving tableaux HTTP API on %s\n", addr)
+	return srv.ListenAndServe()
+}
+
+// request holds every field any of the endpoints use.
+type request struct {
+	Formula        string   `json:"formula"`
+	Hypotheses     []string `json:"hypotheses"`
+	Consequence    string   `json:"consequence"`
+	Format         string   `json:"format"`
+	Unsigned       bool     `json:"unsigned"`
+	Regular        bool     `json:"regular"`
+	Modal          string   `json:"modal"` // K, T, S4 or S5
+	Intuitionistic bool     `json:"intuitionistic"`
+	Logic          string   `json:"logic"` // K3, B3, L3 or LP
+	LTL            bool     `json:"ltl"`
+	QBF            bool     `json:"qbf"`
+	Interpolate    bool     `json:"interpolate"`
+}
+
+// options gives back the kind of tableau the request asks for, a bad
+// request error if it doesn't name a modal logic that trees need, or
+// asks for intuitionistic or three-valued logic along with something
+// that doesn't go with it.
+func (req *request) options(trees ...*node.Node) (tableaux.Options, error) {
+	system, err := tableaux.ParseSystem(req.Modal)
+	if err != nil {
+		return tableaux.Options{}, badRequest(err)
+	}
+	if req.Intuitionistic {
+		if system != tableaux.NonModal || req.Unsigned {
+			return tableaux.Options{}, badRequest(errors.New("intuitionistic doesn't go with modal or unsigned"))
+		}
+		system = tableaux.Intuitionistic
+	}
+	logic, err := truthtable.ParseLogic(req.Logic)
+	if err != nil {
+		return tableaux.Options{}, badRequest(err)
+	}
+	if logic != truthtable.Classical && (system != tableaux.NonModal || req.Unsigned) {
+		return tableaux.Options{}, badRequest(errors.New("three-valued logic doesn't go with modal, intuitionistic or unsigned"))
+	}
+	if req.LTL {
+		return tableaux.Options{}, badRequest(errors.New("ltl only goes with /satisfiable"))
+	}
+	if req.QBF {
+		return tableaux.Options{}, badRequest(errors.New("qbf only goes with /satisfiable"))
+	}
+	if req.Interpolate && (system != tableaux.NonModal || logic != truthtable.Classical || req.Unsigned) {
+		return tableaux.Options{}, badRequest(errors.New("interpolate doesn't go with modal, intuitionistic, logic or unsigned"))
+	}
+	for _, tree := range trees {
+		if tree.Temporal() {
+			return tableaux.Options{}, badRequest(fmt.Errorf("%q has temporal operators, use /satisfiable with ltl", node.ExpressionToString(tree)))
+		}
+		if tree.Modal() && system == tableaux.NonModal {
+			return tableaux.Options{}, badRequest(fmt.Errorf("%q has modal operators, need modal K, T, S4 or S5", node.ExpressionToString(tree)))
+		}
+		if (tree.Modal() || tree.FirstOrder()) && system == tableaux.Intuitionistic {
+			return tableaux.Options{}, badRequest(fmt.Errorf("intuitionistic logic is propositional, without modal operators, not %q", node.ExpressionToString(tree)))
+		}
+		if tree.FirstOrder() && req.Interpolate {
+			return tableaux.Options{}, badRequest(fmt.Errorf("interpolants are for propositional logic, not %q", node.ExpressionToString(tree)))
+		}
+		if (tree.Modal() || tree.FirstOrder()) && logic != truthtable.Classical {
+			return tableaux.Options{}, badRequest(fmt.Errorf("three-valued logic is propositional, without modal operators, not %q", node.ExpressionToString(tree)))
+		}
+	}
+	return tableaux.Options{Unsigned: req.Unsigned, Regular: req.Regular, Modal: system, Logic: logic}, nil
+}
+
+// errorResponse is what any endpoint gives back when it fails.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// httpError carries an HTTP status code along with an error.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string { return e.err.Error() }
+
+func badRequest(err error) error {
+	return &httpError{status: http.StatusBadRequest, err: err}
+}
+
+type handlerFunc func(ctx context.Context, req *request) (interface{}, error)
+
+// handle wraps the function that does the work of an endpoint with
+// request decoding, limits, and JSON encoding of results and errors.
+func (s *Server) handle(fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := s.decode(w, r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		result, err := s.run(r.Context(), func(ctx context.Context) (interface{}, error) {
+			return fn(ctx, req)
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// decode checks method and size of a request, and unmarshals its body.
+func (s *Server) decode(w http.ResponseWriter, r *http.Request) (*request, error) {
+	if r.Method != http.MethodPost {
+		return nil, &httpError{status: http.StatusMethodNotAllowed, err: errors.New("use POST")}
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodyBytes)
+	req := &request{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, badRequest(fmt.Errorf("decoding request: %v", err))
+	}
+	return req, nil
+}
+
+// run does work with a concurrency slot, giving up if it takes longer
+// than the configured timeout, or if the client goes away.
+func (s *Server) run(ctx context.Context, work func(context.Context) (interface{}, error)) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, &httpError{status: http.StatusServiceUnavailable, err: errors.New("server busy")}
+	}
+
+	type outcome struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() { <-s.slots }()
+		defer func() {
+			// The tableaux package panics on internal errors,
+			// which shouldn't take down the whole server.
+			if r := recover(); r != nil {
+				done <- outcome{nil, fmt.Errorf("internal error: %v", r)}
+			}
+		}()
+		result, err := work(ctx)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, &httpError{status: http.StatusServiceUnavailable, err: fmt.Errorf("gave up after %v", s.config.Timeout)}
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var he *httpError
+	if errors.As(err, &he) {
+		status = he.status
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
+
+func parseFormula(formula string) (*node.Node, error) {
+	if formula == "" {
+		return nil, badRequest(errors.New("no formula"))
+	}
+	tree, err := parser.ParseString(formula)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	return tree, nil
+}
+
+// problemTrees parses either a single formula, or hypotheses and
+// a consequence, in the order tableaux.Setup wants them.
+func problemTrees(req *request) ([]*node.Node, error) {
+	if req.Formula != "" {
+		tree, err := parseFormula(req.Formula)
+		if err != nil {
+			return nil, err
+		}
+		return []*node.Node{tree}, nil
+	}
+	if req.Consequence == "" {
+		return nil, badRequest(errors.New("need either formula, or consequence and hypotheses"))
+	}
+	var trees []*node.Node
+	for _, formula := range append(append([]string(nil), req.Hypotheses...), req.Consequence) {
+		tree, err := parseFormula(formula)
+		if err != nil {
+			return nil, err
+		}
+		trees = append(trees, tree)
+	}
+	if len(trees) == 1 {
+		return nil, badRequest(errors.New("consequence needs at least one hypothesis"))
+	}
+	return trees, nil
+}
+
+type proofResponse struct {
+	Formula       string                        `json:"formula,omitempty"`
+	Hypotheses    []string                      `json:"hypotheses,omitempty"`
+	Consequence   string                        `json:"consequence,omitempty"`
+	Tautology     *bool                         `json:"tautology,omitempty"`
+	Follows       *bool                         `json:"follows,omitempty"`
+	Satisfiable   *bool                         `json:"satisfiable,omitempty"`
+	Unsigned      bool                          `json:"unsigned,omitempty"`
+	Modal         string                        `json:"modal,omitempty"`
+	Logic         string                        `json:"logic,omitempty"`
+	Closed        bool                          `json:"closed"`
+	Unknown       string                        `json:"unknown,omitempty"`
+	Size          *tableaux.Size                `json:"size,omitempty"`
+	Countermodels []map[string]bool             `json:"countermodels,omitempty"`
+	Models        []map[string]bool             `json:"models,omitempty"`
+	Kripke        []*tableaux.Kripke            `json:"kripke,omitempty"`      // Modal countermodels or models
+	ThreeValued   []map[string]truthtable.Value `json:"threevalued,omitempty"` // Three-valued countermodels or models
+	LTL           bool                          `json:"ltl,omitempty"`
+	Lasso         *tableaux.Lasso               `json:"lasso,omitempty"` // Linear temporal logic model
+	QBF           bool                          `json:"qbf,omitempty"`
+	True          *bool                         `json:"true,omitempty"`   // Quantified boolean formula's value
+	Skolem        []*truthtable.Skolem          `json:"skolem,omitempty"` // Skolem functions of a true one
+	Interpolant   string                        `json:"interpolant,omitempty"`
+	Tableau       *tableaux.JSONTnode           `json:"tableau,omitempty"`
+}
+
+// expand does the proof search for a request, within the server's
+// limits. If the tableau grows too big, expand fills in why in resp
+// and gives back false. Cancellation or time out is an error.
+func (s *Server) expand(ctx context.Context, root *tableaux.Tnode, resp *proofResponse) (bool, error) {
+	result, err := root.ExpandContext(ctx, s.config.Limits)
+	if result != tableaux.Unknown {
+		resp.Closed = result == tableaux.Closed
+		resp.Tableau = root.JSON()
+		return true, nil
+	}
+	if ctx.Err() != nil {
+		return false, &httpError{status: http.StatusServiceUnavailable, err: err}
+	}
+	size := root.Size()
+	resp.Unknown = err.Error()
+	resp.Size = &size
+	return false, nil
+}
+
+// openValuations gives back the valuation each open branch gives.
+func openValuations(root *tableaux.Tnode) []map[string]bool {
+	var valuations []map[string]bool
+	for _, leaf := range root.FindUnclosedLeaf() {
+		valuations = append(valuations, leaf.Valuation())
+	}
+	return valuations
+}
+
+// openModels fills in resp's models, or countermodels, that the open
+// branches of the tableau rooted at root describe: Kripke models
+// for modal logic, three-valued valuations for three-valued logic,
+// valuations otherwise.
+func openModels(root *tableaux.Tnode, opts tableaux.Options, resp *proofResponse, counter bool) {
+	if opts.Logic != truthtable.Classical {
+		for _, leaf := range root.FindUnclosedLeaf() {
+			resp.ThreeValued = append(resp.ThreeValued, leaf.ThreeValued())
+		}
+		return
+	}
+	if opts.Modal != tableaux.NonModal {
+		for _, leaf := range root.FindUnclosedLeaf() {
+			resp.Kripke = append(resp.Kripke, leaf.Kripke())
+		}
+		return
+	}
+	if counter {
+		resp.Countermodels = openValuations(root)
+	} else {
+		resp.Models = openValuations(root)
+	}
+}
+
+func (s *Server) prove(ctx context.Context, req *request) (interface{}, error) {
+	tree, err := parseFormula(req.Formula)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := req.options(tree)
+	if err != nil {
+		return nil, err
+	}
+	root, _ := tableaux.Setup([]*node.Node{tree}, opts)
+	resp := &proofResponse{
+		Formula:  node.ExpressionToString(tree),
+		Unsigned: req.Unsigned,
+		Modal:    opts.Modal.String(),
+		Logic:    opts.Logic.String(),
+	}
+	if done, err := s.expand(ctx, root, resp); !done {
+		return resp, err
+	}
+	resp.Tautology = &resp.Closed
+	openModels(root, opts, resp, true)
+	return resp, nil
+}
+
+func (s *Server) consequence(ctx context.Context, req *request) (interface{}, error) {
+	if req.Formula != "" {
+		return nil, badRequest(errors.New("use hypotheses and consequence, not formula"))
+	}
+	trees, err := problemTrees(req)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := req.options(trees...)
+	if err != nil {
+		return nil, err
+	}
+	root, final := tableaux.Setup(trees, opts)
+	resp := &proofResponse{
+		Consequence: node.ExpressionToString(trees[len(trees)-1]),
+		Unsigned:    req.Unsigned,
+		Modal:       opts.Modal.String(),
+		Logic:       opts.Logic.String(),
+	}
+	for _, tree := range trees[:len(trees)-1] {
+		resp.Hypotheses = append(resp.Hypotheses, node.ExpressionToString(tree))
+	}
+	if done, err := s.expand(ctx, root, resp); !done {
+		return resp, err
+	}
+	resp.Follows = &resp.Closed
+	openModels(root, opts, resp, true)
+	if req.Interpolate && resp.Closed {
+		interpolant, err := root.Interpolant(final)
+		if err == nil {
+			err = tableaux.CheckInterpolant(trees[:len(trees)-1], trees[len(trees)-1], interpolant)
+		}
+		if err != nil {
+			return nil, err
+		}
+		resp.Interpolant = node.ExpressionToString(interpolant)
+	}
+	return resp, nil
+}
+
+// satisfiable builds a tableau with the formula signed T: an open branch
+// means some valuation makes the formula true.
+func (s *Server) satisfiable(ctx context.Context, req *request) (interface{}, error) {
+	tree, err := parseFormula(req.Formula)
+	if err != nil {
+		return nil, err
+	}
+	if req.LTL {
+		return s.satisfiableLTL(ctx, req, tree)
+	}
+	if req.QBF {
+		return s.satisfiableQBF(ctx, req, tree)
+	}
+	opts, err := req.options(tree)
+	if err != nil {
+		return nil, err
+	}
+	root := tableaux.Root(tree, true, opts)
+	resp := &proofResponse{
+		Formula:  node.ExpressionToString(tree),
+		Unsigned: req.Unsigned,
+		Modal:    opts.Modal.String(),
+		Logic:    opts.Logic.String(),
+	}
+	if done, err := s.expand(ctx, root, resp); !done {
+		return resp, err
+	}
+	satisfiable := !resp.Closed
+	resp.Satisfiable = &satisfiable
+	openModels(root, opts, resp, false)
+	return resp, nil
+}
+
+// satisfiableLTL decides a linear temporal logic formula
+// with a graph tableau, giving back a lasso model if there is one.
+func (s *Server) satisfiableLTL(ctx context.Context, req *request, tree *node.Node) (interface{}, error) {
+	if req.Unsigned || req.Regular || req.Modal != "" || req.Intuitionistic || req.Logic != "" {
+		return nil, badRequest(errors.New("ltl doesn't go with unsigned, regular, modal, intuitionistic or logic"))
+	}
+	if tree.Modal() || tree.FirstOrder() {
+		return nil, badRequest(fmt.Errorf("linear temporal logic is propositional, without modal operators, not %q", node.ExpressionToString(tree)))
+	}
+	tblx := tableaux.SetupLTL([]*node.Node{tree})
+	resp := &proofResponse{Formula: node.ExpressionToString(tree), LTL: true}
+	result, err := tblx.ExpandContext(ctx, s.config.Limits)
+	if result == tableaux.Unknown {
+		if ctx.Err() != nil {
+			return nil, &httpError{status: http.StatusServiceUnavailable, err: err}
+		}
+		size := tblx.Size()
+		resp.Unknown = err.Error()
+		resp.Size = &size
+		return resp, nil
+	}
+	satisfiable := result == tableaux.Open
+	resp.Satisfiable = &satisfiable
+	resp.Closed = !satisfiable
+	resp.Lasso = tblx.Model()
+	return resp, nil
+}
+
+// satisfiableQBF decides whether a closed quantified boolean formula
+// is true with a QBF tableau, giving back Skolem functions if it is.
+func (s *Server) satisfiableQBF(ctx context.Context, req *request, tree *node.Node) (interface{}, error) {
+	if req.Unsigned || req.Regular || req.Modal != "" || req.Intuitionistic || req.Logic != "" || req.LTL {
+		return nil, badRequest(errors.New("qbf doesn't go with unsigned, regular, modal, intuitionistic, logic or ltl"))
+	}
+	if !tree.QBF() {
+		return nil, badRequest(fmt.Errorf("quantified boolean formulas are propositional, without modal operators or predicates, not %q", node.ExpressionToString(tree)))
+	}
+	if free := truthtable.Identifiers(tree); len(free) > 0 {
+		return nil, badRequest(fmt.Errorf("%q has free identifiers %v, qbf decides closed formulas", node.ExpressionToString(tree), free))
+	}
+	tree = node.NewRenamer().Rename(tree)
+	root := tableaux.SetupQBF([]*node.Node{tree}, tableaux.Options{})
+	resp := &proofResponse{Formula: node.ExpressionToString(tree), QBF: true}
+	if done, err := s.expand(ctx, root, resp); !done {
+		return resp, err
+	}
+	value := !resp.Closed
+	resp.True = &value
+	if value {
+		resp.Skolem = root.FindUnclosedLeaf()[0].Skolem()
+	}
+	return resp, nil
+}
+
+type truthTableResponse struct {
+	Formula     string               `json:"formula"`
+	Identifiers []string             `json:"identifiers"`
+	Rows        []truthTableRow      `json:"rows"`
+	True        *bool                `json:"true,omitempty"`   // Closed quantified boolean formula's value
+	Skolem      []*truthtable.Skolem `json:"skolem,omitempty"` // Skolem functions of a true one
+}
+
+type truthTableRow struct {
+	Values []bool `json:"values"`
+	Result bool   `json:"result"`
+}
+
+// threeValuedRow is a truthTableRow of a three-valued table,
+// with values T, U and F.
+type threeValuedRow struct {
+	Values []truthtable.Value `json:"values"`
+	Result truthtable.Value   `json:"result"`
+}
+
+type threeValuedResponse struct {
+	Formula     string           `json:"formula"`
+	Logic       string           `json:"logic"`
+	Identifiers []string         `json:"identifiers"`
+	Rows        []threeValuedRow `json:"rows"`
+	Valid       bool             `json:"valid"`
+}
+
+// Truth tables have 2ⁿ rows, three-valued ones 3ⁿ, for n identifiers.
+// Every quantifier of a quantified boolean formula doubles the time it
+// takes to evaluate, so the identifiers they bind count too.
+const (
+	maxTruthTableIdentifiers  = 16
+	maxThreeValuedIdentifiers = 10
+)
+
+// quantifiers counts the quantifiers in tree.
+func quantifiers(tree *node.Node) int {
+	if tree == nil {
+		return 0
+	}
+	n := quantifiers(tree.Left) + quantifiers(tree.Right)
+	if lexer.Quantifier(tree.Op) {
+		n++
+	}
+	return n
+}
+
+func (s *Server) truthTable(ctx context.Context, req *request) (interface{}, error) {
+	tree, err := parseFormula(req.Formula)
+	if err != nil {
+		return nil, err
+	}
+	if !tree.QBF() {
+		return nil, badRequest(errors.New("truth tables are for propositional logic and quantified boolean formulas"))
+	}
+	logic, err := truthtable.ParseLogic(req.Logic)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	quantified := tree.FirstOrder()
+	if quantified && logic != truthtable.Classical {
+		return nil, badRequest(errors.New("three-valued truth tables don't do quantifiers"))
+	}
+	if quantified {
+		tree = node.NewRenamer().Rename(tree)
+	}
+	limit := maxTruthTableIdentifiers
+	if logic != truthtable.Classical {
+		limit = maxThreeValuedIdentifiers
+	}
+	if n := len(truthtable.Identifiers(tree)) + quantifiers(tree); n > limit {
+		return nil, badRequest(fmt.Errorf("%d identifiers, more than the %d a truth table can have", n, limit))
+	}
+	if logic != truthtable.Classical {
+		table := truthtable.NewThreeValued(tree, logic)
+		resp := &threeValuedResponse{
+			Formula:     node.ExpressionToString(tree),
+			Logic:       logic.String(),
+			Identifiers: table.Identifiers,
+			Valid:       table.Valid(),
+		}
+		for _, row := range table.Rows {
+			resp.Rows = append(resp.Rows, threeValuedRow{Values: row.Values, Result: row.Result})
+		}
+		return resp, nil
+	}
+	table := truthtable.New(tree)
+	resp := &truthTableResponse{
+		Formula:     node.ExpressionToString(tree),
+		Identifiers: table.Identifiers,
+	}
+	for _, row := range table.Rows {
+		resp.Rows = append(resp.Rows, truthTableRow{Values: row.Values, Result: row.Result})
+	}
+	if quantified && len(table.Identifiers) == 0 {
+		value := table.Rows[0].Result
+		resp.True = &value
+		resp.Skolem = truthtable.SkolemFunctions(tree)
+	}
+	return resp, nil
+}
+
+type parseResponse struct {
+	Formula string    `json:"formula"`
+	Tree    *jsonNode `json:"tree"`
+}
+
+// jsonNode mirrors a node.Node, with token names for operators.
+type jsonNode struct {
+	Op    string      `json:"op"`
+	Ident string      `json:"ident,omitempty"`
+	Args  []*jsonNode `json:"args,omitempty"` // Arguments of predicates and functions
+	Left  *jsonNode   `json:"left,omitempty"`
+	Right *jsonNode   `json:"right,omitempty"`
+}
+
+func jsonNodeFrom(n *node.Node) *jsonNode {
+	if n == nil {
+		return nil
+	}
+	j := &jsonNode{
+		Op:    lexer.TokenName(n.Op),
+		Ident: n.Ident,
+		Left:  jsonNodeFrom(n.Left),
+		Right: jsonNodeFrom(n.Right),
+	}
+	for _, arg := range n.Args {
+		j.Args = append(j.Args, jsonNodeFrom(arg))
+	}
+	return j
+}
+
+func (s *Server) parse(ctx context.Context, req *request) (interface{}, error) {
+	tree, err := parseFormula(req.Formula)
+	if err != nil {
+		return nil, err
+	}
+	return &parseResponse{
+		Formula: node.ExpressionToString(tree),
+		Tree:    jsonNodeFrom(tree),
+	}, nil
+}
+
+// render gives back GraphViz dot text for a finished tableau, or,
+// with "format": "svg", the SVG that GraphViz makes of it. With
+// "format": "sequent" or "latex", it gives back a closed tableau's
+// sequent calculus derivation.
+func (s *Server) render(w http.ResponseWriter, r *http.Request) {
+	req, err := s.decode(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result, err := s.run(r.Context(), func(ctx context.Context) (interface{}, error) {
+		trees, err := problemTrees(req)
+		if err != nil {
+			return nil, err
+		}
+		opts, err := req.options(trees...)
+		if err != nil {
+			return nil, err
+		}
+		root, _ := tableaux.Setup(trees, opts)
+		resp := &proofResponse{}
+		done, err := s.expand(ctx, root, resp)
+		if err != nil {
+			return nil, err
+		}
+		if !done {
+			return nil, &httpError{status: http.StatusUnprocessableEntity, err: errors.New(resp.Unknown)}
+		}
+
+		if req.Format == "sequent" || req.Format == "latex" {
+			return derivation(root, req.Format, resp)
+		}
+
+		var dot bytes.Buffer
+		tableaux.GraphTableaux(&dot, root, tableaux.GraphOptions{})
+
+		switch req.Format {
+		case "", "dot":
+			return dot.Bytes(), nil
+		case "svg":
+			return s.svg(ctx, &dot)
+		}
+		return nil, badRequest(fmt.Errorf("unknown format %q", req.Format))
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	contentType := "text/vnd.graphviz"
+	switch req.Format {
+	case "svg":
+		contentType = "image/svg+xml"
+	case "sequent":
+		contentType = "text/plain; charset=utf-8"
+	case "latex":
+		contentType = "application/x-latex"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(result.([]byte))
+}
+
+// derivation gives back the sequent calculus derivation of the closed
+// tableau rooted at root, as text or, for format "latex", bussproofs.
+func derivation(root *tableaux.Tnode, format string, resp *proofResponse) ([]byte, error) {
+	if !resp.Closed {
+		return nil, &httpError{status: http.StatusUnprocessableEntity, err: errors.New("open tableau, no sequent calculus derivation")}
+	}
+	d, err := root.Derivation()
+	if err != nil {
+		return nil, &httpError{status: http.StatusUnprocessableEntity, err: err}
+	}
+	var buf bytes.Buffer
+	if format == "latex" {
+		d.LaTeX(&buf)
+	} else {
+		d.Print(&buf)
+	}
+	return buf.Bytes(), nil
+}
+
+// svg runs GraphViz on dot input to get SVG output.
+func (s *Server) svg(ctx context.Context, dot *bytes.Buffer) ([]byte, error) {
+	if s.config.DotCommand == "" {
+		return nil, &httpError{status: http.StatusNotImplemented, err: errors.New("SVG rendering not configured")}
+	}
+	path, err := exec.LookPath(s.config.DotCommand)
+	if err != nil {
+		return nil, &httpError{status: http.StatusNotImplemented, err: fmt.Errorf("SVG rendering unavailable: %v", err)}
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "-Tsvg")
+	cmd.Stdin = dot
+	cmd.Stderr = &stderr
+	svg, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %v: %s", s.config.DotCommand, err, stderr.String())
+	}
+	return svg, nil
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tableaux-in-go/src/checker"
+	"tableaux-in-go/src/tableaux"
+)
+
+// post sends body to path on s, giving back the status and the body
+// of the response.
+func post(s *Server, path, body string) (int, []byte) {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w.Code, w.Body.Bytes()
+}
+
+// Requests, the status of the response, and some of the top level
+// fields of its JSON object.
+var requestTests = []struct {
+	path, body string
+	status     int
+	want       map[string]interface{}
+}{
+	{"/prove", `{"formula": "p > (q > p)"}`, 200, map[string]interface{}{"tautology": true, "closed": true}},
+	{"/prove", `{"formula": "p > q"}`, 200, map[string]interface{}{"tautology": false, "closed": false}},
+	{"/prove", `{"formula": "p > (q > p)", "unsigned": true}`, 200, map[string]interface{}{"tautology": true, "unsigned": true}},
+	{"/prove", `{"formula": "[]p > [][]p", "modal": "S4"}`, 200, map[string]interface{}{"tautology": true, "modal": "S4"}},
+	{"/prove", `{"formula": "[]p > [][]p", "modal": "T"}`, 200, map[string]interface{}{"tautology": false, "modal": "T"}},
+	{"/prove", `{"formula": "p | ~p", "intuitionistic": true}`, 200, map[string]interface{}{"tautology": false}},
+	{"/prove", `{"formula": "p | ~p", "logic": "LP"}`, 200, map[string]interface{}{"tautology": true, "logic": "LP"}},
+	{"/prove", `{"formula": "forall y. exists x. R(x,y) > exists x. forall y. R(x,y)"}`, 200,
+		map[string]interface{}{"unknown": "max instances 4"}},
+	{"/consequence", `{"hypotheses": ["p > q", "q > r"], "consequence": "p > r"}`, 200, map[string]interface{}{"follows": true}},
+	{"/consequence", `{"hypotheses": ["p > q", "q"], "consequence": "p"}`, 200, map[string]interface{}{"follows": false}},
+	{"/consequence", `{"hypotheses": ["p & q"], "consequence": "q | r", "interpolate": true}`, 200,
+		map[string]interface{}{"follows": true, "interpolant": "q"}},
+	{"/satisfiable", `{"formula": "p & ~q"}`, 200, map[string]interface{}{"satisfiable": true}},
+	{"/satisfiable", `{"formula": "p & ~p"}`, 200, map[string]interface{}{"satisfiable": false}},
+	{"/satisfiable", `{"formula": "G p & F ~p", "ltl": true}`, 200, map[string]interface{}{"satisfiable": false, "ltl": true}},
+	{"/satisfiable", `{"formula": "Ap Eq (p = q)", "qbf": true}`, 200, map[string]interface{}{"true": true, "qbf": true}},
+	{"/truthtable", `{"formula": "p | ~p"}`, 200, map[string]interface{}{"formula": "p | ~p"}},
+	{"/truthtable", `{"formula": "Ap Eq (p = q)"}`, 200, map[string]interface{}{"true": true}},
+	{"/truthtable", `{"formula": "p > p", "logic": "K3"}`, 200, map[string]interface{}{"valid": false, "logic": "K3"}},
+	{"/parse", `{"formula": "p & q | r"}`, 200, map[string]interface{}{"formula": "(p & q) | r"}},
+
+	{"/prove", `{"formula": "p >"}`, 400, nil},
+	{"/prove", `{}`, 400, nil},
+	{"/prove", `{"formula": "p", "modal": "K4"}`, 400, nil},
+	{"/prove", `{"formula": "[]p"}`, 400, nil},
+	{"/prove", `{"formula": "p"`, 400, nil},
+	{"/consequence", `{"consequence": "p"}`, 400, nil},
+	{"/truthtable", `{"formula": "P(x)"}`, 400, nil},
+	{"/truthtable", `{"formula": "p1 & p2 & p3 & p4 & p5 & p6 & p7 & p8 & p9 & p10 & p11", "logic": "K3"}`, 400, nil},
+}
+
+func TestRequests(t *testing.T) {
+	s := New(DefaultConfig)
+	for _, test := range requestTests {
+		status, body := post(s, test.path, test.body)
+		if status != test.status {
+			t.Errorf("%s %s: status %d, want %d: %s", test.path, test.body, status, test.status, body)
+			continue
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Errorf("%s %s: %v", test.path, test.body, err)
+			continue
+		}
+		if status != http.StatusOK && resp["error"] == nil {
+			t.Errorf("%s %s: status %d without an error", test.path, test.body, status)
+		}
+		for field, want := range test.want {
+			got := resp[field]
+			if text, ok := got.(string); ok && field == "unknown" {
+				if !strings.Contains(text, want.(string)) {
+					t.Errorf("%s %s: %s %q, want %q", test.path, test.body, field, text, want)
+				}
+				continue
+			}
+			if got != want {
+				t.Errorf("%s %s: %s %v, want %v", test.path, test.body, field, got, want)
+			}
+		}
+	}
+}
+
+// More than 16 identifiers make too big a truth table.
+func TestTruthTableLimit(t *testing.T) {
+	s := New(DefaultConfig)
+	for _, n := range []int{16, 17} {
+		var ids []string
+		for i := 1; i <= n; i++ {
+			ids = append(ids, fmt.Sprintf("p%d", i))
+		}
+		status, body := post(s, "/truthtable", fmt.Sprintf(`{"formula": %q}`, strings.Join(ids, " | ")))
+		want := http.StatusOK
+		if n > maxTruthTableIdentifiers {
+			want = http.StatusBadRequest
+		}
+		if status != want {
+			t.Errorf("%d identifiers: status %d, want %d", n, status, want)
+			continue
+		}
+		if status != http.StatusOK {
+			continue
+		}
+		var resp truthTableResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Rows) != 1<<n {
+			t.Errorf("%d identifiers: %d rows, want %d", n, len(resp.Rows), 1<<n)
+		}
+	}
+}
+
+// The tableaux that /prove and /consequence give back check out.
+// Proofs that hit a limit don't give any back.
+func TestProofsCheck(t *testing.T) {
+	s := New(DefaultConfig)
+	for _, test := range requestTests {
+		if test.status != http.StatusOK || test.path != "/prove" && test.path != "/consequence" || test.want["unknown"] != nil {
+			continue
+		}
+		_, body := post(s, test.path, test.body)
+		proof, err := checker.ReadJSON(bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("%s %s: %v", test.path, test.body, err)
+			continue
+		}
+		if err := checker.Check(proof); err != nil {
+			t.Errorf("%s %s: %v", test.path, test.body, err)
+		}
+	}
+}
+
+func TestMethodAndSize(t *testing.T) {
+	config := DefaultConfig
+	config.MaxBodyBytes = 64
+	s := New(config)
+
+	r := httptest.NewRequest(http.MethodGet, "/prove", nil)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	formula := strings.Repeat("p & ", 20) + "p"
+	if status, _ := post(s, "/prove", fmt.Sprintf(`{"formula": %q}`, formula)); status != http.StatusBadRequest {
+		t.Errorf("too big a request: status %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestMaxNodes(t *testing.T) {
+	config := DefaultConfig
+	config.Limits = tableaux.Limits{MaxNodes: 5}
+	s := New(config)
+	status, body := post(s, "/prove", `{"formula": "(p > q) > ((q > r) > (p > r))"}`)
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %s", status, body)
+	}
+	var resp proofResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Tautology != nil || !strings.Contains(resp.Unknown, "max nodes 5") || resp.Size == nil {
+		t.Errorf("got %s, want unknown after max nodes 5, with a size", body)
+	}
+
+	if status, _ := post(s, "/render", `{"formula": "(p > q) > ((q > r) > (p > r))"}`); status != http.StatusUnprocessableEntity {
+		t.Errorf("render: status %d, want %d", status, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestRender(t *testing.T) {
+	s := New(DefaultConfig)
+	renderTests := []struct {
+		body        string
+		status      int
+		contentType string
+		has         string
+	}{
+		{`{"formula": "p > p"}`, 200, "text/vnd.graphviz", "digraph"},
+		{`{"formula": "p > p", "format": "sequent"}`, 200, "text/plain; charset=utf-8", "⊢"},
+		{`{"formula": "p > p", "format": "latex"}`, 200, "application/x-latex", `\begin{prooftree}`},
+		{`{"formula": "p > q", "format": "sequent"}`, 422, "application/json", "open tableau"},
+		{`{"formula": "p > p", "format": "png"}`, 400, "application/json", "unknown format"},
+	}
+	for _, test := range renderTests {
+		r := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("%s: status %d, want %d: %s", test.body, w.Code, test.status, w.Body)
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); ct != test.contentType {
+			t.Errorf("%s: content type %q, want %q", test.body, ct, test.contentType)
+		}
+		if !strings.Contains(w.Body.String(), test.has) {
+			t.Errorf("%s: %q lacks %q", test.body, w.Body, test.has)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package tableaux
+
+// Text-art drawing of a tableau: branches of a bifurcation go side by
+// side, with connector lines between a formula and its two subbranches.
+// A subtree too wide for the space available gets its two subbranches
+// stacked one above the other, indented, like the output of tree(1).
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// DrawOptions tells DrawTableaux how to lay out a tableau.
+type DrawOptions struct {
+	Width      int  // Terminal width in columns, 0 means 80.
+	ASCII      bool // Plain ASCII connectors and marks, no box-drawing characters.
+	NumberOpen bool // Number open branches, from 1, in FindUnclosedLeaf order.
+	MarkUnused bool // Put a "*" after formulas with no inferences subjoined yet.
+}
+
+// Marks and connectors, box-drawing and plain ASCII versions.
+type drawGlyphs struct {
+	closed, open, unexplored    string
+	across, down, downRight     string
+	tee, elbow, vertical, blank string
+}
+
+var boxGlyphs = drawGlyphs{
+	closed: "✗", open: "○", unexplored: "…",
+	across: "─", down: "┬", downRight: "┐",
+	tee: "├─ ", elbow: "└─ ", vertical: "│  ", blank: "   ",
+}
+
+var asciiGlyphs = drawGlyphs{
+	closed: "x", open: "o", unexplored: "...",
+	across: "-", down: "+", downRight: "+",
+	tee: "+- ", elbow: "`- ", vertical: "|  ", blank: "   ",
+}
+
+// Columns between two subbranches drawn side by side.
+const drawGap = 3
+
+// DrawTableaux writes a text-art tree of the tableau rooted at root on w.
+// Closed branches end in a line marked ✗, naming the line that the leaf
+// formula contradicts, open branches end in a line marked ○, and branches
+// depth-first search never got to end in a line marked ….
+func DrawTableaux(w io.Writer, root *Tnode, opts DrawOptions) {
+	d := &drawer{glyphs: boxGlyphs, width: opts.Width, markUnused: opts.MarkUnused}
+	if opts.NumberOpen {
+		d.openNumbers = make(map[*Tnode]int)
+		for i, leaf := range root.FindUnclosedLeaf() {
+			d.openNumbers[leaf] = i + 1
+		}
+	}
+	if opts.ASCII {
+		d.glyphs = asciiGlyphs
+	}
+	if d.width <= 0 {
+		d.width = 80
+	}
+
+	for _, line := range d.draw(root, d.width) {
+		fmt.Fprintf(w, "%s\n", strings.TrimRight(line, " "))
+	}
+}
+
+type drawer struct {
+	glyphs      drawGlyphs
+	width       int
+	markUnused  bool
+	openNumbers map[*Tnode]int
+}
+
+// formulaLine gives back the text for a single Tnode, as it appears
+// in a text-art tree.
+func (d *drawer) formulaLine(n *Tnode) string {
+	line := fmt.Sprintf("%d. %s", n.LineNumber, n.signedExpression())
+	if n.inferredFrom != nil {
+		line += fmt.Sprintf(" (%d, %s)", n.inferredFrom.LineNumber, ruleName(n.inferredFrom))
+	}
+	if d.markUnused && !n.Used {
+		line += " *"
+	}
+	return line
+}
+
+// leafLine gives back the text that marks the end of a branch.
+func (d *drawer) leafLine(leaf *Tnode) string {
+	if leaf.closed && leaf.Contradictory == leaf {
+		return d.glyphs.closed + " contradicts itself"
+	}
+	if leaf.closed {
+		return fmt.Sprintf("%s contradicts %d", d.glyphs.closed, leaf.Contradictory.LineNumber)
+	}
+	if leaf.unexplored {
+		return d.glyphs.unexplored + " unexplored"
+	}
+	if number, ok := d.openNumbers[leaf]; ok {
+		return fmt.Sprintf("%s open %d", d.glyphs.open, number)
+	}
+	return d.glyphs.open + " open"
+}
+
+// chain follows Tnode.Left links from n until it finds a bifurcation
+// or a leaf, giving back the lines of text for that linear run of
+// formulas, and the Tnode at the end of the run.
+func (d *drawer) chain(n *Tnode) ([]string, *Tnode) {
+	var lines []string
+	for {
+		lines = append(lines, d.formulaLine(n))
+		if n.Left == nil || n.Right != nil {
+			break
+		}
+		n = n.Left
+	}
+	if n.Left == nil && n.Right == nil {
+		lines = append(lines, d.leafLine(n))
+	}
+	return lines, n
+}
+
+// naturalWidth finds the number of columns the subtree rooted at n
+// takes up with every bifurcation drawn side by side.
+func (d *drawer) naturalWidth(n *Tnode) int {
+	lines, end := d.chain(n)
+	max := 0
+	for _, line := range lines {
+		if l := utf8.RuneCountInString(line); l > max {
+			max = l
+		}
+	}
+	if end.Left != nil && end.Right != nil {
+		if l := d.naturalWidth(end.Left) + drawGap + d.naturalWidth(end.Right); l > max {
+			max = l
+		}
+	}
+	return max
+}
+
+// draw lays out the subtree rooted at n in no more than width columns
+// (as long as width allows for anything at all), giving back one string
+// per line of output, each padded to the same number of columns.
+func (d *drawer) draw(n *Tnode, width int) []string {
+	lines, end := d.chain(n)
+
+	var block []string
+	for _, line := range lines {
+		block = append(block, wrapLine(line, width)...)
+	}
+
+	if end.Left != nil && end.Right != nil {
+		if d.naturalWidth(end.Left)+drawGap+d.naturalWidth(end.Right) <= width {
+			block = append(block, d.sideBySide(end.Left, end.Right, width)...)
+		} else {
+			block = append(block, d.stacked(end.Left, end.Right, width)...)
+		}
+	}
+
+	return padBlock(block)
+}
+
+// sideBySide draws the left and right subbranches next to each other,
+// under a connector line.
+func (d *drawer) sideBySide(left, right *Tnode, width int) []string {
+	leftBlock := d.draw(left, width)
+	leftWidth := blockWidth(leftBlock)
+	rightBlock := d.draw(right, width-leftWidth-drawGap)
+
+	connector := d.glyphs.down + strings.Repeat(d.glyphs.across, leftWidth+drawGap-1) + d.glyphs.downRight
+
+	block := []string{connector}
+	spacer := strings.Repeat(" ", drawGap)
+	for i := 0; i < len(leftBlock) || i < len(rightBlock); i++ {
+		l := strings.Repeat(" ", leftWidth)
+		if i < len(leftBlock) {
+			l = leftBlock[i]
+		}
+		r := ""
+		if i < len(rightBlock) {
+			r = rightBlock[i]
+		}
+		block = append(block, l+spacer+r)
+	}
+	return block
+}
+
+// stacked draws the left subbranch above the right subbranch,
+// both indented, with tree(1) style connectors.
+func (d *drawer) stacked(left, right *Tnode, width int) []string {
+	indent := utf8.RuneCountInString(d.glyphs.tee)
+
+	var block []string
+	for i, line := range d.draw(left, width-indent) {
+		prefix := d.glyphs.vertical
+		if i == 0 {
+			prefix = d.glyphs.tee
+		}
+		block = append(block, prefix+line)
+	}
+	for i, line := range d.draw(right, width-indent) {
+		prefix := d.glyphs.blank
+		if i == 0 {
+			prefix = d.glyphs.elbow
+		}
+		block = append(block, prefix+line)
+	}
+	return block
+}
+
+// wrapLine breaks a line of text longer than width columns at spaces,
+// indenting continuation lines. A single word longer than width
+// columns stays intact, there's no good place to break it.
+func wrapLine(line string, width int) []string {
+	const continuation = "    "
+	if width <= len(continuation) || utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range strings.Split(line, " ") {
+		if current != "" && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, current)
+			current = continuation
+		}
+		if current != "" && current != continuation {
+			current += " "
+		}
+		current += word
+	}
+	return append(lines, current)
+}
+
+func blockWidth(block []string) int {
+	max := 0
+	for _, line := range block {
+		if l := utf8.RuneCountInString(line); l > max {
+			max = l
+		}
+	}
+	return max
+}
+
+// padBlock pads every line of block with spaces to the same number of
+// columns, so that blocks can get put side by side.
+func padBlock(block []string) []string {
+	width := blockWidth(block)
+	for i, line := range block {
+		block[i] = line + strings.Repeat(" ", width-utf8.RuneCountInString(line))
+	}
+	return block
+}
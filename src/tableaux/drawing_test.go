@@ -0,0 +1,71 @@
+package tableaux
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// A bifurcation that fits goes side by side, numbering open branches
+// in FindUnclosedLeaf order.
+func TestDrawTableaux(t *testing.T) {
+	root, _ := Setup(parseAll(t, []string{"(p | q) > (p & q)"}), Options{})
+	root.Expand()
+
+	var buf bytes.Buffer
+	DrawTableaux(&buf, root, DrawOptions{NumberOpen: true})
+	want := `0. F: (p | q) > (p & q)
+1. T: p | q (0, α)
+2. F: p & q (0, α)
+┬──────────────────────────────────┐
+3. T: p (1, β)                     4. T: q (1, β)
+┬─────────────────┐                ┬────────────────┐
+5. F: p (2, β)    6. F: q (2, β)   7. F: p (2, β)   8. F: q (2, β)
+✗ contradicts 3   ○ open 1         ○ open 2         ✗ contradicts 4
+`
+	if buf.String() != want {
+		t.Errorf("drawing:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+// Subbranches too wide to go side by side get stacked, and lines too
+// long for the width get wrapped, so no line is wider than asked for.
+func TestDrawTableauxWidth(t *testing.T) {
+	root, _ := Setup(parseAll(t, []string{"(p | q) > (p & q)"}), Options{})
+	root.Expand()
+
+	for _, width := range []int{20, 30, 40} {
+		for _, ascii := range []bool{false, true} {
+			var buf bytes.Buffer
+			DrawTableaux(&buf, root, DrawOptions{Width: width, ASCII: ascii})
+			text := buf.String()
+			for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+				if utf8.RuneCountInString(line) > width {
+					t.Errorf("width %d, ascii %v: %q is %d columns", width, ascii, line, utf8.RuneCountInString(line))
+				}
+			}
+			for _, line := range []string{"5. F: p (2, β)", "6. F: q (2, β)", "7. F: p (2, β)", "8. F: q (2, β)"} {
+				if !strings.Contains(text, line) {
+					t.Errorf("width %d, ascii %v: no %q in\n%s", width, ascii, line, text)
+				}
+			}
+			if ascii && strings.ContainsAny(text, "✗○…─┬┐├└│") {
+				t.Errorf("width %d: box-drawing characters in ASCII drawing\n%s", width, text)
+			}
+		}
+	}
+}
+
+// Unused formulas get marked. Atoms have no inferences, so they
+// never are.
+func TestDrawTableauxUnused(t *testing.T) {
+	root, _ := Start(parseAll(t, []string{"p & q", "p"}), Options{})
+
+	var buf bytes.Buffer
+	DrawTableaux(&buf, root, DrawOptions{MarkUnused: true, NumberOpen: true})
+	want := "0. T: p & q *\n1. F: p\n○ open 1\n"
+	if buf.String() != want {
+		t.Errorf("drawing:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
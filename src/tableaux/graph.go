@@ -0,0 +1,144 @@
+package tableaux
+
+// GraphViz "dot" output for tableaux. Node names derive from
+// Tnode.LineNumber, so the same tableau always gives back the
+// same dot text, which makes it possible to diff outputs.
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GraphOptions controls GraphTableaux output.
+type GraphOptions struct {
+	LeftToRight bool // Lay out the tableau from left to right, instead of top to bottom
+	Clusters    bool // Put each linear segment of a branch in its own cluster
+}
+
+// GraphTnode writes GraphViz directed graph dot input to argument w io.Writer.
+func (n *Tnode) GraphTnode(w io.Writer) {
+	GraphTableaux(w, n, GraphOptions{})
+}
+
+// GraphTableaux writes GraphViz dot input for the tableau rooted at root
+// to w. Each formula node has its line number, sign, and the line number
+// and rule type of the formula it's inferred from. Formulas all of whose
+// branches have closed get colored, closed branches end in a ✗ node
+// with a dashed edge back to the contradicted formula, open branches
+// end in a ○ node, and branches depth-first search never got to end in
+// a … node.
+func GraphTableaux(w io.Writer, root *Tnode, opts GraphOptions) {
+	fmt.Fprintf(w, "digraph g {\n")
+	if opts.LeftToRight {
+		fmt.Fprintf(w, "rankdir=LR;\n")
+	}
+	fmt.Fprintf(w, "node [shape=box];\n")
+	g := &grapher{w: w, opts: opts}
+	g.segment(root)
+	for _, edge := range g.contradictions {
+		fmt.Fprintf(w, "%s;\n", edge)
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+type grapher struct {
+	w    io.Writer
+	opts GraphOptions
+
+	// Dashed edges from closing leaf nodes to the formula they
+	// contradict get written last, so they don't affect ranking.
+	contradictions []string
+}
+
+// segment writes a linear run of formulas in the tableau, starting
+// at n and following Tnode.Left links to a bifurcation or a leaf,
+// then writes the segments below that bifurcation.
+func (g *grapher) segment(n *Tnode) {
+	if g.opts.Clusters {
+		fmt.Fprintf(g.w, "subgraph cluster_%d {\n", n.LineNumber)
+		fmt.Fprintf(g.w, "style=dotted;\n")
+	}
+
+	var last *Tnode
+	for p := n; p != nil; p = p.Left {
+		g.formula(p)
+		if last != nil {
+			fmt.Fprintf(g.w, "n%d -> n%d;\n", last.LineNumber, p.LineNumber)
+		}
+		last = p
+		if p.Right != nil {
+			break
+		}
+	}
+
+	if last.Left == nil && last.Right == nil {
+		g.leaf(last)
+	}
+
+	if g.opts.Clusters {
+		fmt.Fprintf(g.w, "}\n")
+	}
+
+	if last.Right != nil {
+		g.segment(last.Left)
+		fmt.Fprintf(g.w, "n%d -> n%d;\n", last.LineNumber, last.Left.LineNumber)
+		g.segment(last.Right)
+		fmt.Fprintf(g.w, "n%d -> n%d;\n", last.LineNumber, last.Right.LineNumber)
+	}
+}
+
+// formula writes a dot node for a single signed formula.
+func (g *grapher) formula(n *Tnode) {
+	label := fmt.Sprintf("%d. %s", n.LineNumber, n.signedExpression())
+	if n.inferredFrom != nil {
+		label += fmt.Sprintf("\\n(%d, %s)", n.inferredFrom.LineNumber, ruleName(n.inferredFrom))
+	}
+
+	attributes := ""
+	if n.allClosed() {
+		attributes = ", color=firebrick, fontcolor=firebrick"
+	}
+	fmt.Fprintf(g.w, "n%d [label=\"%s\"%s];\n", n.LineNumber, dotEscape(label), attributes)
+}
+
+// leaf writes the dot node that terminates a branch.
+func (g *grapher) leaf(n *Tnode) {
+	if n.closed {
+		fmt.Fprintf(g.w, "x%d [label=\"✗\", shape=plaintext, fontcolor=firebrick];\n", n.LineNumber)
+		fmt.Fprintf(g.w, "n%d -> x%d [color=firebrick];\n", n.LineNumber, n.LineNumber)
+		if n.Contradictory != n {
+			g.contradictions = append(g.contradictions,
+				fmt.Sprintf("n%d -> n%d [style=dashed, color=firebrick, constraint=false]", n.LineNumber, n.Contradictory.LineNumber),
+			)
+		}
+		return
+	}
+	if n.unexplored {
+		fmt.Fprintf(g.w, "u%d [label=\"…\", shape=plaintext, fontcolor=gray];\n", n.LineNumber)
+		fmt.Fprintf(g.w, "n%d -> u%d [color=gray, style=dotted];\n", n.LineNumber, n.LineNumber)
+		return
+	}
+	fmt.Fprintf(g.w, "o%d [label=\"○\", shape=plaintext, fontcolor=darkgreen];\n", n.LineNumber)
+	fmt.Fprintf(g.w, "n%d -> o%d [color=darkgreen];\n", n.LineNumber, n.LineNumber)
+}
+
+// allClosed returns true if every branch through n has closed.
+func (n *Tnode) allClosed() bool {
+	if n.Left == nil && n.Right == nil {
+		return n.closed
+	}
+	if n.Left != nil && !n.Left.allClosed() {
+		return false
+	}
+	if n.Right != nil && !n.Right.allClosed() {
+		return false
+	}
+	return true
+}
+
+// dotEscape puts backslashes in front of double quotes, so that
+// a string can appear inside a double-quoted dot label.
+func dotEscape(s string) string {
+	return strings.Replace(s, "\"", "\\\"", -1)
+}
@@ -0,0 +1,78 @@
+package tableaux
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// The same problem always gives back the same dot text, whatever
+// the options.
+func TestGraphTableauxDeterministic(t *testing.T) {
+	for _, opts := range []GraphOptions{{}, {LeftToRight: true}, {Clusters: true}} {
+		var first string
+		for i := 0; i < 5; i++ {
+			root, _ := Setup(parseAll(t, []string{"(p | q) > (p & q)"}), Options{})
+			root.Expand()
+			var buf bytes.Buffer
+			GraphTableaux(&buf, root, opts)
+			if i == 0 {
+				first = buf.String()
+			} else if buf.String() != first {
+				t.Fatalf("%+v: dot text differs between runs:\n%s\nand\n%s", opts, first, buf.String())
+			}
+		}
+	}
+}
+
+func TestGraphTableaux(t *testing.T) {
+	root, _ := Setup(parseAll(t, []string{"(p | q) > (p & q)"}), Options{})
+	root.Expand()
+
+	tests := []struct {
+		opts     GraphOptions
+		contains []string
+		count    map[string]int
+	}{
+		{
+			GraphOptions{},
+			[]string{
+				`n0 [label="0. F: (p | q) > (p & q)"];`,
+				`n5 [label="5. F: p\n(2, β)", color=firebrick, fontcolor=firebrick];`,
+				`n3 -> n5;`,
+				`n5 -> n3 [style=dashed, color=firebrick, constraint=false];`,
+				`n8 -> n4 [style=dashed, color=firebrick, constraint=false];`,
+			},
+			map[string]int{"rankdir": 0, "subgraph": 0, `label="✗"`: 2, `label="○"`: 2},
+		},
+		{
+			GraphOptions{LeftToRight: true},
+			[]string{"rankdir=LR;"},
+			map[string]int{"subgraph": 0},
+		},
+		{
+			GraphOptions{Clusters: true},
+			[]string{"subgraph cluster_0 {", "subgraph cluster_8 {"},
+			// One cluster per linear segment of a branch
+			map[string]int{"subgraph": 7, "rankdir": 0},
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		GraphTableaux(&buf, root, test.opts)
+		dot := buf.String()
+		if !strings.HasPrefix(dot, "digraph g {\n") || !strings.HasSuffix(dot, "}\n") {
+			t.Errorf("%+v: not a digraph:\n%s", test.opts, dot)
+		}
+		for _, s := range test.contains {
+			if !strings.Contains(dot, s) {
+				t.Errorf("%+v: no %q in\n%s", test.opts, s, dot)
+			}
+		}
+		for s, n := range test.count {
+			if got := strings.Count(dot, s); got != n {
+				t.Errorf("%+v: %d of %q, want %d", test.opts, got, s, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package tableaux
+
+// Self-contained HTML proof report: the tableau as a tree whose
+// bifurcations the reader can collapse, with CSS and JavaScript inline,
+// so the HTML file needs nothing else to display in a browser.
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	"tableaux-in-go/src/truthtable"
+)
+
+// HTMLReport holds what an HTML proof report says about a tableau,
+// other than the tableau itself.
+type HTMLReport struct {
+	Title       string   // Page title and top-level heading
+	Expressions []string // "Hypothesis: ...", "Consequence: ..." lines
+	Verdict     string   // "Formula is a tautology", etc
+}
+
+// htmlFormula is what the template needs to know about a single Tnode.
+type htmlFormula struct {
+	LineNumber  int
+	Text        string
+	Note        string // "(1, β)", line number and rule type of premise
+	Premise     string // Hover text describing premise
+	PremiseLine int    // -1 if no premise
+	Contradicts int    // -1 if formula doesn't close a branch
+}
+
+// htmlSegment is a linear run of formulas in a tableau, ending in either
+// a bifurcation (Left and Right non-nil) or a leaf node.
+type htmlSegment struct {
+	Formulas    []htmlFormula
+	Closed      bool
+	Open        bool
+	Unexplored  bool
+	Left, Right *htmlSegment
+}
+
+type htmlCountermodel struct {
+	Leaf   int
+	Values []string
+}
+
+type htmlPage struct {
+	HTMLReport
+	Root          *htmlSegment
+	Identifiers   []string
+	Countermodels []htmlCountermodel
+}
+
+// WriteHTML writes a complete, self-contained HTML document for the
+// tableau rooted at root to w. Hovering over a closed branch's leaf
+// highlights it and the formula it contradicts, hovering over any formula
+// shows and highlights the formula it's inferred from. Open branches
+// each get a row in a table of countermodels, three-valued ones if the
+// tableau is many-signed.
+func WriteHTML(w io.Writer, root *Tnode, report HTMLReport) error {
+	page := htmlPage{
+		HTMLReport:  report,
+		Root:        htmlSegmentFrom(root),
+		Identifiers: root.Identifiers(),
+	}
+
+	for _, leaf := range root.FindUnclosedLeaf() {
+		valuation := make(map[string]string)
+		if root.tableau.opts.Logic != truthtable.Classical {
+			for id, v := range leaf.ThreeValued() {
+				valuation[id] = v.String()
+			}
+		} else {
+			for id, v := range leaf.Valuation() {
+				valuation[id] = "F"
+				if v {
+					valuation[id] = "T"
+				}
+			}
+		}
+		cm := htmlCountermodel{Leaf: leaf.LineNumber}
+		for _, id := range page.Identifiers {
+			value := "-"
+			if v, ok := valuation[id]; ok {
+				value = v
+			}
+			cm.Values = append(cm.Values, value)
+		}
+		page.Countermodels = append(page.Countermodels, cm)
+	}
+
+	return htmlTemplate.Execute(w, page)
+}
+
+func htmlSegmentFrom(n *Tnode) *htmlSegment {
+	seg := &htmlSegment{}
+	var last *Tnode
+	for p := n; p != nil; p = p.Left {
+		seg.Formulas = append(seg.Formulas, htmlFormulaFrom(p))
+		last = p
+		if p.Right != nil {
+			break
+		}
+	}
+	if last.Right != nil {
+		seg.Left = htmlSegmentFrom(last.Left)
+		seg.Right = htmlSegmentFrom(last.Right)
+	} else {
+		seg.Closed = last.closed
+		seg.Open = !last.closed && !last.unexplored
+		seg.Unexplored = last.unexplored
+	}
+	return seg
+}
+
+func htmlFormulaFrom(n *Tnode) htmlFormula {
+	f := htmlFormula{
+		LineNumber:  n.LineNumber,
+		Text:        fmt.Sprintf("%d. %s", n.LineNumber, n.signedExpression()),
+		PremiseLine: -1,
+		Contradicts: -1,
+	}
+	if p := n.inferredFrom; p != nil {
+		f.PremiseLine = p.LineNumber
+		f.Note = fmt.Sprintf("(%d, %s)", p.LineNumber, ruleName(p))
+		f.Premise = fmt.Sprintf("From %d. %s, %s rule", p.LineNumber, p.signedExpression(), ruleName(p))
+	}
+	if n.closed {
+		f.Contradicts = n.Contradictory.LineNumber
+	}
+	return f
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.branches { display: flex; gap: 2em; align-items: flex-start; }
+.branches > div { border-left: 1px solid #999; padding-left: 0.5em; }
+summary { cursor: pointer; color: #666; font-size: smaller; }
+.formula { font-family: monospace; white-space: pre; padding: 1px 3px; display: block; }
+.note { color: #666; }
+.closed { color: firebrick; font-weight: bold; }
+.open { color: darkgreen; font-weight: bold; }
+.unexplored { color: gray; }
+.contradiction { background: #fcc; }
+.premise { background: #ffd; outline: 1px dotted #999; }
+table { border-collapse: collapse; margin-top: 1em; }
+td, th { border: 1px solid #999; padding: 2px 8px; text-align: center; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Expressions}}<p>{{.}}</p>
+{{end}}
+<div class="tree">
+{{template "segment" .Root}}
+</div>
+<p><strong>{{.Verdict}}</strong></p>
+{{if .Countermodels}}
+<h2>Countermodels from open branches</h2>
+<table>
+<tr><th>Leaf</th>{{range .Identifiers}}<th>{{.}}</th>{{end}}</tr>
+{{range .Countermodels}}<tr><td>{{.Leaf}}</td>{{range .Values}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+<p>A "-" means the identifier can have either truth value.</p>
+{{end}}
+<script>
+(function() {
+	function line(n) { return document.getElementById("line" + n); }
+	function mark(el, on) {
+		var other;
+		if (el.dataset.contradicts) {
+			other = line(el.dataset.contradicts);
+			el.classList.toggle("contradiction", on);
+			if (other) { other.classList.toggle("contradiction", on); }
+		}
+		if (el.dataset.premise) {
+			other = line(el.dataset.premise);
+			if (other) { other.classList.toggle("premise", on); }
+		}
+	}
+	var formulas = document.querySelectorAll(".formula");
+	for (var i = 0; i < formulas.length; i++) {
+		formulas[i].addEventListener("mouseover", function() { mark(this, true); });
+		formulas[i].addEventListener("mouseout", function() { mark(this, false); });
+	}
+})();
+</script>
+</body>
+</html>
+{{define "segment"}}{{range .Formulas}}<span class="formula" id="line{{.LineNumber}}"{{if ge .PremiseLine 0}} data-premise="{{.PremiseLine}}" title="{{.Premise}}"{{end}}{{if ge .Contradicts 0}} data-contradicts="{{.Contradicts}}"{{end}}>{{.Text}}{{if .Note}} <span class="note">{{.Note}}</span>{{end}}{{if ge .Contradicts 0}} <span class="closed">✗ contradicts {{.Contradicts}}</span>{{end}}</span>
+{{end}}{{if .Open}}<span class="formula open">○ open branch</span>
+{{end}}{{if .Unexplored}}<span class="formula unexplored">… unexplored branch</span>
+{{end}}{{if .Left}}<details open>
+<summary>branches</summary>
+<div class="branches">
+<div>{{template "segment" .Left}}</div>
+<div>{{template "segment" .Right}}</div>
+</div>
+</details>
+{{end}}{{end}}`))
@@ -0,0 +1,76 @@
+package tableaux
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"tableaux-in-go/src/truthtable"
+)
+
+func TestWriteHTML(t *testing.T) {
+	tests := []struct {
+		formula  string
+		opts     Options
+		contains []string
+		absent   []string
+	}{
+		{
+			"(p | q) > (p & q)",
+			Options{},
+			[]string{
+				"<title>a &lt; b</title>",
+				`<span class="formula" id="line2" data-premise="0" title="From 0. F: (p | q) &gt; (p &amp; q), α rule">2. F: p &amp; q`,
+				`data-contradicts="3">5. F: p`,
+				"<tr><th>Leaf</th><th>p</th><th>q</th></tr>",
+				"<tr><td>6</td><td>T</td><td>F</td></tr>",
+				"<tr><td>7</td><td>F</td><td>T</td></tr>",
+			},
+			nil,
+		},
+		{
+			// Closed tableau, no countermodels
+			"p | ~p",
+			Options{},
+			[]string{`data-contradicts="1"`},
+			[]string{"Countermodels", "open branch"},
+		},
+		{
+			"p | ~p",
+			Options{Logic: truthtable.StrongKleene},
+			[]string{"2. {U,F}: ~p", "<tr><td>3</td><td>U</td></tr>"},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		root, _ := Setup(parseAll(t, []string{test.formula}), test.opts)
+		root.Expand()
+		var buf bytes.Buffer
+		err := WriteHTML(&buf, root, HTMLReport{
+			Title:       "a < b",
+			Expressions: []string{"Tautology? " + test.formula},
+			Verdict:     "verdict",
+		})
+		if err != nil {
+			t.Fatalf("%q: %v", test.formula, err)
+		}
+		page := buf.String()
+		if !strings.HasPrefix(page, "<!DOCTYPE html>") || !strings.Contains(page, "</html>") {
+			t.Errorf("%q: not a complete HTML document", test.formula)
+		}
+		// Self-contained: nothing to fetch
+		if strings.Contains(page, " src=") || strings.Contains(page, "<link") {
+			t.Errorf("%q: HTML refers to other files", test.formula)
+		}
+		for _, s := range test.contains {
+			if !strings.Contains(page, s) {
+				t.Errorf("%q, %+v: no %q in HTML", test.formula, test.opts, s)
+			}
+		}
+		for _, s := range test.absent {
+			if strings.Contains(page, s) {
+				t.Errorf("%q, %+v: %q in HTML", test.formula, test.opts, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package tableaux
+
+// Craig interpolants from closed logical consequence tableaux. If
+// hypotheses A entail consequence B, some formula I, the interpolant,
+// has only identifiers that appear in both A and B, with A entailing I
+// and I entailing B.
+//
+// Every formula in the tableau comes from a hypothesis, A's side, or
+// the consequence, B's side. Working up from the leaves, each part of
+// the tableau gets an interpolant between its branches' A formulas and
+// B formulas:
+//
+//	A formula contradicts a B formula: the A formula, ~ it if signed F
+//	Two A formulas contradict each other: ⊥, A is contradictory
+//	Two B formulas contradict each other: ⊤, B is valid
+//	An A formula's β rule: the branches' interpolants, disjoined
+//	A B formula's β rule: the branches' interpolants, conjoined
+//
+// α rules don't change anything, the interpolant below is the one above.
+// A formula on both sides has only identifiers from both, so that works
+// out to the right vocabulary.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/truthtable"
+)
+
+// Interpolant gives back a Craig interpolant between the hypotheses and
+// consequence of the closed, signed, propositional logical consequence
+// tableau rooted at root. consequence is the consequence's Tnode, the
+// one Setup gives back.
+func (root *Tnode) Interpolant(consequence *Tnode) (*node.Node, error) {
+	if consequence == nil {
+		return nil, errors.New("an interpolant needs hypotheses and a consequence")
+	}
+	opts := root.tableau.opts
+	if opts.Unsigned || opts.Modal != NonModal || opts.Logic != truthtable.Classical || opts.QBF {
+		return nil, errors.New("interpolants come from signed tableaux of classical logic")
+	}
+	for n := root; n != nil && n.inferredFrom == nil; n = n.Left {
+		if n.Tree.FirstOrder() {
+			return nil, fmt.Errorf("interpolants are for propositional logic, not %q", n.Expression)
+		}
+	}
+	return root.interpolant(consequence)
+}
+
+func (n *Tnode) interpolant(consequence *Tnode) (*node.Node, error) {
+	switch {
+	case n.Left != nil && n.Right != nil:
+		left, err := n.Left.interpolant(consequence)
+		if err != nil {
+			return nil, err
+		}
+		right, err := n.Right.interpolant(consequence)
+		if err != nil {
+			return nil, err
+		}
+		// Both branches come from the same β formula.
+		if n.Left.inferredFrom.consequenceSide(consequence) {
+			return connect(lexer.AND, left, right), nil
+		}
+		return connect(lexer.OR, left, right), nil
+	case n.Left != nil:
+		return n.Left.interpolant(consequence)
+	case !n.closed:
+		return nil, fmt.Errorf("open branch at line %d, the consequence doesn't follow", n.LineNumber)
+	}
+
+	p := n.Contradictory
+	nSide, pSide := n.consequenceSide(consequence), p.consequenceSide(consequence)
+	switch {
+	case !nSide && !pSide:
+		return node.Truth(false), nil
+	case nSide && pSide:
+		return node.Truth(true), nil
+	case nSide:
+		n = p // The hypotheses' side of the contradiction
+	}
+	if n.Sign {
+		return n.Tree, nil
+	}
+	not := node.NewOpNode(lexer.NOT)
+	not.Left = n.Tree
+	return not, nil
+}
+
+// consequenceSide returns true if n comes from the consequence,
+// false if it comes from a hypothesis.
+func (n *Tnode) consequenceSide(consequence *Tnode) bool {
+	for n.inferredFrom != nil {
+		n = n.inferredFrom
+	}
+	return n == consequence
+}
+
+// connect gives back left op right, op & or |, leaving out the
+// truth constants it can, and either one if both are the same.
+func connect(op lexer.TokenType, left, right *node.Node) *node.Node {
+	identity := op == lexer.AND // ⊤ & X is X, ⊥ | X is X
+	for _, pair := range [][2]*node.Node{{left, right}, {right, left}} {
+		if value, ok := pair[0].Constant(); ok {
+			if value == identity {
+				return pair[1]
+			}
+			return pair[0]
+		}
+	}
+	if node.ExpressionToString(left) == node.ExpressionToString(right) {
+		return left
+	}
+	n := node.NewOpNode(op)
+	n.Left, n.Right = left, right
+	return n
+}
+
+// CheckInterpolant makes sure that interpolant only has identifiers
+// that appear in both hypotheses and consequence, that the hypotheses
+// entail it, and that it entails the consequence, proving both
+// entailments with tableaux.
+func CheckInterpolant(hypotheses []*node.Node, consequence, interpolant *node.Node) error {
+	shared := make(map[string]bool)
+	for _, tree := range hypotheses {
+		node.FreeIdentifiers(tree, shared)
+	}
+	inConsequence := make(map[string]bool)
+	node.FreeIdentifiers(consequence, inConsequence)
+	var strays []string
+	for _, id := range truthtable.Identifiers(interpolant) {
+		if !shared[id] || !inConsequence[id] {
+			strays = append(strays, id)
+		}
+	}
+	if len(strays) > 0 {
+		return fmt.Errorf("interpolant has %s, not in both hypotheses and consequence", strings.Join(strays, ", "))
+	}
+
+	hypothesis := hypotheses[0]
+	for _, tree := range hypotheses[1:] {
+		and := node.NewOpNode(lexer.AND)
+		and.Left, and.Right = hypothesis, tree
+		hypothesis = and
+	}
+	if !entails(hypothesis, interpolant) {
+		return errors.New("hypotheses don't entail interpolant")
+	}
+	if !entails(interpolant, consequence) {
+		return errors.New("interpolant doesn't entail consequence")
+	}
+	return nil
+}
+
+// entails returns true if a tableau proves x > y. Proving the
+// implication, rather than y from hypothesis x, checks every formula
+// for contradictions, so that T: ⊥ closes a branch.
+func entails(x, y *node.Node) bool {
+	implies := node.NewOpNode(lexer.IMPLIES)
+	implies.Left, implies.Right = x, y
+	root, _ := Setup([]*node.Node{implies}, Options{})
+	return root.Expand()
+}
@@ -0,0 +1,73 @@
+package tableaux
+
+import (
+	"testing"
+
+	"tableaux-in-go/src/node"
+)
+
+// Hypotheses and a consequence that follows from them, and the
+// interpolant their tableau gives.
+var interpolantTests = []struct {
+	formulas    []string
+	interpolant string
+}{
+	{[]string{"p & q", "q | r"}, "q"},
+	{[]string{"p > q", "q > r", "p > r"}, "~p | r"},
+	{[]string{"(p > q) & (q > r) & p", "r | s"}, "r"},
+	{[]string{"~(p | q)", "~p & (r > r)"}, "~p"},
+	{[]string{"p & (q = r)", "q > r"}, "r | ~q"},
+	{[]string{"p", "q", "p & q"}, "p & q"},
+	{[]string{"p & ~p", "q"}, "⊥"},
+	{[]string{"p", "q | ~q"}, "⊤"},
+}
+
+func TestInterpolant(t *testing.T) {
+	for _, test := range interpolantTests {
+		trees := parseAll(t, test.formulas)
+		root, consequence := Setup(trees, Options{})
+		if !root.Expand() {
+			t.Fatalf("%q: consequence doesn't follow", test.formulas)
+		}
+		interpolant, err := root.Interpolant(consequence)
+		if err != nil {
+			t.Errorf("%q: %v", test.formulas, err)
+			continue
+		}
+		if s := node.ExpressionToString(interpolant); s != test.interpolant {
+			t.Errorf("%q: interpolant %q, want %q", test.formulas, s, test.interpolant)
+		}
+		if err := CheckInterpolant(trees[:len(trees)-1], trees[len(trees)-1], interpolant); err != nil {
+			t.Errorf("%q: %v", test.formulas, err)
+		}
+	}
+}
+
+// Formulas that aren't interpolants for p & q entailing q | r.
+func TestCheckInterpolant(t *testing.T) {
+	trees := parseAll(t, []string{"p & q", "q | r", "p", "r", "~q"})
+	for _, interpolant := range trees[2:] {
+		if err := CheckInterpolant(trees[:1], trees[1], interpolant); err == nil {
+			t.Errorf("%q checks out", node.ExpressionToString(interpolant))
+		}
+	}
+}
+
+// Only signed, classical, propositional tableaux give interpolants.
+func TestInterpolantErrors(t *testing.T) {
+	for _, test := range []struct {
+		formulas []string
+		opts     Options
+	}{
+		{[]string{"p & q", "q | r"}, Options{Unsigned: true}},
+		{[]string{"[]p", "[]p | q"}, Options{Modal: SystemK}},
+		{[]string{"forall x. P(x)", "P(c)"}, Options{}},
+		{[]string{"p > p"}, Options{}},
+	} {
+		root, consequence := Setup(parseAll(t, test.formulas), test.opts)
+		root.Expand()
+		if _, err := root.Interpolant(consequence); err == nil {
+			t.Errorf("%q, %+v: interpolant", test.formulas, test.opts)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package tableaux
+
+// JSON representation of a tableau, for programs that want a finished
+// tableau without linking to this package.
+
+// JSONTnode mirrors a Tnode, with line numbers standing in for pointers
+// to other Tnodes. A bifurcation has two children, a formula in the
+// middle of a branch has one, a leaf has none. Formulas of an unsigned
+// tableau all have sign true. Formulas of a modal tableau have a World.
+// Formulas of a many-signed tableau have Values, with sign true if
+// those values are all designated.
+type JSONTnode struct {
+	Line        int          `json:"line"`
+	Sign        bool         `json:"sign"`
+	Formula     string       `json:"formula"`
+	World       string       `json:"world,omitempty"`
+	Values      string       `json:"values,omitempty"`
+	Premise     *int         `json:"premise,omitempty"`
+	Rule        string       `json:"rule,omitempty"`
+	Contradicts *int         `json:"contradicts,omitempty"`
+	Closed      bool         `json:"closed,omitempty"`
+	Open        bool         `json:"open,omitempty"`
+	Unexplored  bool         `json:"unexplored,omitempty"`
+	Children    []*JSONTnode `json:"children,omitempty"`
+}
+
+// JSON creates a JSONTnode tree for the tableau rooted at n, suitable
+// for handing to encoding/json.
+func (n *Tnode) JSON() *JSONTnode {
+	j := &JSONTnode{
+		Line:       n.LineNumber,
+		Sign:       n.Sign || n.Unsigned(),
+		Formula:    n.Expression,
+		World:      n.World,
+		Closed:     n.closed,
+		Open:       n.Left == nil && n.Right == nil && !n.closed && !n.unexplored,
+		Unexplored: n.unexplored,
+	}
+	if n.Values != 0 {
+		j.Values = n.Values.String()
+	}
+	if n.inferredFrom != nil {
+		premise := n.inferredFrom.LineNumber
+		j.Premise = &premise
+		j.Rule = ruleName(n.inferredFrom)
+	}
+	if n.Contradictory != nil {
+		contradicts := n.Contradictory.LineNumber
+		j.Contradicts = &contradicts
+	}
+	if n.Left != nil {
+		j.Children = append(j.Children, n.Left.JSON())
+	}
+	if n.Right != nil {
+		j.Children = append(j.Children, n.Right.JSON())
+	}
+	return j
+}
+
+// JSONProof holds a problem, the finished tableau for it, and whether
+// every branch of that tableau closed. A single formula, or hypotheses
+// and a consequence, make up a problem.
+type JSONProof struct {
+	Formula     string     `json:"formula,omitempty"`
+	Hypotheses  []string   `json:"hypotheses,omitempty"`
+	Consequence string     `json:"consequence,omitempty"`
+	Unsigned    bool       `json:"unsigned,omitempty"`
+	Modal       string     `json:"modal,omitempty"` // K, T, S4 or S5
+	Logic       string     `json:"logic,omitempty"` // K3, B3, L3 or LP
+	Closed      bool       `json:"closed"`
+	Tableau     *JSONTnode `json:"tableau"`
+}
@@ -0,0 +1,656 @@
+package tableaux
+
+// Linear temporal logic, decided with a graph tableau, after Wolper, and
+// Lichtenstein and Pnueli. A state is a set of signed formulas that one
+// instant of a model could make true: every α and β rule applied, one
+// alternative of each β rule, no formula signed both T and F. What a
+// state says about the instant after it is in its X formulas: T: X p
+// says p holds next. The states the formulas to decide expand to are the
+// initial states, and a state's successors are the states that the
+// operands of its X formulas expand to. Every formula of every state
+// comes from the formulas to decide, so the graph is finite.
+//
+// F, G, U and R work one instant at a time, too: F p is p now, or X F p,
+// G p is p now and X G p, and so on. Any infinite path through the graph
+// from an initial state describes a model, except that for T: F p, the
+// path has to get around to p at some point, rather than putting it off
+// forever. T: F X, F: G X, T: X U Y and F: X R Y are eventualities, which
+// their first alternative fulfills. Formulas are satisfiable if an
+// initial state reaches a strongly connected part of the graph with an
+// edge in it, where every eventuality of any of its states gets fulfilled
+// in one of them. Going around that part forever makes an ultimately
+// periodic model, a lasso: a prefix of instants, then a loop.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+)
+
+// temporalRules are the rules for linear temporal logic's operators,
+// other than X, whose operand holds in the next state, not this one.
+var temporalRules = map[ruleKey]Rule{
+	{lexer.EVENTUALLY, true}:  {Beta, [][]Component{{{LeftOperand, true}}, {{Postponed, true}}}},
+	{lexer.EVENTUALLY, false}: {Alpha, [][]Component{{{LeftOperand, false}, {Postponed, false}}}},
+	{lexer.ALWAYS, true}:      {Alpha, [][]Component{{{LeftOperand, true}, {Postponed, true}}}},
+	{lexer.ALWAYS, false}:     {Beta, [][]Component{{{LeftOperand, false}}, {{Postponed, false}}}},
+
+	// X U Y: Y now, or X now and X U Y next.
+	{lexer.UNTIL, true}: {Beta, [][]Component{
+		{{RightOperand, true}},
+		{{LeftOperand, true}, {Postponed, true}},
+	}},
+	{lexer.UNTIL, false}: {Beta, [][]Component{
+		{{LeftOperand, false}, {RightOperand, false}},
+		{{RightOperand, false}, {Postponed, false}},
+	}},
+
+	// X R Y: Y now, and X now or X R Y next.
+	{lexer.RELEASE, true}: {Beta, [][]Component{
+		{{LeftOperand, true}, {RightOperand, true}},
+		{{RightOperand, true}, {Postponed, true}},
+	}},
+	{lexer.RELEASE, false}: {Beta, [][]Component{
+		{{RightOperand, false}},
+		{{LeftOperand, false}, {Postponed, false}},
+	}},
+}
+
+// ltlFormula is a signed formula of a state of an LTL tableau.
+type ltlFormula struct {
+	sign bool
+	tree *node.Node
+	text string // "T: p U q", for finding the same formula
+}
+
+func newLTLFormula(sign bool, tree *node.Node) ltlFormula {
+	return ltlFormula{sign: sign, tree: tree, text: signedText(sign, node.ExpressionToString(tree))}
+}
+
+// signedText gives back "T: p" or "F: p" for text p.
+func signedText(sign bool, text string) string {
+	if sign {
+		return "T: " + text
+	}
+	return "F: " + text
+}
+
+// rule finds the rule for f, giving back false for identifiers
+// and X formulas.
+func (f ltlFormula) rule() (Rule, bool) {
+	if rule, ok := temporalRules[ruleKey{f.tree.Op, f.sign}]; ok {
+		return rule, true
+	}
+	if lexer.Temporal(f.tree.Op) {
+		return Rule{}, false
+	}
+	return LookupRule(f.tree.Op, f.sign)
+}
+
+// eventuality returns true if some state has to fulfill f, by having
+// its rule's first alternative, sooner or later.
+func (f ltlFormula) eventuality() bool {
+	switch f.tree.Op {
+	case lexer.EVENTUALLY, lexer.UNTIL:
+		return f.sign
+	case lexer.ALWAYS, lexer.RELEASE:
+		return !f.sign
+	}
+	return false
+}
+
+// LTLState is a state of an LTL tableau, a set of signed formulas
+// that an instant of a model could make true.
+type LTLState struct {
+	Number  int         // Counting from 1, initial states first
+	Initial bool        // The formulas to decide expand to it
+	Closed  bool        // On no path that describes a model
+	Next    []*LTLState // States the instant after could be in
+	// Signed formulas, in the order rules subjoined them.
+	formulas []ltlFormula
+	next     string // Key of the formulas that Next expand
+}
+
+// has returns true if s has the signed formula with text.
+func (s *LTLState) has(text string) bool {
+	for _, f := range s.formulas {
+		if f.text == text {
+			return true
+		}
+	}
+	return false
+}
+
+// fulfills returns true if s has every component of eventuality f's
+// first alternative, which makes f true without putting it off.
+func (s *LTLState) fulfills(f ltlFormula) bool {
+	rule, _ := f.rule()
+	for _, c := range rule.Components[0] {
+		if !s.has(newLTLFormula(c.Sign, c.of(f.tree)).text) {
+			return false
+		}
+	}
+	return true
+}
+
+// Valuation gives back the truth values the signed identifiers of s
+// assign them. Identifiers absent from s can have either value.
+func (s *LTLState) Valuation() map[string]bool {
+	valuation := make(map[string]bool)
+	for _, f := range s.formulas {
+		if f.tree.Op == lexer.IDENT {
+			valuation[f.text[3:]] = f.sign
+		}
+	}
+	return valuation
+}
+
+// LTLTableau is a graph tableau deciding whether linear temporal logic
+// formulas are satisfiable together.
+type LTLTableau struct {
+	Formulas []*node.Node // Formulas to decide
+	States   []*LTLState
+
+	states   map[string]*LTLState   // States by the key of their formulas
+	expanded map[string][]*LTLState // States formulas expand to, by the formulas' key
+	todo     []ltlWork              // Formulas to expand
+	start    string                 // Key of the formulas to decide
+	model    *Lasso
+}
+
+// ltlWork is formulas waiting for expansion into states.
+type ltlWork struct {
+	key      string
+	formulas []ltlFormula
+}
+
+// SetupLTL creates the LTL tableau for formulas, all signed T,
+// ready for ExpandContext.
+func SetupLTL(trees []*node.Node) *LTLTableau {
+	t := &LTLTableau{
+		Formulas: trees,
+		states:   make(map[string]*LTLState),
+		expanded: make(map[string][]*LTLState),
+	}
+	var formulas []ltlFormula
+	for _, tree := range trees {
+		formulas = append(formulas, newLTLFormula(true, tree))
+	}
+	t.start = t.queue(formulas)
+	return t
+}
+
+// key gives back a string that any list of the same signed
+// formulas has, in whatever order.
+func key(formulas []ltlFormula) string {
+	var texts []string
+	for _, f := range formulas {
+		texts = append(texts, f.text)
+	}
+	sort.Strings(texts)
+	return strings.Join(texts, "\n")
+}
+
+// queue puts formulas up for expansion, unless the same
+// formulas have been already.
+func (t *LTLTableau) queue(formulas []ltlFormula) string {
+	k := key(formulas)
+	if _, ok := t.expanded[k]; !ok {
+		t.expanded[k] = nil
+		t.todo = append(t.todo, ltlWork{k, formulas})
+	}
+	return k
+}
+
+// ExpandContext builds the graph, then looks for a model in it. Open
+// means the formulas are satisfiable, Closed means they aren't. Like
+// Tnode's ExpandContext, it stops with Unknown and a *LimitError if the
+// graph gets more than limits.MaxNodes states, or ctx gets cancelled
+// or times out.
+func (t *LTLTableau) ExpandContext(ctx context.Context, limits Limits) (Result, error) {
+	for len(t.todo) > 0 {
+		if limits.MaxNodes > 0 && len(t.States) > limits.MaxNodes {
+			return Unknown, &LimitError{Reason: fmt.Sprintf("max nodes %d", limits.MaxNodes), Size: t.Size()}
+		}
+		if err := ctx.Err(); err != nil {
+			return Unknown, &LimitError{Reason: err.Error(), Size: t.Size(), err: err}
+		}
+		work := t.todo[0]
+		t.todo = t.todo[1:]
+		t.expanded[work.key] = t.expand(work.formulas)
+	}
+	for _, s := range t.States {
+		s.Next = t.expanded[s.next]
+	}
+	for _, s := range t.expanded[t.start] {
+		s.Initial = true
+	}
+
+	t.model = t.findModel()
+	if t.model == nil {
+		return Closed, nil
+	}
+	return Open, nil
+}
+
+// Size gives back how big t is: Nodes counts states,
+// Branches counts edges between them.
+func (t *LTLTableau) Size() Size {
+	size := Size{Nodes: len(t.States)}
+	for _, s := range t.States {
+		size.Branches += len(t.expanded[s.next])
+	}
+	return size
+}
+
+// expand gives back the states that formulas expand to.
+func (t *LTLTableau) expand(formulas []ltlFormula) []*LTLState {
+	var found []*LTLState
+	var branch []ltlFormula
+	for _, f := range formulas {
+		var closed bool
+		if branch, closed = subjoinLTL(branch, []ltlFormula{f}); closed {
+			return nil
+		}
+	}
+	t.branch(branch, 0, &found)
+	return found
+}
+
+// branch applies rules to formulas, starting with the one at index i,
+// adding the states that each branch ends in to found, unless found
+// has them already.
+func (t *LTLTableau) branch(formulas []ltlFormula, i int, found *[]*LTLState) {
+	for ; i < len(formulas); i++ {
+		from := formulas[i]
+		rule, ok := from.rule()
+		if !ok {
+			continue
+		}
+		if len(rule.Components) == 1 {
+			var closed bool
+			if formulas, closed = subjoinLTL(formulas, components(from, rule.Components[0])); closed {
+				return
+			}
+			continue
+		}
+		for _, alternative := range rule.Components {
+			if branch, closed := subjoinLTL(formulas, components(from, alternative)); !closed {
+				t.branch(branch, i+1, found)
+			}
+		}
+		return
+	}
+	s := t.state(formulas)
+	for _, other := range *found {
+		if other == s {
+			return
+		}
+	}
+	*found = append(*found, s)
+}
+
+// components gives back the signed formulas of one alternative
+// of from's rule.
+func components(from ltlFormula, alternative []Component) []ltlFormula {
+	var formulas []ltlFormula
+	for _, c := range alternative {
+		formulas = append(formulas, newLTLFormula(c.Sign, c.of(from.tree)))
+	}
+	return formulas
+}
+
+// subjoinLTL gives back a copy of formulas with the ones in add that
+// formulas doesn't have already, and true if some formula of add is
+// the complement of one in formulas, closing the branch.
+func subjoinLTL(formulas, add []ltlFormula) ([]ltlFormula, bool) {
+	branch := append([]ltlFormula(nil), formulas...)
+outer:
+	for _, f := range add {
+		complement := signedText(!f.sign, f.text[3:])
+		for _, g := range branch {
+			switch g.text {
+			case f.text:
+				continue outer
+			case complement:
+				return nil, true
+			}
+		}
+		branch = append(branch, f)
+	}
+	return branch, false
+}
+
+// state gives back the state with formulas, creating it, and putting
+// the operands of its X formulas up for expansion, if it's new.
+func (t *LTLTableau) state(formulas []ltlFormula) *LTLState {
+	k := key(formulas)
+	if s, ok := t.states[k]; ok {
+		return s
+	}
+	s := &LTLState{Number: len(t.States) + 1, formulas: formulas}
+	t.states[k] = s
+	t.States = append(t.States, s)
+
+	var next []ltlFormula
+	for _, f := range formulas {
+		if f.tree.Op == lexer.NEXT {
+			next = append(next, newLTLFormula(f.sign, f.tree.Left))
+		}
+	}
+	s.next = t.queue(next)
+	return s
+}
+
+// findModel marks the states on no path that describes a model closed,
+// and gives back a lasso for some path from an initial state that does,
+// or nil if none does.
+func (t *LTLTableau) findModel() *Lasso {
+	// Fulfilling parts of the graph. A part with a state that has an
+	// eventuality no state of the part fulfills can't have it in a
+	// fulfilling part: the rest of it might still have one.
+	var fulfilling [][]*LTLState
+	candidates := [][]*LTLState{t.States}
+	for len(candidates) > 0 {
+		states := candidates[len(candidates)-1]
+		candidates = candidates[:len(candidates)-1]
+		for _, part := range stronglyConnected(states) {
+			if len(part) == 1 && !edge(part[0], part[0]) {
+				continue
+			}
+			if rest := fulfilled(part); len(rest) < len(part) {
+				candidates = append(candidates, rest)
+			} else {
+				fulfilling = append(fulfilling, part)
+			}
+		}
+	}
+
+	partOf := make(map[*LTLState][]*LTLState)
+	for _, part := range fulfilling {
+		for _, s := range part {
+			partOf[s] = part
+		}
+	}
+	// Open states reach a fulfilling part.
+	open := make(map[*LTLState]bool)
+	for s := range partOf {
+		open[s] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, s := range t.States {
+			for _, next := range s.Next {
+				if !open[s] && open[next] {
+					open[s] = true
+					changed = true
+				}
+			}
+		}
+	}
+	var initial []*LTLState
+	for _, s := range t.States {
+		s.Closed = !open[s]
+		if s.Initial && open[s] {
+			initial = append(initial, s)
+		}
+	}
+	if len(initial) == 0 {
+		return nil
+	}
+
+	// Shortest path to a fulfilling part, then around it.
+	var prefix []*LTLState
+	entry := initial[0]
+	if partOf[entry] == nil {
+		path := shortestPath(initial, func(s *LTLState) bool { return partOf[s] != nil }, nil)
+		prefix, entry = path[:len(path)-1], path[len(path)-1]
+	}
+	part := partOf[entry]
+	within := make(map[*LTLState]bool)
+	for _, s := range part {
+		within[s] = true
+	}
+	loop := []*LTLState{entry}
+	for _, s := range part {
+		for _, f := range s.formulas {
+			if !f.eventuality() || fulfillsOnLoop(loop, f) {
+				continue
+			}
+			found := func(s *LTLState) bool { return s.fulfills(f) }
+			if current := loop[len(loop)-1]; !found(current) {
+				path := shortestPath(current.Next, found, within)
+				loop = append(loop, path...)
+			}
+		}
+	}
+	back := shortestPath(loop[len(loop)-1].Next, func(s *LTLState) bool { return s == entry }, within)
+	loop = append(loop, back[:len(back)-1]...)
+
+	lasso := &Lasso{}
+	for _, s := range prefix {
+		lasso.Prefix = append(lasso.Prefix, s.Valuation())
+	}
+	for _, s := range loop {
+		lasso.Loop = append(lasso.Loop, s.Valuation())
+	}
+	return lasso
+}
+
+// fulfillsOnLoop returns true if some state of loop fulfills f.
+func fulfillsOnLoop(loop []*LTLState, f ltlFormula) bool {
+	for _, s := range loop {
+		if s.fulfills(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// fulfilled gives back the states of part whose eventualities
+// some state of part fulfills.
+func fulfilled(part []*LTLState) []*LTLState {
+	var rest []*LTLState
+	for _, s := range part {
+		ok := true
+		for _, f := range s.formulas {
+			if f.eventuality() && !fulfillsOnLoop(part, f) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			rest = append(rest, s)
+		}
+	}
+	return rest
+}
+
+// edge returns true if to is a successor of from.
+func edge(from, to *LTLState) bool {
+	for _, next := range from.Next {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// stronglyConnected gives back the strongly connected components of
+// the graph of states, counting only edges between states, with
+// Tarjan's algorithm.
+func stronglyConnected(states []*LTLState) [][]*LTLState {
+	among := make(map[*LTLState]bool)
+	for _, s := range states {
+		among[s] = true
+	}
+	index := make(map[*LTLState]int)
+	low := make(map[*LTLState]int)
+	onStack := make(map[*LTLState]bool)
+	var stack []*LTLState
+	var parts [][]*LTLState
+
+	var visit func(s *LTLState)
+	visit = func(s *LTLState) {
+		index[s] = len(index)
+		low[s] = index[s]
+		stack = append(stack, s)
+		onStack[s] = true
+		for _, next := range s.Next {
+			if !among[next] {
+				continue
+			}
+			if _, seen := index[next]; !seen {
+				visit(next)
+				low[s] = min(low[s], low[next])
+			} else if onStack[next] {
+				low[s] = min(low[s], index[next])
+			}
+		}
+		if low[s] == index[s] {
+			var part []*LTLState
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				part = append(part, top)
+				if top == s {
+					break
+				}
+			}
+			parts = append(parts, part)
+		}
+	}
+	for _, s := range states {
+		if _, seen := index[s]; !seen {
+			visit(s)
+		}
+	}
+	return parts
+}
+
+// shortestPath gives back the states of a shortest path from one of
+// starts to a state that found returns true for, starts included,
+// going only through states within, if within isn't nil.
+func shortestPath(starts []*LTLState, found func(*LTLState) bool, within map[*LTLState]bool) []*LTLState {
+	from := make(map[*LTLState]*LTLState)
+	var queue []*LTLState
+	for _, s := range starts {
+		if _, seen := from[s]; !seen && (within == nil || within[s]) {
+			from[s] = nil
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if found(s) {
+			var path []*LTLState
+			for p := s; p != nil; p = from[p] {
+				path = append([]*LTLState{p}, path...)
+			}
+			return path
+		}
+		for _, next := range s.Next {
+			if _, seen := from[next]; !seen && (within == nil || within[next]) {
+				from[next] = s
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// Model gives back a model of the formulas of a tableau
+// that ExpandContext found Open, nil otherwise.
+func (t *LTLTableau) Model() *Lasso {
+	return t.model
+}
+
+// Print writes the states of t on w, each with its formulas,
+// then whether it's initial or closed, and its successors.
+func (t *LTLTableau) Print(w io.Writer) {
+	for _, s := range t.States {
+		var texts []string
+		for _, f := range s.formulas {
+			texts = append(texts, f.text)
+		}
+		if len(texts) == 0 {
+			texts = append(texts, "no formulas, anything goes")
+		}
+		fmt.Fprintf(w, "%d. %s\n", s.Number, strings.Join(texts, ", "))
+
+		var notes []string
+		if s.Initial {
+			notes = append(notes, "initial")
+		}
+		if s.Closed {
+			notes = append(notes, "closed")
+		}
+		next := "next"
+		for _, n := range s.Next {
+			next += fmt.Sprintf(" %d", n.Number)
+		}
+		fmt.Fprintf(w, "   %s\n", strings.Join(append(notes, next), ", "))
+	}
+}
+
+// Graph writes t on w in GraphViz dot format, a node per state labeled
+// with its formulas, an edge per successor. Initial states have a double
+// border, closed ones a dashed border.
+func (t *LTLTableau) Graph(w io.Writer) {
+	fmt.Fprintf(w, "digraph ltl {\n")
+	fmt.Fprintf(w, "node [shape=box];\n")
+	for _, s := range t.States {
+		label := fmt.Sprintf("%d.", s.Number)
+		for _, f := range s.formulas {
+			label += "\\n" + f.text
+		}
+		var attributes string
+		if s.Initial {
+			attributes += " peripheries=2"
+		}
+		if s.Closed {
+			attributes += " style=dashed"
+		}
+		fmt.Fprintf(w, "s%d [label=\"%s\"%s];\n", s.Number, label, attributes)
+	}
+	for _, s := range t.States {
+		for _, next := range s.Next {
+			fmt.Fprintf(w, "s%d -> s%d;\n", s.Number, next.Number)
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+// Lasso is an ultimately periodic model of linear temporal logic
+// formulas: values of identifiers at each instant of Prefix, then at
+// each instant of Loop, over and over. Identifiers absent from an
+// instant can have either value then.
+type Lasso struct {
+	Prefix []map[string]bool `json:"prefix"`
+	Loop   []map[string]bool `json:"loop"`
+}
+
+// Print writes l on w in a human readable form,
+// a line per instant, counting from 0.
+func (l *Lasso) Print(w io.Writer) {
+	last := len(l.Prefix) + len(l.Loop) - 1
+	again := fmt.Sprintf("%d to %d", len(l.Prefix), last)
+	if len(l.Loop) == 1 {
+		again = fmt.Sprint(last)
+	}
+	fmt.Fprintf(w, "Model, instants 0 to %d, then %s over and over:\n", last, again)
+	for i, valuation := range append(append([]map[string]bool(nil), l.Prefix...), l.Loop...) {
+		values := literals(valuation)
+		if values == "" {
+			values = "anything"
+		}
+		fmt.Fprintf(w, "\t%d: %s\n", i, values)
+	}
+}
@@ -0,0 +1,137 @@
+package tableaux
+
+import (
+	"context"
+	"testing"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+)
+
+// LTL formulas, decided together, and whether they're satisfiable.
+var ltlTests = []struct {
+	formulas    []string
+	satisfiable bool
+}{
+	{[]string{"p U q"}, true},
+	{[]string{"p R q"}, true},
+	{[]string{"G F p"}, true},
+	{[]string{"G (p > X ~p) & G (~p > X p) & p"}, true},
+	{[]string{"F p & G ~q"}, true},
+	{[]string{"G p", "F q"}, true},
+	{[]string{"G p & F ~p"}, false},
+	{[]string{"~(p U q) & q"}, false},
+	{[]string{"F p", "G ~p"}, false},
+	{[]string{"X p & X ~p"}, false},
+	{[]string{"G (p > X p)", "p", "F ~p"}, false},
+}
+
+func TestLTL(t *testing.T) {
+	for _, test := range ltlTests {
+		trees := parseAll(t, test.formulas)
+		tableau := SetupLTL(trees)
+		result, err := tableau.ExpandContext(context.Background(), Limits{})
+		if err != nil {
+			t.Fatalf("%q: %v", test.formulas, err)
+		}
+		if satisfiable := result == Open; satisfiable != test.satisfiable {
+			t.Errorf("%q: satisfiable %v, want %v", test.formulas, satisfiable, test.satisfiable)
+			continue
+		}
+		model := tableau.Model()
+		if (model != nil) != test.satisfiable {
+			t.Errorf("%q: %s, with model %v", test.formulas, result, model)
+			continue
+		}
+		for _, tree := range trees {
+			if model != nil && !model.holds(tree, 0) {
+				t.Errorf("%q: model %v doesn't satisfy %q", test.formulas, model, node.ExpressionToString(tree))
+			}
+		}
+	}
+}
+
+// holds returns true if tree is true at instant i of l.
+func (l *Lasso) holds(tree *node.Node, i int) bool {
+	switch tree.Op {
+	case lexer.IDENT:
+		return l.at(i)[tree.Ident]
+	case lexer.NOT:
+		return !l.holds(tree.Left, i)
+	case lexer.AND:
+		return l.holds(tree.Left, i) && l.holds(tree.Right, i)
+	case lexer.OR:
+		return l.holds(tree.Left, i) || l.holds(tree.Right, i)
+	case lexer.IMPLIES:
+		return !l.holds(tree.Left, i) || l.holds(tree.Right, i)
+	case lexer.EQUIV:
+		return l.holds(tree.Left, i) == l.holds(tree.Right, i)
+	case lexer.NEXT:
+		return l.holds(tree.Left, l.next(i))
+	case lexer.EVENTUALLY:
+		for _, j := range l.future(i) {
+			if l.holds(tree.Left, j) {
+				return true
+			}
+		}
+		return false
+	case lexer.ALWAYS:
+		for _, j := range l.future(i) {
+			if !l.holds(tree.Left, j) {
+				return false
+			}
+		}
+		return true
+	case lexer.UNTIL:
+		for _, j := range l.future(i) {
+			if l.holds(tree.Right, j) {
+				return true
+			}
+			if !l.holds(tree.Left, j) {
+				return false
+			}
+		}
+		return false
+	case lexer.RELEASE:
+		for _, j := range l.future(i) {
+			if !l.holds(tree.Right, j) {
+				return false
+			}
+			if l.holds(tree.Left, j) {
+				return true
+			}
+		}
+		return true
+	}
+	panic("not an LTL formula")
+}
+
+func (l *Lasso) at(i int) map[string]bool {
+	if i < len(l.Prefix) {
+		return l.Prefix[i]
+	}
+	return l.Loop[i-len(l.Prefix)]
+}
+
+// next gives back the instant after i, going round the loop.
+func (l *Lasso) next(i int) int {
+	if i+1 == len(l.Prefix)+len(l.Loop) {
+		return len(l.Prefix)
+	}
+	return i + 1
+}
+
+// future gives back the instants from i on, in order, each once:
+// the rest of the prefix and all of the loop, or the loop from i round.
+func (l *Lasso) future(i int) []int {
+	count := len(l.Loop)
+	if i < len(l.Prefix) {
+		count += len(l.Prefix) - i
+	}
+	var instants []int
+	for j := 0; j < count; j++ {
+		instants = append(instants, i)
+		i = l.next(i)
+	}
+	return instants
+}
@@ -0,0 +1,243 @@
+package tableaux
+
+// Many-signed tableaux, for three-valued logics. Signs are sets of truth
+// values, after Hähnle: {T,U}: X says X has value T or U. A formula to
+// prove starts out signed with the values that aren't designated, a
+// hypothesis with the ones that are. A branch closes once some formula's
+// signs on the branch have no value in common.
+//
+// Rather than a table of rules, the rules come from the logic's truth
+// tables. For X & Y signed S, and any other binary connective, every
+// value of X that some value of Y makes X & Y take a value in S gets
+// grouped with the other values of X that go with the same values of Y.
+// Each group makes a branch: X signed the group, Y signed the values
+// that go with it. Grouping on Y's values instead can make fewer
+// branches, and a rule uses whichever makes fewer. Three branches
+// don't fit below a single Tnode, so the second and third branches
+// hang below their shared operand, signed with the values of both.
+
+import (
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/truthtable"
+)
+
+// valued is a component of a rule of a many-signed tableau:
+// an operand, signed values.
+type valued struct {
+	operand Operand
+	values  truthtable.Set
+}
+
+// valuedRule gives back the alternative lists of components for a
+// formula of logic l with connective op, signed values: one list for
+// an α rule, two or three for a β rule, none if the sign has every
+// value, so that there's nothing to infer.
+func valuedRule(l truthtable.Logic, op lexer.TokenType, values truthtable.Set) [][]valued {
+	if values == truthtable.All {
+		return nil
+	}
+	if op == lexer.NOT {
+		var operand truthtable.Set
+		for _, a := range truthtable.Values {
+			if values.Has(l.Apply(op, a, truthtable.False)) {
+				operand |= truthtable.SetOf(a)
+			}
+		}
+		return [][]valued{{{LeftOperand, operand}}}
+	}
+
+	byLeft := groups(func(a, b truthtable.Value) bool { return values.Has(l.Apply(op, a, b)) })
+	byRight := groups(func(b, a truthtable.Value) bool { return values.Has(l.Apply(op, a, b)) })
+	first, second := LeftOperand, RightOperand
+	if len(byRight) < len(byLeft) {
+		byLeft = byRight
+		first, second = RightOperand, LeftOperand
+	}
+
+	var alternatives [][]valued
+	for _, g := range byLeft {
+		var components []valued
+		if g[0] != truthtable.All {
+			components = append(components, valued{first, g[0]})
+		}
+		if g[1] != truthtable.All {
+			components = append(components, valued{second, g[1]})
+		}
+		alternatives = append(alternatives, components)
+	}
+	return alternatives
+}
+
+// groups gives back pairs of sets of values: values of one operand,
+// and the values of the other operand that go with each of them,
+// according to holds. Values of the first operand that nothing goes
+// with don't show up.
+func groups(holds func(a, b truthtable.Value) bool) [][2]truthtable.Set {
+	var pairs [][2]truthtable.Set
+	for _, a := range truthtable.Values {
+		var with truthtable.Set
+		for _, b := range truthtable.Values {
+			if holds(a, b) {
+				with |= truthtable.SetOf(b)
+			}
+		}
+		if with == 0 {
+			continue
+		}
+		found := false
+		for i := range pairs {
+			if pairs[i][1] == with {
+				pairs[i][0] |= truthtable.SetOf(a)
+				found = true
+			}
+		}
+		if !found {
+			pairs = append(pairs, [2]truthtable.Set{truthtable.SetOf(a), with})
+		}
+	}
+	return pairs
+}
+
+// valuedRule gives back the alternatives for n's formula, if n is
+// part of a many-signed tableau.
+func (n *Tnode) valuedRule() [][]valued {
+	return valuedRule(n.tableau.opts.Logic, n.Tree.Op, n.Values)
+}
+
+// valuedRuleType gives back α for a rule with a single alternative, β otherwise.
+func valuedRuleType(alternatives [][]valued) RuleType {
+	if len(alternatives) > 1 {
+		return Beta
+	}
+	return Alpha
+}
+
+// manyValued subjoins the inferences of from to leaf node parent of a
+// many-signed tableau. A regular tableau subjoins nothing if the branch
+// already has every component of some alternative, and leaves out
+// components of α rules that the branch already has.
+func (parent *Tnode) manyValued(from *Tnode) {
+	alternatives := from.valuedRule()
+	if len(alternatives) == 0 {
+		return
+	}
+	regular := parent.tableau.opts.Regular
+	if regular {
+		for _, components := range alternatives {
+			if parent.hasValued(from, components) {
+				parent.tableau.saved += len(components)
+				return
+			}
+		}
+	}
+	parent.tableau.counts.expanded(from.Tree.Op, Rule{Type: valuedRuleType(alternatives)})
+	parent.split(from, alternatives, regular && len(alternatives) == 1)
+}
+
+// split subjoins alternatives below n: the first to the left, the
+// second and third to the right, below a formula that either implies.
+func (n *Tnode) split(from *Tnode, alternatives [][]valued, regular bool) {
+	switch len(alternatives) {
+	case 1:
+		n.Left = n.subjoinValued(from, alternatives[0], regular)
+		return
+	case 3:
+		n.Left = n.subjoinValued(from, alternatives[0], false)
+		both := valued{alternatives[1][0].operand, alternatives[1][0].values | alternatives[2][0].values}
+		n.Right = n.inferValued(from, both)
+		n.tableau.branches++
+		if !n.Right.CheckForContradictions() {
+			n.Right.split(from, alternatives[1:], false)
+		}
+		return
+	}
+	n.Left = n.subjoinValued(from, alternatives[0], false)
+	n.Right = n.subjoinValued(from, alternatives[1], false)
+	n.tableau.branches++
+}
+
+// subjoinValued subjoins components one below the other, below n,
+// stopping once one closes the branch, giving back the first one.
+// Regular leaves out components the branch already has, although not
+// all of them.
+func (n *Tnode) subjoinValued(from *Tnode, components []valued, regular bool) *Tnode {
+	var first *Tnode
+	p := n
+	for _, c := range components {
+		if regular && p.hasValued(from, []valued{c}) {
+			n.tableau.saved++
+			continue
+		}
+		immediate := p.inferValued(from, c)
+		if first == nil {
+			first = immediate
+		} else {
+			p.Left = immediate
+		}
+		p = immediate
+		if immediate.CheckForContradictions() {
+			break
+		}
+	}
+	return first
+}
+
+// inferValued creates an inference of premise from, component c,
+// as a child of n. The caller makes it n's Left or Right.
+func (n *Tnode) inferValued(from *Tnode, c valued) *Tnode {
+	operand := Component{Operand: c.operand}.of(from.Tree)
+	immediate := n.infer(from, operand, c.values&^n.tableau.opts.Logic.Designated() == 0, from.World)
+	immediate.Values = c.values
+	return immediate
+}
+
+// hasValued returns true if the branch ending at n already has all
+// of components: if the signs of each component's operand on the
+// branch have no values in common outside the component's sign.
+func (n *Tnode) hasValued(from *Tnode, components []valued) bool {
+	for _, c := range components {
+		expression := node.ExpressionToString(Component{Operand: c.operand}.of(from.Tree))
+		if n.values(expression)&^c.values != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// values gives back the values that expression can have on the
+// branch ending at n, according to the signs it has there.
+func (n *Tnode) values(expression string) truthtable.Set {
+	values := truthtable.All
+	for p := n; p != nil; p = p.Parent {
+		if p.Expression == expression {
+			values &= p.Values
+		}
+	}
+	return values
+}
+
+// ThreeValued gives back values for the identifiers on the branch
+// ending at leaf node n of a many-signed tableau: a value that each
+// identifier's signs have in common, T ahead of U ahead of F.
+// Identifiers absent from the branch don't appear in the map,
+// they can have any value.
+func (n *Tnode) ThreeValued() map[string]truthtable.Value {
+	valuation := make(map[string]truthtable.Value)
+	for p := n; p != nil; p = p.Parent {
+		if p.Tree.Op != lexer.IDENT {
+			continue
+		}
+		if _, ok := valuation[p.Expression]; ok {
+			continue
+		}
+		values := n.values(p.Expression)
+		for _, v := range truthtable.Values {
+			if values.Has(v) {
+				valuation[p.Expression] = v
+				break
+			}
+		}
+	}
+	return valuation
+}
@@ -0,0 +1,40 @@
+package tableaux
+
+import (
+	"testing"
+
+	"tableaux-in-go/src/truthtable"
+)
+
+// Many-signed tableaux decide what three-valued truth tables do, and
+// an open branch gives a valuation where the formula isn't designated.
+func TestManyValued(t *testing.T) {
+	formulas := []string{
+		"p | ~p",
+		"p > p",
+		"~(p & ~p)",
+		"(p & ~p) > q",
+		"p > (q > p)",
+		"((p > q) > p) > p",
+		"~(p & q) = (~p | ~q)",
+		"(p > q) > (~q > ~p)",
+		"p = ~p",
+	}
+	for _, formula := range formulas {
+		for _, l := range []truthtable.Logic{truthtable.StrongKleene, truthtable.WeakKleene, truthtable.Lukasiewicz, truthtable.LP} {
+			trees := parseAll(t, []string{formula})
+			valid := truthtable.NewThreeValued(trees[0], l).Valid()
+			root, _ := Setup(trees, Options{Logic: l})
+			if closed := root.Expand(); closed != valid {
+				t.Errorf("%q in %s: closed %v, truth table says valid %v", formula, l, closed, valid)
+				continue
+			}
+			for _, leaf := range root.FindUnclosedLeaf() {
+				valuation := leaf.ThreeValued()
+				if v := l.Evaluate(trees[0], valuation); l.Designated().Has(v) {
+					t.Errorf("%q in %s: %v gives it designated value %s", formula, l, valuation, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,504 @@
+package tableaux
+
+// Modal tableaux. A prefix names a possible world: 1 is the world the
+// tableau starts out in, and σ.n is the nth world that a branch made up
+// for π formulas at world σ. Which worlds can see which depends only on
+// their prefixes, and on the modal system:
+//
+//	K:  σ sees σ.n
+//	T:  σ also sees σ itself
+//	S4: σ sees every world whose prefix starts with σ
+//	S5: every world sees every world
+//
+// Like γ formulas, ν and π formulas get applied in rounds, once nothing
+// else is left to do. A round gives every ν formula on a branch its
+// operand in every world on the branch that the formula's world sees.
+// If that adds nothing, each π formula gets its operand in a new world,
+// once per branch. In S4 and S5, a branch could go on making up worlds
+// forever, so loop checking holds back π formulas: in S4, at a world
+// whose formulas some ancestor world has all of, in S5, when some world
+// on the branch already has the operand.
+//
+// Intuitionistic logic works the same way, with worlds seeing each other
+// the way they do in S4, and its own rules for ~, > and =. A formula
+// signed T in some world is true in every world that world sees: rather
+// than copying T: formulas into other worlds, CheckForContradictions
+// closes a branch with T: X in some world, F: X in a world it sees.
+// Loop checking counts the T: formulas a world inherits that way, and
+// also holds back a π formula when some world its world sees already
+// has all of its components.
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+)
+
+// System is a modal logic, deciding which worlds see which,
+// or intuitionistic logic.
+type System int
+
+const (
+	NonModal       System = iota // Classical logic, no worlds
+	SystemK                      // No conditions on seeing worlds
+	SystemT                      // Every world sees itself
+	SystemS4                     // And sees what the worlds it sees see
+	SystemS5                     // Every world sees every world
+	Intuitionistic               // Worlds see each other the way they do in S4
+)
+
+func (s System) String() string {
+	switch s {
+	case SystemK:
+		return "K"
+	case SystemT:
+		return "T"
+	case SystemS4:
+		return "S4"
+	case SystemS5:
+		return "S5"
+	case Intuitionistic:
+		return "intuitionistic"
+	}
+	return ""
+}
+
+// MarshalText makes JSON show a system by name.
+func (s System) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSystem gives back the modal system called name: "K", "T", "S4"
+// or "S5", in either case. An empty name means classical logic.
+func ParseSystem(name string) (System, error) {
+	switch strings.ToUpper(name) {
+	case "":
+		return NonModal, nil
+	case "K":
+		return SystemK, nil
+	case "T":
+		return SystemT, nil
+	case "S4":
+		return SystemS4, nil
+	case "S5":
+		return SystemS5, nil
+	}
+	return NonModal, fmt.Errorf("unknown modal system %q, want K, T, S4 or S5", name)
+}
+
+// sees returns true if world sees other in system s.
+func (s System) sees(world, other string) bool {
+	switch s {
+	case SystemK:
+		return parentWorld(other) == world
+	case SystemT:
+		return other == world || parentWorld(other) == world
+	case SystemS4, Intuitionistic:
+		return other == world || strings.HasPrefix(other, world+".")
+	}
+	return true
+}
+
+// contradicts returns true if intuitionistic formulas n and p, one
+// signed T, one F, both X, contradict each other: if T: X's world
+// sees F: X's world.
+func contradicts(n, p *Tnode) bool {
+	if !n.Sign {
+		n, p = p, n
+	}
+	return Intuitionistic.sees(n.World, p.World)
+}
+
+// parentWorld gives back the world that world got made up
+// for, "" for world 1.
+func parentWorld(world string) string {
+	if i := strings.LastIndexByte(world, '.'); i >= 0 {
+		return world[:i]
+	}
+	return ""
+}
+
+// worlds gives back the worlds on the branch ending at leaf,
+// in order of appearance.
+func (leaf *Tnode) worlds() []string {
+	var branch []*Tnode
+	for p := leaf; p != nil; p = p.Parent {
+		branch = append(branch, p)
+	}
+	var worlds []string
+	seen := make(map[string]bool)
+	for i := len(branch) - 1; i >= 0; i-- {
+		if w := branch[i].World; !seen[w] {
+			seen[w] = true
+			worlds = append(worlds, w)
+		}
+	}
+	return worlds
+}
+
+// necessity subjoins the operand of ν formula from to the branch ending
+// at leaf, in every world of the branch that from's world sees, unless
+// the branch already has it, stopping if the branch closes. Necessity
+// gives back the new leaf of the branch, and how many formulas it added.
+// An intuitionistic T: X > Y splits the branch instead, F: X to the left,
+// T: Y to the right, for the first world that has neither. Necessity
+// leaves the right branch for the next round.
+func (leaf *Tnode) necessity(from *Tnode) (*Tnode, int) {
+	rule, _ := leaf.tableau.rule(from.Tree.Op, from.Sign)
+	added := 0
+	for _, world := range leaf.worlds() {
+		if !leaf.tableau.opts.Modal.sees(from.World, world) {
+			continue
+		}
+		alreadyOn := false
+		for _, components := range rule.Components {
+			c := components[0]
+			alreadyOn = alreadyOn || leaf.onBranch(world, c.of(from.Tree), c.Sign)
+		}
+		if alreadyOn {
+			continue
+		}
+		leaf.tableau.counts.expanded(from.Tree.Op, rule)
+		added++
+		c := rule.Components[0][0]
+		immediate := leaf.infer(from, c.of(from.Tree), c.Sign, world)
+		leaf.Left = immediate
+		if len(rule.Components) > 1 {
+			c := rule.Components[1][0]
+			leaf.Right = leaf.infer(from, c.of(from.Tree), c.Sign, world)
+			leaf.tableau.branches++
+			leaf.Right.CheckForContradictions()
+		}
+		leaf = immediate
+		if leaf.CheckForContradictions() || len(rule.Components) > 1 {
+			break
+		}
+	}
+	return leaf, added
+}
+
+// possibility subjoins the operand of π formula from to the branch
+// ending at leaf, in a new world that from's world sees, unless the
+// branch already did, or loop checking holds from back. Possibility
+// gives back the new leaf of the branch, and how many formulas it added.
+// An intuitionistic F: X > Y gets T: X and F: Y in the new world.
+func (leaf *Tnode) possibility(from *Tnode) (*Tnode, int) {
+	for p := leaf; p != nil; p = p.Parent {
+		if p.inferredFrom == from {
+			return leaf, 0
+		}
+	}
+	rule, _ := leaf.tableau.rule(from.Tree.Op, from.Sign)
+	components := rule.Components[0]
+
+	switch leaf.tableau.opts.Modal {
+	case Intuitionistic:
+		for _, world := range leaf.worlds() {
+			if Intuitionistic.sees(from.World, world) && leaf.witnesses(world, from, components) {
+				return leaf, 0
+			}
+		}
+		fallthrough
+	case SystemS4:
+		if leaf.blocker(from.World) != "" {
+			return leaf, 0
+		}
+	case SystemS5:
+		c := components[0]
+		for _, world := range leaf.worlds() {
+			if leaf.onBranch(world, c.of(from.Tree), c.Sign) {
+				return leaf, 0
+			}
+		}
+	}
+
+	children := 0
+	for _, world := range leaf.worlds() {
+		if parentWorld(world) == from.World {
+			children++
+		}
+	}
+	world := fmt.Sprintf("%s.%d", from.World, children+1)
+
+	leaf.tableau.counts.expanded(from.Tree.Op, rule)
+	added := 0
+	for _, c := range components {
+		immediate := leaf.infer(from, c.of(from.Tree), c.Sign, world)
+		leaf.Left = immediate
+		leaf = immediate
+		added++
+		if leaf.CheckForContradictions() {
+			break
+		}
+	}
+	return leaf, added
+}
+
+// witnesses returns true if world already has all the components
+// of π formula from on the branch ending at leaf.
+func (leaf *Tnode) witnesses(world string, from *Tnode, components []Component) bool {
+	for _, c := range components {
+		if !leaf.onBranch(world, c.of(from.Tree), c.Sign) {
+			return false
+		}
+	}
+	return true
+}
+
+// worldFormulas gives back the signed formulas in each world
+// of the branch ending at leaf. In intuitionistic logic, a world
+// has the T: formulas of the worlds that see it, too.
+func (leaf *Tnode) worldFormulas() map[string]map[string]bool {
+	formulas := make(map[string]map[string]bool)
+	worlds := leaf.worlds()
+	for _, world := range worlds {
+		formulas[world] = make(map[string]bool)
+	}
+	intuitionistic := leaf.tableau.opts.Modal == Intuitionistic
+	for p := leaf; p != nil; p = p.Parent {
+		f := fmt.Sprintf("%v %s", p.Sign, p.Expression)
+		formulas[p.World][f] = true
+		if intuitionistic && p.Sign {
+			for _, world := range worlds {
+				if Intuitionistic.sees(p.World, world) {
+					formulas[world][f] = true
+				}
+			}
+		}
+	}
+	return formulas
+}
+
+// blocker finds the nearest ancestor of world that has all of world's
+// formulas on the branch ending at leaf, giving back "" if there's none.
+// An S4 countermodel lets world see the worlds its blocker sees, instead
+// of making up new ones.
+func (leaf *Tnode) blocker(world string) string {
+	formulas := leaf.worldFormulas()
+	for ancestor := parentWorld(world); ancestor != ""; ancestor = parentWorld(ancestor) {
+		all := true
+		for f := range formulas[world] {
+			if !formulas[ancestor][f] {
+				all = false
+				break
+			}
+		}
+		if all {
+			return ancestor
+		}
+	}
+	return ""
+}
+
+// modalRound does a round of ν formulas on every open branch of the
+// tableau rooted at root, or of π formulas on branches where ν formulas
+// add nothing, giving back how many formulas it subjoined.
+func (root *Tnode) modalRound() int {
+	added := 0
+	for _, leaf := range root.FindUnclosedLeaf() {
+		var necessary, possible []*Tnode
+		for p := leaf; p != nil; p = p.Parent {
+			rule, ok := leaf.tableau.rule(p.Tree.Op, p.Sign)
+			switch {
+			case ok && rule.Type == Nu:
+				necessary = append([]*Tnode{p}, necessary...)
+			case ok && rule.Type == Pi:
+				possible = append([]*Tnode{p}, possible...)
+			}
+		}
+
+		n := 0
+		for _, f := range necessary {
+			var k int
+			leaf, k = leaf.necessity(f)
+			n += k
+			if leaf.closed {
+				break
+			}
+		}
+		if n == 0 {
+			for _, f := range possible {
+				var k int
+				leaf, k = leaf.possibility(f)
+				n += k
+				if leaf.closed {
+					break
+				}
+			}
+		}
+		added += n
+	}
+	return added
+}
+
+// Kripke is a Kripke model: possible worlds, which worlds each world
+// sees, and the truth values of identifiers in each world.
+type Kripke struct {
+	System    System                     `json:"system"`
+	Worlds    []string                   `json:"worlds"` // World 1 first
+	Sees      map[string][]string        `json:"sees"`   // Worlds each world sees
+	Valuation map[string]map[string]bool `json:"valuation"`
+}
+
+// Kripke gives back the Kripke model that the open branch ending at leaf
+// describes, a countermodel to the formula at the root of a complete
+// tableau. Identifiers a world has no value for can have either value.
+// An intuitionistic model has just the identifiers true in each world,
+// those signed T in any world that sees it. The rest are false.
+func (leaf *Tnode) Kripke() *Kripke {
+	system := leaf.tableau.opts.Modal
+	k := &Kripke{
+		System:    system,
+		Worlds:    leaf.worlds(),
+		Sees:      make(map[string][]string),
+		Valuation: make(map[string]map[string]bool),
+	}
+	sort.Slice(k.Worlds, func(i, j int) bool { return worldLess(k.Worlds[i], k.Worlds[j]) })
+	for _, world := range k.Worlds {
+		k.Valuation[world] = make(map[string]bool)
+		k.Sees[world] = []string{}
+	}
+	for p := leaf; p != nil; p = p.Parent {
+		if p.Tree.Op == lexer.IDENT {
+			k.Valuation[p.World][node.ExpressionToString(p.Tree)] = p.Sign
+		}
+	}
+
+	for _, world := range k.Worlds {
+		for _, other := range k.Worlds {
+			if system.sees(world, other) {
+				k.Sees[world] = append(k.Sees[world], other)
+			}
+		}
+	}
+	if system == SystemS4 || system == Intuitionistic {
+		// A blocked world sees what its blocker sees,
+		// and everything those worlds see.
+		for _, world := range k.Worlds {
+			if b := leaf.blocker(world); b != "" {
+				k.Sees[world] = append(k.Sees[world], k.Sees[b]...)
+			}
+		}
+		k.close()
+	}
+	if system == Intuitionistic {
+		signedT := k.Valuation
+		k.Valuation = make(map[string]map[string]bool)
+		for _, world := range k.Worlds {
+			k.Valuation[world] = make(map[string]bool)
+		}
+		for _, world := range k.Worlds {
+			for id, value := range signedT[world] {
+				for _, other := range k.Sees[world] {
+					if value {
+						k.Valuation[other][id] = true
+					}
+				}
+			}
+		}
+	}
+	return k
+}
+
+// close makes seeing worlds transitive.
+func (k *Kripke) close() {
+	for changed := true; changed; {
+		changed = false
+		for _, world := range k.Worlds {
+			seen := make(map[string]bool)
+			for _, other := range k.Sees[world] {
+				seen[other] = true
+			}
+			for _, other := range k.Sees[world] {
+				for _, third := range k.Sees[other] {
+					if !seen[third] {
+						seen[third] = true
+						changed = true
+					}
+				}
+			}
+			var sees []string
+			for _, other := range k.Worlds {
+				if seen[other] {
+					sees = append(sees, other)
+				}
+			}
+			k.Sees[world] = sees
+		}
+	}
+}
+
+// worldLess orders worlds by their prefixes, numerically:
+// 1, 1.1, 1.1.1, 1.2, 1.10.
+func worldLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			return x < y
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// valuationString gives back "p, ~q" for the identifiers of a world.
+func (k *Kripke) valuationString(world string) string {
+	return literals(k.Valuation[world])
+}
+
+// literals gives back "p, ~q" for valuation, in order of identifier.
+func literals(valuation map[string]bool) string {
+	var ids []string
+	for id := range valuation {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for i, id := range ids {
+		if !valuation[id] {
+			ids[i] = "~" + id
+		}
+	}
+	return strings.Join(ids, ", ")
+}
+
+// Print writes k on w in a human readable form, a line per world,
+// with the identifiers' values there, and the worlds it sees.
+func (k *Kripke) Print(w io.Writer) {
+	fmt.Fprintf(w, "Kripke countermodel, %s:\n", k.System)
+	for _, world := range k.Worlds {
+		label := "world " + world
+		if values := k.valuationString(world); values != "" {
+			label += ", " + values
+		}
+		sees := "nothing"
+		if len(k.Sees[world]) > 0 {
+			sees = strings.Join(k.Sees[world], " ")
+		}
+		fmt.Fprintf(w, "\t%s: sees %s\n", label, sees)
+	}
+}
+
+// Graph writes k on w in GraphViz dot format, a node per world
+// labeled with its identifiers' values, an edge per world seen.
+func (k *Kripke) Graph(w io.Writer) {
+	fmt.Fprintf(w, "digraph kripke {\n")
+	for i, world := range k.Worlds {
+		fmt.Fprintf(w, "w%d [label=\"%s\\n%s\"];\n", i, world, k.valuationString(world))
+	}
+	index := make(map[string]int)
+	for i, world := range k.Worlds {
+		index[world] = i
+	}
+	for _, world := range k.Worlds {
+		for _, other := range k.Sees[world] {
+			fmt.Fprintf(w, "w%d -> w%d;\n", index[world], index[other])
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+}
@@ -0,0 +1,150 @@
+package tableaux
+
+import (
+	"testing"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+)
+
+// Modal formulas and the weakest of K, T, S4 and S5 they're valid in,
+// NonModal if they're valid in none of them.
+var modalTests = []struct {
+	formula string
+	weakest System
+}{
+	{"[](p > q) > ([]p > []q)", SystemK},
+	{"<>(p | q) = (<>p | <>q)", SystemK},
+	{"□p > p", SystemT},
+	{"[]p > <>p", SystemT},
+	{"[]p > [][]p", SystemS4},
+	{"<>p > []<>p", SystemS5},
+	{"◇p > □◇p", SystemS5},
+	{"p > []p", NonModal},
+	{"[]<>p > <>[]p", NonModal},
+}
+
+func TestModal(t *testing.T) {
+	for _, test := range modalTests {
+		for _, system := range []System{SystemK, SystemT, SystemS4, SystemS5} {
+			trees := parseAll(t, []string{test.formula})
+			root, _ := Setup(trees, Options{Modal: system})
+			valid := test.weakest != NonModal && test.weakest <= system
+			if closed := root.Expand(); closed != valid {
+				t.Errorf("%q in %s: closed %v, want %v", test.formula, system, closed, valid)
+				continue
+			}
+			if valid {
+				continue
+			}
+			k := root.FindUnclosedLeaf()[0].Kripke()
+			if k.holds(trees[0], k.Worlds[0]) {
+				t.Errorf("%q in %s: countermodel %v makes it true", test.formula, system, k)
+			}
+		}
+	}
+}
+
+// Propositional formulas, and whether they're valid in intuitionistic
+// logic. All of them are classical tautologies.
+var intuitionisticTests = []struct {
+	formula string
+	valid   bool
+}{
+	{"~~~p > ~p", true},
+	{"p > ~~p", true},
+	{"~(p & ~p)", true},
+	{"~~(p | ~p)", true},
+	{"~(p | q) = (~p & ~q)", true},
+	{"(p > q) > (~q > ~p)", true},
+	{"p | ~p", false},
+	{"~~p > p", false},
+	{"((p > q) > p) > p", false},
+	{"~(p & q) > (~p | ~q)", false},
+	{"(~q > ~p) > (p > q)", false},
+	{"(p > q) | (q > p)", false},
+}
+
+func TestIntuitionistic(t *testing.T) {
+	for _, test := range intuitionisticTests {
+		trees := parseAll(t, []string{test.formula})
+		root, _ := Setup(trees, Options{Modal: Intuitionistic})
+		if closed := root.Expand(); closed != test.valid {
+			t.Errorf("%q: closed %v, want %v", test.formula, closed, test.valid)
+			continue
+		}
+		if test.valid {
+			continue
+		}
+		k := root.FindUnclosedLeaf()[0].Kripke()
+		if k.forces(trees[0], k.Worlds[0]) {
+			t.Errorf("%q: countermodel %v forces it", test.formula, k)
+		}
+	}
+}
+
+// holds returns true if tree is true in world of k.
+func (k *Kripke) holds(tree *node.Node, world string) bool {
+	switch tree.Op {
+	case lexer.IDENT:
+		return k.Valuation[world][tree.Ident]
+	case lexer.NOT:
+		return !k.holds(tree.Left, world)
+	case lexer.AND:
+		return k.holds(tree.Left, world) && k.holds(tree.Right, world)
+	case lexer.OR:
+		return k.holds(tree.Left, world) || k.holds(tree.Right, world)
+	case lexer.IMPLIES:
+		return !k.holds(tree.Left, world) || k.holds(tree.Right, world)
+	case lexer.EQUIV:
+		return k.holds(tree.Left, world) == k.holds(tree.Right, world)
+	case lexer.BOX:
+		for _, other := range k.Sees[world] {
+			if !k.holds(tree.Left, other) {
+				return false
+			}
+		}
+		return true
+	case lexer.DIAMOND:
+		for _, other := range k.Sees[world] {
+			if k.holds(tree.Left, other) {
+				return true
+			}
+		}
+		return false
+	}
+	panic("not a modal formula")
+}
+
+// forces returns true if world of intuitionistic model k forces tree.
+func (k *Kripke) forces(tree *node.Node, world string) bool {
+	switch tree.Op {
+	case lexer.IDENT:
+		return k.Valuation[world][tree.Ident]
+	case lexer.AND:
+		return k.forces(tree.Left, world) && k.forces(tree.Right, world)
+	case lexer.OR:
+		return k.forces(tree.Left, world) || k.forces(tree.Right, world)
+	}
+	// ~X, X > Y and X = Y hold in every world world sees.
+	for _, other := range k.Sees[world] {
+		left := k.forces(tree.Left, other)
+		switch tree.Op {
+		case lexer.NOT:
+			if left {
+				return false
+			}
+		case lexer.IMPLIES:
+			if left && !k.forces(tree.Right, other) {
+				return false
+			}
+		case lexer.EQUIV:
+			if left != k.forces(tree.Right, other) {
+				return false
+			}
+		default:
+			panic("not a propositional formula")
+		}
+	}
+	return true
+}
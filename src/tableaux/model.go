@@ -0,0 +1,75 @@
+package tableaux
+
+// Countermodels from open branches. A complete tableau with an open
+// branch shows that the formula at its root can have the value its
+// sign gives it: the signed identifiers on that branch constitute a
+// valuation that does it. In first order logic, signed atomic formulas,
+// P(a) and so on, take the place of signed identifiers. A modal
+// tableau's open branch describes a Kripke model, see modal.go.
+
+import (
+	"sort"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+)
+
+// Valuation gives back the truth values that the signed identifiers
+// on the branch ending at leaf node n assign to those identifiers.
+// Identifiers absent from the branch don't appear in the map, they can
+// have either truth value. In a modal tableau, the values are the ones
+// in the world the tableau starts out in.
+func (n *Tnode) Valuation() map[string]bool {
+	valuation := make(map[string]bool)
+	root := n
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	for p := n; p != nil; p = p.Parent {
+		if p.Tree.Op == lexer.IDENT && p.World == root.World {
+			valuation[node.ExpressionToString(p.Tree)] = p.Sign
+		}
+	}
+	return valuation
+}
+
+// Identifiers gives back the sorted, de-duplicated propositional
+// identifiers appearing in any formula of the tableau rooted at n.
+func (n *Tnode) Identifiers() []string {
+	seen := make(map[string]bool)
+	n.identifiers(seen)
+
+	var identifiers []string
+	for id := range seen {
+		identifiers = append(identifiers, id)
+	}
+	sort.Strings(identifiers)
+	return identifiers
+}
+
+func (n *Tnode) identifiers(seen map[string]bool) {
+	treeIdentifiers(n.Tree, seen)
+	if n.Left != nil {
+		n.Left.identifiers(seen)
+	}
+	if n.Right != nil {
+		n.Right.identifiers(seen)
+	}
+}
+
+// treeIdentifiers finds identifiers, and atomic formulas outside
+// of quantifiers, in tree.
+func treeIdentifiers(tree *node.Node, seen map[string]bool) {
+	if lexer.Quantifier(tree.Op) {
+		return
+	}
+	if tree.Op == lexer.IDENT {
+		seen[node.ExpressionToString(tree)] = true
+	}
+	if tree.Left != nil {
+		treeIdentifiers(tree.Left, seen)
+	}
+	if tree.Right != nil {
+		treeIdentifiers(tree.Right, seen)
+	}
+}
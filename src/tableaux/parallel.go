@@ -0,0 +1,92 @@
+package tableaux
+
+// Parallel depth-first search. After a β split, the two branches below
+// it don't have anything to do with each other, so a worker with nothing
+// to do can take the second one while the worker that split the branch
+// carries on with the first. A channel of tokens bounds the number of
+// workers. Everything the workers share goes through shared.mu.
+
+func (sh *shared) newSearcher(opts Options, limits Limits, keep bool) *searcher {
+	s := &searcher{
+		opts:     opts,
+		limits:   limits,
+		keep:     keep,
+		shared:   sh,
+		onBranch: make(map[string]int),
+	}
+	sh.mu.Lock()
+	sh.searchers = append(sh.searchers, s)
+	sh.mu.Unlock()
+	return s
+}
+
+// run has s search until it finds an open branch, every one of its
+// branches closes, or it has to stop, and records what it found out.
+// The first open branch, or the first reason to stop early, stops
+// every other worker.
+func (sh *shared) run(s *searcher, closed bool) {
+	result, err := s.search(closed)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	s.result = result
+	switch {
+	case result == Open && sh.open == nil:
+		sh.open = s
+		sh.cancel()
+	case result == Unknown && sh.open == nil && sh.err == nil:
+		// Not stopped by some other worker's open branch.
+		sh.err = err
+		sh.cancel()
+	}
+}
+
+// handOff gives alternative a to a new worker, if there's a free token
+// for one, giving back false if not.
+func (sh *shared) handOff(s *searcher, a alternative) bool {
+	if sh.workers == nil {
+		return false
+	}
+	select {
+	case sh.workers <- struct{}{}:
+	default:
+		return false
+	}
+
+	w := sh.newSearcher(s.opts, s.limits, s.keep)
+	w.branch = append([]formula(nil), s.branch...)
+	for key, i := range s.onBranch {
+		w.onBranch[key] = i
+	}
+
+	sh.wg.Add(1)
+	go func() {
+		defer sh.wg.Done()
+		defer func() { <-sh.workers }()
+		sh.run(w, w.take(a))
+	}()
+	return true
+}
+
+func (sh *shared) addSaved(n int) {
+	sh.mu.Lock()
+	sh.saved += n
+	sh.mu.Unlock()
+}
+
+// renumber gives the Tnodes of the tableau rooted at root line numbers
+// top to bottom, everything on the left of a split before anything on
+// its right, the way they'd read in the printed tableau.
+func (root *Tnode) renumber() {
+	root.tableau.next = 0
+	var walk func(n *Tnode)
+	walk = func(n *Tnode) {
+		if n == nil {
+			return
+		}
+		n.LineNumber = root.tableau.number()
+		walk(n.Left)
+		walk(n.Right)
+	}
+	walk(root)
+}
@@ -0,0 +1,80 @@
+package tableaux
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// pigeonhole returns the hypotheses and consequence of the pigeonhole
+// principle: n+1 pigeons, each in one of n holes, have a hole with two
+// pigeons in it. Identifier pI_J means pigeon I is in hole J. Every
+// branch of its tableau closes, so workers can't stop early, which
+// makes it a fair test of speed-up.
+func pigeonhole(n int) []string {
+	var formulas, pairs []string
+	for i := 1; i <= n+1; i++ {
+		var holes []string
+		for j := 1; j <= n; j++ {
+			holes = append(holes, fmt.Sprintf("p%d_%d", i, j))
+		}
+		formulas = append(formulas, strings.Join(holes, " | "))
+	}
+	for j := 1; j <= n; j++ {
+		for i := 1; i <= n+1; i++ {
+			for k := i + 1; k <= n+1; k++ {
+				pairs = append(pairs, fmt.Sprintf("(p%d_%d & p%d_%d)", i, j, k, j))
+			}
+		}
+	}
+	return append(formulas, strings.Join(pairs, " | "))
+}
+
+// Parallel search decides the same as Expand, whatever the number of
+// workers.
+func TestParallel(t *testing.T) {
+	for _, workers := range []int{1, 2, 4} {
+		for _, opts := range []Options{{}, {Unsigned: true}} {
+			for _, test := range consequenceTests {
+				search, err := Parallel(context.Background(), parseAll(t, test.formulas), opts, Limits{}, false, workers)
+				if err != nil {
+					t.Fatalf("%q: %v", test.formulas, err)
+				}
+				if closed := search.Result == Closed; closed != test.closed {
+					t.Errorf("%q, %+v, %d workers: closed %v, want %v", test.formulas, opts, workers, closed, test.closed)
+				}
+			}
+		}
+	}
+}
+
+// go test -bench Parallel ./src/tableaux
+func BenchmarkParallel(b *testing.B) {
+	workerCounts := []int{1, 2, 4}
+	if n := runtime.NumCPU(); n > 4 {
+		workerCounts = append(workerCounts, n)
+	}
+	for n := 2; n <= 3; n++ {
+		trees := parseAll(b, pigeonhole(n))
+		root, _ := Setup(trees, Options{})
+		want := Open
+		if root.Expand() {
+			want = Closed
+		}
+		for _, workers := range workerCounts {
+			b.Run(fmt.Sprintf("pigeonhole%d/workers%d", n, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					search, err := Parallel(context.Background(), trees, Options{}, Limits{}, false, workers)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if search.Result != want {
+						b.Fatalf("%s, want %s as Expand has it", search.Result, want)
+					}
+				}
+			})
+		}
+	}
+}
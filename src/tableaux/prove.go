@@ -0,0 +1,232 @@
+package tableaux
+
+// The proof procedure, as described in README.md, separate from
+// any command line or other user interface.
+
+import (
+	"context"
+	"fmt"
+
+	"tableaux-in-go/src/node"
+)
+
+// Setup creates the initial tableau for a list of parse trees. A single
+// formula gets signed F, and its own inferences get subjoined to it.
+// More than one formula gets treated as hypotheses signed T, and a final
+// formula signed F, whose logical consequence from the hypotheses the
+// tableau will decide. Setup gives back the root of the tableau, and the
+// Tnode of that final formula, nil for a single formula.
+func Setup(trees []*node.Node, opts Options) (root *Tnode, finalFormula *Tnode) {
+	root, finalFormula = Start(trees, opts)
+	if finalFormula == nil && !root.Used {
+		// Single expression. Subjoin its own inferences.
+		root.AddInferences(root)
+		root.Used = true
+	}
+	return root, finalFormula
+}
+
+// Start creates the initial tableau for a list of parse trees the way
+// Setup does, but without subjoining any inferences.
+func Start(trees []*node.Node, opts Options) (root *Tnode, finalFormula *Tnode) {
+	if len(trees) == 1 {
+		return Root(trees[0], false, opts), nil
+	}
+
+	// More than 1 PL formula, put them together for deciding
+	// logical consequence - all signed T except that last one F.
+	// In an unsigned tableau, where ~p is F: p, any of them can
+	// contradict a formula above it outright. Like AddInferences, don't
+	// bother subjoining more once the branch closes, so the final
+	// formula is a hypothesis if the hypotheses contradict each other.
+	// The tableau keeps the rest for sequent calculus derivations.
+	root = Root(trees[0], true, opts)
+	leaf := root
+	for i, tree := range trees[1:] {
+		sign := i < len(trees)-2
+		if leaf.closed {
+			tree, sign = root.tableau.normalize(tree, sign)
+			root.tableau.skipped = append(root.tableau.skipped, &Tnode{Tree: tree, Sign: sign})
+			continue
+		}
+		leaf.Left = New(tree, sign, leaf)
+		leaf = leaf.Left
+		leaf.CheckForContradictions()
+	}
+	return root, leaf
+}
+
+// Expand repeatedly subjoins inferences of unused formulas to the
+// tableau rooted at root until every branch closes, or no unused formulas
+// remain. Expand returns true if every branch of the tableau closed.
+func (root *Tnode) Expand() bool {
+	result, _ := root.ExpandContext(context.Background(), Limits{})
+	return result == Closed
+}
+
+// Result is the outcome of a proof search.
+type Result int
+
+const (
+	Open    Result = iota // Complete tableau with an open branch
+	Closed                // Every branch closed
+	Unknown               // Search stopped before finding out
+)
+
+func (r Result) String() string {
+	switch r {
+	case Open:
+		return "open"
+	case Closed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// DefaultMaxInstances is how many instances of any one γ formula a
+// branch gets before proof search gives up, unless somebody says.
+const DefaultMaxInstances = 4
+
+// Limits bound how big a tableau can grow. Zero means no limit.
+type Limits struct {
+	MaxNodes    int // Formulas in the whole tableau
+	MaxBranches int // Branches, open or closed
+	MaxDepth    int // Formulas on any one branch
+
+	MaxInstances int // Instances of any one γ formula on a branch
+}
+
+// Size describes how big a tableau is.
+type Size struct {
+	Nodes    int `json:"nodes"`
+	Branches int `json:"branches"`
+	Depth    int `json:"depth"` // Formulas on the longest branch
+}
+
+// Size gives back the size of the tableau n belongs to.
+func (n *Tnode) Size() Size {
+	return Size{Nodes: n.tableau.next, Branches: n.tableau.branches, Depth: n.tableau.maxDepth}
+}
+
+// LimitError says why a proof search stopped early,
+// and how big the tableau had grown by then.
+type LimitError struct {
+	Reason string // "max nodes 1000", "context deadline exceeded", etc
+	Size   Size   // Tableau when the search stopped
+	err    error  // Context's error, if that's why
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("limit exceeded: %s, at %d nodes, %d branches, depth %d",
+		e.Reason, e.Size.Nodes, e.Size.Branches, e.Size.Depth)
+}
+
+// Unwrap gives back the context's error, if a cancelled
+// or timed out context stopped the search.
+func (e *LimitError) Unwrap() error {
+	return e.err
+}
+
+// exceeded gives back the first of limits that the tableau
+// rooted at root has gone over, or "".
+func (root *Tnode) exceeded(limits Limits) string {
+	size := root.Size()
+	switch {
+	case limits.MaxNodes > 0 && size.Nodes > limits.MaxNodes:
+		return fmt.Sprintf("max nodes %d", limits.MaxNodes)
+	case limits.MaxBranches > 0 && size.Branches > limits.MaxBranches:
+		return fmt.Sprintf("max branches %d", limits.MaxBranches)
+	case limits.MaxDepth > 0 && size.Depth > limits.MaxDepth:
+		return fmt.Sprintf("max depth %d", limits.MaxDepth)
+	}
+	return ""
+}
+
+// ExpandContext expands the tableau rooted at root the way Expand does,
+// but stops with result Unknown and a *LimitError if the tableau grows
+// past limits, or ctx gets cancelled or times out. The tableau stays as
+// it was when the search stopped.
+func (root *Tnode) ExpandContext(ctx context.Context, limits Limits) (Result, error) {
+	for {
+		if len(root.FindUnclosedLeaf()) == 0 {
+			return Closed, nil
+		}
+		unusedFormula := root.NextUnused()
+		if unusedFormula == nil && !root.tableau.rounds {
+			return Open, nil
+		}
+		if reason := root.exceeded(limits); reason != "" {
+			return Unknown, &LimitError{Reason: reason, Size: root.Size()}
+		}
+		if err := ctx.Err(); err != nil {
+			return Unknown, &LimitError{Reason: err.Error(), Size: root.Size(), err: err}
+		}
+		if unusedFormula == nil {
+			// Nothing left but γ, ν and π formulas.
+			added, blocked := root.instantiateAll(limits.MaxInstances)
+			if added == 0 {
+				added = root.modalRound()
+			}
+			if added > 0 {
+				continue
+			}
+			if blocked {
+				reason := fmt.Sprintf("max instances %d", limits.MaxInstances)
+				return Unknown, &LimitError{Reason: reason, Size: root.Size()}
+			}
+			return Open, nil
+		}
+		unusedFormula.Subjoin()
+	}
+}
+
+// NextUnused finds the formula whose inferences the proof procedure
+// would subjoin next: the unused formula highest in the branch of the
+// first unclosed leaf that has an unused formula at all.
+func (root *Tnode) NextUnused() *Tnode {
+	for _, leaf := range root.FindUnclosedLeaf() {
+		if unusedFormula := leaf.FindTallestUnused(); unusedFormula != nil {
+			return unusedFormula
+		}
+	}
+	return nil
+}
+
+// Expansion records what a call to Subjoin changed in a tableau,
+// so that Undo can change it back.
+type Expansion struct {
+	Formula *Tnode   // Formula whose inferences got subjoined
+	Leaves  []*Tnode // Leaf nodes that got inferences subjoined
+	Closed  []*Tnode // New leaf nodes that closed their branches
+
+	before tableau // Line numbers, counts, etc before subjoining
+}
+
+// Subjoin adds the inferences of the receiver formula to every unclosed
+// leaf node below it in the tableau, and marks the receiver used.
+func (n *Tnode) Subjoin() *Expansion {
+	x := &Expansion{Formula: n, before: *n.tableau}
+	for _, leafNode := range n.FindUnclosedLeaf() {
+		leafNode.AddInferences(n)
+		x.Leaves = append(x.Leaves, leafNode)
+		for _, leaf := range leafNode.FindLeaves() {
+			if leaf.closed {
+				x.Closed = append(x.Closed, leaf)
+			}
+		}
+	}
+	n.Used = true
+	return x
+}
+
+// Undo removes the inferences a call to Subjoin added to a tableau, and
+// marks the formula unused again. Expansions have to get undone in the
+// reverse of the order they got made.
+func (x *Expansion) Undo() {
+	for _, leaf := range x.Leaves {
+		leaf.Left = nil
+		leaf.Right = nil
+	}
+	x.Formula.Used = false
+	*x.Formula.tableau = x.before
+}
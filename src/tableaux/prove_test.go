@@ -0,0 +1,153 @@
+package tableaux
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/parser"
+)
+
+// parseAll parses formulas for a test, failing it if one doesn't parse.
+func parseAll(t testing.TB, formulas []string) []*node.Node {
+	t.Helper()
+	var trees []*node.Node
+	for _, formula := range formulas {
+		tree, err := parser.ParseString(formula)
+		if err != nil {
+			t.Fatalf("%q: %v", formula, err)
+		}
+		trees = append(trees, tree)
+	}
+	return trees
+}
+
+// Problems for Setup and Expand: a single formula, or hypotheses and a
+// consequence, and whether every branch of the tableau should close.
+var consequenceTests = []struct {
+	formulas []string
+	closed   bool
+}{
+	{[]string{"p > p"}, true},
+	{[]string{"p > q"}, false},
+	{[]string{"~(p & q) = (~p | ~q)"}, true},
+	{[]string{"p > q", "q > r", "p > r"}, true},
+	{[]string{"p > q", "q", "p"}, false},
+	{[]string{"p", "~p", "q"}, true},
+	{[]string{"~p", "p", "q"}, true},
+	{[]string{"p", "q", "~p", "r"}, true},
+	{[]string{"p", "q", "r"}, false},
+	{[]string{"p", "q", "p"}, true},
+}
+
+func TestExpand(t *testing.T) {
+	for _, unsigned := range []bool{false, true} {
+		for _, test := range consequenceTests {
+			root, _ := Setup(parseAll(t, test.formulas), Options{Unsigned: unsigned})
+			if closed := root.Expand(); closed != test.closed {
+				t.Errorf("%q, unsigned %v: closed %v, want %v", test.formulas, unsigned, closed, test.closed)
+			}
+		}
+	}
+}
+
+// Hypotheses that contradict each other outright, in an unsigned
+// tableau, close the branch before the consequence gets subjoined.
+func TestStartContradictoryHypotheses(t *testing.T) {
+	root, final := Start(parseAll(t, []string{"p", "~p", "q"}), Options{Unsigned: true})
+	if final == nil || !final.closed || final.Contradictory != root {
+		t.Fatalf("line 1 should close the branch, contradicting line 0")
+	}
+	if len(root.FindUnclosedLeaf()) != 0 {
+		t.Errorf("open branch after contradictory hypotheses")
+	}
+}
+
+// A regular tableau decides the same as any other, without an inferred
+// formula that's already on its branch.
+func TestRegular(t *testing.T) {
+	tests := append(consequenceTests, struct {
+		formulas []string
+		closed   bool
+	}{[]string{"((p>q)>p)>p", "(p|q)>(q|p)", "p|q"}, false})
+	for _, unsigned := range []bool{false, true} {
+		for _, test := range tests {
+			root, _ := Setup(parseAll(t, test.formulas), Options{Unsigned: unsigned, Regular: true})
+			if closed := root.Expand(); closed != test.closed {
+				t.Errorf("%q, unsigned %v: closed %v, want %v", test.formulas, unsigned, closed, test.closed)
+			}
+			if n := repeated(root); n != nil {
+				t.Errorf("%q, unsigned %v: line %d repeats a formula on its branch", test.formulas, unsigned, n.LineNumber)
+			}
+		}
+	}
+
+	root, _ := Setup(parseAll(t, tests[len(tests)-1].formulas), Options{Regular: true})
+	root.Expand()
+	if root.Saved() == 0 {
+		t.Errorf("regular tableau subjoined every formula")
+	}
+}
+
+// repeated returns the first inferred formula in the tableau below n
+// that's already on its branch, nil if there isn't one.
+func repeated(n *Tnode) *Tnode {
+	if n == nil {
+		return nil
+	}
+	if n.inferredFrom != nil {
+		for p := n.Parent; p != nil; p = p.Parent {
+			if p.Sign == n.Sign && p.formula == n.formula {
+				return n
+			}
+		}
+	}
+	if r := repeated(n.Left); r != nil {
+		return r
+	}
+	return repeated(n.Right)
+}
+
+// A proof search that goes over a limit stops with Unknown, saying
+// which limit, whether it builds the whole tableau or searches
+// depth-first.
+func TestLimits(t *testing.T) {
+	formulas := []string{"(p > q) > ((q > r) > (p > r))"}
+	limitTests := []struct {
+		limits Limits
+		reason string
+	}{
+		{Limits{MaxNodes: 5}, "max nodes 5"},
+		{Limits{MaxBranches: 2}, "max branches 2"},
+		{Limits{MaxDepth: 3}, "max depth 3"},
+		{Limits{MaxNodes: 1000, MaxBranches: 100, MaxDepth: 100}, ""},
+	}
+	for _, test := range limitTests {
+		root, _ := Setup(parseAll(t, formulas), Options{})
+		result, err := root.ExpandContext(context.Background(), test.limits)
+		search, dfsErr := DepthFirst(context.Background(), parseAll(t, formulas), Options{}, test.limits, false)
+		if test.reason == "" {
+			if result != Closed || err != nil || search.Result != Closed || dfsErr != nil {
+				t.Errorf("%+v: %s, %v, depth-first %s, %v, want closed", test.limits, result, err, search.Result, dfsErr)
+			}
+			continue
+		}
+		for _, err := range []error{err, dfsErr} {
+			limitErr, ok := err.(*LimitError)
+			if !ok || limitErr.Reason != test.reason {
+				t.Errorf("%+v: error %v, want %s", test.limits, err, test.reason)
+			}
+		}
+		if result != Unknown || search.Result != Unknown {
+			t.Errorf("%+v: %s, depth-first %s, want unknown", test.limits, result, search.Result)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	root, _ := Setup(parseAll(t, formulas), Options{})
+	if result, err := root.ExpandContext(ctx, Limits{}); result != Unknown || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: %s, %v", result, err)
+	}
+}
@@ -0,0 +1,168 @@
+package tableaux
+
+// Pruning a finished tableau. Subjoining inferences to every open leaf
+// below a formula puts plenty of formulas in a tableau that no closure
+// ever needs. Walking back from each closed leaf, through the formula it
+// contradicts and the premises of both, finds the formulas that do matter.
+
+// Prune removes rule applications from the tableau rooted at root that
+// no closed branch needs. An application that some closed branch needs
+// keeps all its components. A β split where one branch closes without
+// needing its component goes away, that branch taking the split's place.
+// Formulas on an open branch all stay, as do the formulas the tableau
+// started with. Prune numbers the remaining lines top to bottom, left
+// branch before right.
+func (root *Tnode) Prune() {
+	used := root.prune()
+	root.completeApplications(used)
+
+	var splice func(n *Tnode)
+	splice = func(n *Tnode) {
+		// A formula can go if whatever comes below it can
+		// take its place, without making a 3-way split.
+		for c := n.Left; c != nil && removable(c, used) && (c.Right == nil || n.Right == nil); c = n.Left {
+			n.Left = c.Left
+			if c.Right != nil {
+				n.Right = c.Right
+			}
+			n.adopt()
+		}
+		for c := n.Right; c != nil && removable(c, used) && c.Right == nil; c = n.Right {
+			n.Right = c.Left
+			n.adopt()
+		}
+		if n.Left != nil {
+			splice(n.Left)
+		}
+		if n.Right != nil {
+			splice(n.Right)
+		}
+	}
+	splice(root)
+
+	root.tableau.maxDepth = 0
+	root.tableau.branches = 0
+	var measure func(n *Tnode)
+	measure = func(n *Tnode) {
+		n.setDepth()
+		if n.Left == nil && n.Right == nil {
+			root.tableau.branches++
+		}
+		if n.Left != nil {
+			measure(n.Left)
+		}
+		if n.Right != nil {
+			measure(n.Right)
+		}
+	}
+	measure(root)
+	root.renumber()
+}
+
+// completeApplications adds the other components of an application
+// of an α, β or π rule to used, if used has one of them. Instances of
+// γ formulas, and ν formulas in different worlds, are separate
+// applications.
+func (root *Tnode) completeApplications(used map[*Tnode]bool) {
+	var complete func(n *Tnode)
+	complete = func(n *Tnode) {
+		if used[n] && n.inferredFrom != nil && !n.inferredFrom.separateApplications() {
+			premise := n.inferredFrom
+			for p := n.Parent; p != nil && p.inferredFrom == premise; p = p.Parent {
+				used[p] = true
+			}
+			for c := n.Left; c != nil && c.inferredFrom == premise && n.Right == nil; c = c.Left {
+				used[c] = true
+				n = c
+			}
+		}
+		if n.Left != nil {
+			complete(n.Left)
+		}
+		if n.Right != nil {
+			complete(n.Right)
+		}
+	}
+	complete(root)
+}
+
+// separateApplications returns true if every formula inferred from
+// premise n is an application of its rule by itself.
+func (n *Tnode) separateApplications() bool {
+	rule, _ := n.tableau.rule(n.Tree.Op, n.Sign)
+	return rule.Type == Gamma || rule.Type == Nu
+}
+
+// removable returns true if nothing needs formula n, and it's not
+// one of the formulas the tableau started with.
+func removable(n *Tnode, used map[*Tnode]bool) bool {
+	return !used[n] && n.inferredFrom != nil && n.Left != nil
+}
+
+// adopt makes n the parent of its children.
+func (n *Tnode) adopt() {
+	if n.Left != nil {
+		n.Left.Parent = n
+	}
+	if n.Right != nil {
+		n.Right.Parent = n
+	}
+}
+
+// prune finds the formulas that the branches below n need, collapsing
+// β splits that one branch doesn't need along the way.
+func (n *Tnode) prune() map[*Tnode]bool {
+	for n.Left != nil && n.Right == nil {
+		n = n.Left
+	}
+
+	if n.Left == nil && n.Right == nil {
+		used := make(map[*Tnode]bool)
+		if n.closed {
+			use(used, n)
+			use(used, n.Contradictory)
+		} else {
+			for p := n; p != nil; p = p.Parent {
+				use(used, p)
+			}
+		}
+		return used
+	}
+
+	left, right := n.Left.prune(), n.Right.prune()
+	premise := n.Left.inferredFrom
+	switch {
+	case !uses(n.Left, premise, left):
+		n.Right = nil
+		return left
+	case !uses(n.Right, premise, right):
+		n.Left, n.Right = n.Right, nil
+		return right
+	}
+	for m := range right {
+		left[m] = true
+	}
+	return left
+}
+
+// use marks n, its premise, its premise's premise, and so
+// on as needed.
+func use(used map[*Tnode]bool, n *Tnode) {
+	for ; n != nil && !used[n]; n = n.inferredFrom {
+		used[n] = true
+	}
+}
+
+// uses returns true if used has any of the components of premise
+// that start a branch at c.
+func uses(c *Tnode, premise *Tnode, used map[*Tnode]bool) bool {
+	for ; c != nil && c.inferredFrom == premise; c = c.Left {
+		if used[c] {
+			return true
+		}
+		if c.Right != nil {
+			break
+		}
+	}
+	return false
+}
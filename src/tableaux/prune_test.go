@@ -0,0 +1,64 @@
+package tableaux
+
+import (
+	"testing"
+)
+
+// Problems, and how big their tableaux are before and after pruning.
+var pruneTests = []struct {
+	formulas      []string
+	before, after Size
+}{
+	{[]string{"p > q", "q > r", "p > r"}, Size{Nodes: 13, Branches: 4}, Size{Nodes: 10, Branches: 3}},
+	{[]string{"(p | q) & (r | s)", "~p & ~q", "t"}, Size{Nodes: 19, Branches: 4}, Size{Nodes: 11, Branches: 2}},
+	{[]string{"~(p&q)=(~p|~q)"}, Size{Nodes: 22, Branches: 4}, Size{Nodes: 21, Branches: 4}},
+	{[]string{"p > q", "q", "p"}, Size{Nodes: 5, Branches: 2}, Size{Nodes: 5, Branches: 2}},
+}
+
+func TestPrune(t *testing.T) {
+	for _, test := range pruneTests {
+		root, _ := Setup(parseAll(t, test.formulas), Options{})
+		root.Expand()
+		open := len(root.FindUnclosedLeaf())
+		if size := root.Size(); size.Nodes != test.before.Nodes || size.Branches != test.before.Branches {
+			t.Errorf("%q: %+v before pruning, want %+v", test.formulas, size, test.before)
+		}
+		root.Prune()
+		if size := root.Size(); size.Nodes != test.after.Nodes || size.Branches != test.after.Branches {
+			t.Errorf("%q: %+v after pruning, want %+v", test.formulas, size, test.after)
+		}
+		if n := len(root.FindUnclosedLeaf()); n != open {
+			t.Errorf("%q: %d open branches after pruning, %d before", test.formulas, n, open)
+		}
+
+		// Lines numbered top to bottom, left branch before right,
+		// every formula inferred from one above it.
+		line := 0
+		var walk func(n *Tnode)
+		walk = func(n *Tnode) {
+			if n == nil {
+				return
+			}
+			if n.LineNumber != line {
+				t.Errorf("%q: line %d numbered %d", test.formulas, line, n.LineNumber)
+			}
+			line++
+			if n.inferredFrom != nil && !n.inferredFrom.above(n) {
+				t.Errorf("%q: line %d inferred from line %d, not above it", test.formulas, n.LineNumber, n.inferredFrom.LineNumber)
+			}
+			walk(n.Left)
+			walk(n.Right)
+		}
+		walk(root)
+	}
+}
+
+// above returns true if p is on the branch above n.
+func (p *Tnode) above(n *Tnode) bool {
+	for q := n.Parent; q != nil; q = q.Parent {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package tableaux
+
+// Tableaux for closed quantified boolean formulas. The quantifier rules
+// put ⊤ and ⊥ in place of the variable, ∀p X an α rule with both
+// instances, ∃p X a β rule with one instance per branch. T: ⊥ and F: ⊤
+// close a branch all by themselves. A closed QBF has no identifiers
+// but its variables, so once every quantifier is gone, each branch
+// closes, or says how to make the formulas true.
+//
+// Signing the formulas T, an open branch means they're true, and the
+// branch picks values for the variables in existential position: its
+// way through each β quantifier rule. The α quantifier rules above one
+// on its branch say which values of the variables in universal position
+// it's for. That's a Skolem function.
+
+import (
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/truthtable"
+)
+
+// SetupQBF creates the initial tableau for closed quantified boolean
+// formulas trees, all signed T, one below the other. The tableau has an
+// open branch if they're all true. Their variables should be renamed
+// apart, with a node.Renamer, for Skolem to tell quantifiers apart.
+func SetupQBF(trees []*node.Node, opts Options) *Tnode {
+	opts.QBF = true
+	root := Root(trees[0], true, opts)
+	leaf := root
+	for _, tree := range trees[1:] {
+		leaf.Left = New(tree, true, leaf)
+		leaf = leaf.Left
+	}
+	return root
+}
+
+// Skolem gives back Skolem functions for the variables in existential
+// position of the formulas at the top of the tableau, as open leaf
+// Tnode n's branch of a QBF tableau picks their values.
+func (n *Tnode) Skolem() []*truthtable.Skolem {
+	var branch []*Tnode
+	for p := n; p != nil; p = p.Parent {
+		branch = append(branch, p)
+	}
+
+	strategy := truthtable.NewStrategy()
+	var trees []*node.Node
+	for i := len(branch) - 1; i >= 0; i-- {
+		p := branch[i]
+		if p.inferredFrom == nil {
+			trees = append(trees, p.Tree)
+		} else if rule, ok := p.inferredFrom.quantifierRule(); ok && rule == Beta {
+			// p.Parent expanded a β quantifier rule, left branch ⊤.
+			strategy.Choose(p.inferredFrom.Tree.Ident, p.inferredFrom.universals(), p.Parent.Left == p)
+		}
+	}
+	return strategy.Skolem(trees...)
+}
+
+// quantifierRule gives back the type of rule QBF quantifier n gets,
+// α or β, and false if n isn't a quantifier at all.
+func (n *Tnode) quantifierRule() (RuleType, bool) {
+	if !lexer.Quantifier(n.Tree.Op) {
+		return Alpha, false
+	}
+	rule, ok := n.tableau.rule(n.Tree.Op, n.Sign)
+	return rule.Type, ok
+}
+
+// universals gives back the values of the variables in universal
+// position outside n, following inferences back to the top of the
+// tableau. An α quantifier rule subjoins the ⊤ instance first, with
+// the ⊥ instance below it.
+func (n *Tnode) universals() []truthtable.Binding {
+	var bindings []truthtable.Binding
+	for p := n; p.inferredFrom != nil; p = p.inferredFrom {
+		from := p.inferredFrom
+		if rule, ok := from.quantifierRule(); !ok || rule != Alpha {
+			continue
+		}
+		value := p.Parent.inferredFrom != from
+		bindings = append([]truthtable.Binding{{Variable: from.Tree.Ident, Value: value}}, bindings...)
+	}
+	return bindings
+}
@@ -0,0 +1,55 @@
+package tableaux
+
+import (
+	"testing"
+
+	"tableaux-in-go/src/node"
+)
+
+// Closed QBFs, decided together, whether they're all true, and the
+// variables in existential position an open branch picks values for.
+var qbfTests = []struct {
+	formulas []string
+	value    bool
+	skolem   []string
+}{
+	{[]string{"Ap (p | ~p)"}, true, nil},
+	{[]string{"Ap p"}, false, nil},
+	{[]string{"Ep p"}, true, []string{"p"}},
+	{[]string{"Ap Eq (p = q)"}, true, []string{"q"}},
+	{[]string{"Eq Ap (p = q)"}, false, nil},
+	{[]string{"Ap Eq Ar ((p | q) & (~p | ~q | r) | ~r)"}, true, []string{"q"}},
+	{[]string{"Ep p", "Ep ~p"}, true, []string{"p", "p1"}},
+	{[]string{"Ap Eq (p = q)", "Eq Ap (p = q)"}, false, nil},
+}
+
+func TestQBF(t *testing.T) {
+	for _, test := range qbfTests {
+		renamer := node.NewRenamer()
+		var trees []*node.Node
+		for _, tree := range parseAll(t, test.formulas) {
+			trees = append(trees, renamer.Rename(tree))
+		}
+		root := SetupQBF(trees, Options{})
+		if closed := root.Expand(); closed == test.value {
+			t.Errorf("%q: closed %v, want %v", test.formulas, closed, !test.value)
+			continue
+		}
+		for _, leaf := range root.FindUnclosedLeaf() {
+			var variables []string
+			for _, s := range leaf.Skolem() {
+				variables = append(variables, s.Variable)
+			}
+			if len(variables) != len(test.skolem) {
+				t.Errorf("%q: Skolem functions for %v, want %v", test.formulas, variables, test.skolem)
+				continue
+			}
+			for i := range variables {
+				if variables[i] != test.skolem[i] {
+					t.Errorf("%q: Skolem functions for %v, want %v", test.formulas, variables, test.skolem)
+					break
+				}
+			}
+		}
+	}
+}
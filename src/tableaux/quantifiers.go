@@ -0,0 +1,134 @@
+package tableaux
+
+// First order tableaux. A δ formula gets used once on a branch, like a
+// propositional formula, with a new parameter for its instance. A γ
+// formula never gets used up: ExpandContext instantiates γ formulas in
+// rounds, once it has nothing else left to do, each γ formula on an open
+// branch getting an instance for every ground term on that branch. A
+// branch that a round adds nothing to stays open for good. A bound on the
+// instances of any one γ formula on a branch keeps rounds from going on
+// forever when function symbols make new terms.
+
+import (
+	"fmt"
+
+	"tableaux-in-go/src/node"
+)
+
+// parameter makes up a constant that appears nowhere in tableau t.
+func (t *tableau) parameter() *node.Node {
+	for {
+		t.parameters++
+		name := fmt.Sprintf("a%d", t.parameters)
+		if !t.names[name] {
+			t.names[name] = true
+			return node.NewIdentNode(name)
+		}
+	}
+}
+
+// gamma returns true if n is a γ formula.
+func (n *Tnode) gamma() bool {
+	rule, ok := n.tableau.rule(n.Tree.Op, n.Sign)
+	return ok && rule.Type == Gamma
+}
+
+// branchTerms gives back the ground terms in formulas on the branch
+// from the root of the tableau down to leaf, in order of appearance.
+func (leaf *Tnode) branchTerms() []*node.Node {
+	var branch []*Tnode
+	for p := leaf; p != nil; p = p.Parent {
+		branch = append(branch, p)
+	}
+
+	var terms []*node.Node
+	seen := make(map[string]bool)
+	for i := len(branch) - 1; i >= 0; i-- {
+		for _, term := range node.GroundTerms(branch[i].Tree) {
+			if s := node.ExpressionToString(term); !seen[s] {
+				seen[s] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
+
+// instances counts the instances of γ formula from on the branch
+// ending at leaf.
+func (leaf *Tnode) instances(from *Tnode) int {
+	count := 0
+	for p := leaf; p != nil; p = p.Parent {
+		if p.inferredFrom == from {
+			count++
+		}
+	}
+	return count
+}
+
+// instantiate subjoins instances of γ formula from for each of terms
+// that the branch ending at leaf doesn't already have, one below the
+// other, stopping if the branch closes. With bound more than 0, the
+// branch gets at most bound instances of from. Instantiate gives back
+// the new leaf of the branch, how many instances it subjoined, and
+// true if the bound kept it from subjoining some.
+func (leaf *Tnode) instantiate(from *Tnode, terms []*node.Node, bound int) (*Tnode, int, bool) {
+	rule, _ := from.tableau.rule(from.Tree.Op, from.Sign)
+	c := rule.Components[0][0]
+	count := leaf.instances(from)
+	added := 0
+	for _, term := range terms {
+		instance := c.instance(from.Tree, term)
+		if leaf.onBranch(from.World, instance, c.Sign) {
+			continue
+		}
+		if bound > 0 && count >= bound {
+			return leaf, added, true
+		}
+		immediate := leaf.infer(from, instance, c.Sign, from.World)
+		leaf.Left = immediate
+		leaf = immediate
+		leaf.tableau.counts.expanded(from.Tree.Op, rule)
+		count++
+		added++
+		if leaf.CheckForContradictions() {
+			break
+		}
+	}
+	return leaf, added, false
+}
+
+// instantiateAll does a round of γ instances on every open branch of
+// the tableau rooted at root, giving back how many instances it
+// subjoined, and true if bound kept it from subjoining some.
+func (root *Tnode) instantiateAll(bound int) (int, bool) {
+	added := 0
+	blocked := false
+	for _, leaf := range root.FindUnclosedLeaf() {
+		var gammas []*Tnode
+		for p := leaf; p != nil; p = p.Parent {
+			if p.gamma() {
+				gammas = append([]*Tnode{p}, gammas...)
+			}
+		}
+		if len(gammas) == 0 {
+			continue
+		}
+		terms := leaf.branchTerms()
+		if len(terms) == 0 {
+			// A domain can't be empty.
+			terms = append(terms, leaf.tableau.parameter())
+		}
+		for _, g := range gammas {
+			var n int
+			var b bool
+			leaf, n, b = leaf.instantiate(g, terms, bound)
+			added += n
+			blocked = blocked || b
+			if leaf.closed {
+				break
+			}
+		}
+	}
+	return added, blocked
+}
@@ -0,0 +1,38 @@
+package tableaux
+
+import (
+	"context"
+	"testing"
+)
+
+// First order formulas, and what ExpandContext finds out about them
+// with the default bound on γ instances.
+var firstOrderTests = []struct {
+	formula string
+	result  Result
+}{
+	{"forall x.P(x) > P(c)", Closed},
+	{"forall x. (P(x) > Q(x)) > (forall x. P(x) > exists x. Q(x))", Closed},
+	{"~exists x. P(x) = forall x. ~P(x)", Closed},
+	{"exists x. forall y. R(x,y) > forall y. exists x. R(x,y)", Closed},
+	{"exists x. (P(x) > forall y. P(y))", Closed},
+	{"exists x. P(x) > forall x. P(x)", Open},
+	{"P(c) > forall x. P(x)", Open},
+	{"forall x. (P(x) | Q(x)) > (forall x. P(x) | forall x. Q(x))", Open},
+
+	// Every round makes a new parameter, so only the bound stops it.
+	{"forall y. exists x. R(x,y) > exists x. forall y. R(x,y)", Unknown},
+}
+
+func TestFirstOrder(t *testing.T) {
+	for _, test := range firstOrderTests {
+		root, _ := Setup(parseAll(t, []string{test.formula}), Options{})
+		result, err := root.ExpandContext(context.Background(), Limits{MaxInstances: DefaultMaxInstances})
+		if result != test.result {
+			t.Errorf("%q: %s, want %s", test.formula, result, test.result)
+		}
+		if _, ok := err.(*LimitError); (result == Unknown) != ok {
+			t.Errorf("%q: %s with error %v", test.formula, result, err)
+		}
+	}
+}
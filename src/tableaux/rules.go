@@ -0,0 +1,213 @@
+package tableaux
+
+// Smullyan's unifying notation: every signed formula that isn't a
+// signed identifier is either of type α, whose components all get
+// subjoined to a branch, or of type β, whose components each get a new
+// branch of their own. First order logic adds type γ, universal formulas
+// that get instances for any number of terms, and type δ, existential
+// formulas that get a single instance for a new parameter. Modal logic
+// adds Fitting's type ν, necessary formulas that hold in every world the
+// formula's world can see, and type π, possible formulas that hold in a
+// new world. The table below drives AddInferences. Intuitionistic logic
+// has a table of its own, for the connectives that differ, and so do
+// linear temporal logic's operators, see ltl.go.
+
+import (
+	"sync"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+)
+
+// RuleType is Smullyan's classification of a signed formula.
+type RuleType int
+
+const (
+	Alpha RuleType = iota // Components extend a branch
+	Beta                  // Components split a branch
+	Gamma                 // Instances for any terms extend a branch
+	Delta                 // Instance for a new parameter extends a branch
+	Nu                    // Operand in every accessible world extends a branch
+	Pi                    // Operand in a new world extends a branch
+)
+
+func (t RuleType) String() string {
+	switch t {
+	case Beta:
+		return "β"
+	case Gamma:
+		return "γ"
+	case Delta:
+		return "δ"
+	case Nu:
+		return "ν"
+	case Pi:
+		return "π"
+	}
+	return "α"
+}
+
+// Operand picks out an immediate subformula of a formula.
+type Operand int
+
+const (
+	LeftOperand   Operand = iota // Left side of a binary connective, or what ~ negates
+	RightOperand                 // Right side of a binary connective
+	Forward                      // Left side implies right side
+	Backward                     // Right side implies left side
+	Postponed                    // X of the formula itself: it holds from the next state on
+	TrueInstance                 // What a QBF quantifier quantifies, its variable ⊤
+	FalseInstance                // What a QBF quantifier quantifies, its variable ⊥
+)
+
+// Component is a signed subformula that a rule subjoins.
+type Component struct {
+	Operand Operand
+	Sign    bool
+}
+
+// instance gives back the instance of quantified formula tree for term.
+func (c Component) instance(tree, term *node.Node) *node.Node {
+	return node.Substitute(tree.Left, tree.Ident, term)
+}
+
+// of gives back the subformula of tree that c picks out, or the
+// implication between tree's operands.
+func (c Component) of(tree *node.Node) *node.Node {
+	switch c.Operand {
+	case RightOperand:
+		return tree.Right
+	case Forward, Backward:
+		implies := node.NewOpNode(lexer.IMPLIES)
+		implies.Left, implies.Right = tree.Left, tree.Right
+		if c.Operand == Backward {
+			implies.Left, implies.Right = tree.Right, tree.Left
+		}
+		return implies
+	case Postponed:
+		next := node.NewOpNode(lexer.NEXT)
+		next.Left = tree
+		return next
+	case TrueInstance, FalseInstance:
+		return node.Assign(tree.Left, tree.Ident, c.Operand == TrueInstance)
+	}
+	return tree.Left
+}
+
+// Rule says what subjoining the inferences of a signed formula does.
+// An α rule has one list of components, all subjoined to the same
+// branch, one below the other. A β rule has two lists, one for each
+// of the new branches.
+type Rule struct {
+	Type       RuleType
+	Components [][]Component
+}
+
+type ruleKey struct {
+	op   lexer.TokenType
+	sign bool
+}
+
+// rulesMu guards rules: RegisterRule can change it while proofs,
+// like the HTTP service's, go on in other goroutines.
+var rulesMu sync.RWMutex
+
+var rules = map[ruleKey]Rule{
+	// Negation: not really Smullyan's α, but it does extend a branch.
+	{lexer.NOT, true}:  {Alpha, [][]Component{{{LeftOperand, false}}}},
+	{lexer.NOT, false}: {Alpha, [][]Component{{{LeftOperand, true}}}},
+
+	{lexer.AND, true}:  {Alpha, [][]Component{{{LeftOperand, true}, {RightOperand, true}}}},
+	{lexer.AND, false}: {Beta, [][]Component{{{LeftOperand, false}}, {{RightOperand, false}}}},
+
+	{lexer.OR, true}:  {Beta, [][]Component{{{LeftOperand, true}}, {{RightOperand, true}}}},
+	{lexer.OR, false}: {Alpha, [][]Component{{{LeftOperand, false}, {RightOperand, false}}}},
+
+	{lexer.IMPLIES, true}:  {Beta, [][]Component{{{LeftOperand, false}}, {{RightOperand, true}}}},
+	{lexer.IMPLIES, false}: {Alpha, [][]Component{{{LeftOperand, true}, {RightOperand, false}}}},
+
+	// Smullyan would probably rather define equivalence as an
+	// abbreviation. It splits a branch, with two components per branch.
+	{lexer.EQUIV, true}: {Beta, [][]Component{
+		{{LeftOperand, true}, {RightOperand, true}},
+		{{LeftOperand, false}, {RightOperand, false}},
+	}},
+	{lexer.EQUIV, false}: {Beta, [][]Component{
+		{{LeftOperand, true}, {RightOperand, false}},
+		{{LeftOperand, false}, {RightOperand, true}},
+	}},
+
+	// The component of a quantified formula is an instance of
+	// the formula it quantifies, not that formula itself.
+	{lexer.FORALL, true}:  {Gamma, [][]Component{{{LeftOperand, true}}}},
+	{lexer.FORALL, false}: {Delta, [][]Component{{{LeftOperand, false}}}},
+	{lexer.EXISTS, true}:  {Delta, [][]Component{{{LeftOperand, true}}}},
+	{lexer.EXISTS, false}: {Gamma, [][]Component{{{LeftOperand, false}}}},
+
+	// Same operand and sign, different world.
+	{lexer.BOX, true}:      {Nu, [][]Component{{{LeftOperand, true}}}},
+	{lexer.BOX, false}:     {Pi, [][]Component{{{LeftOperand, false}}}},
+	{lexer.DIAMOND, true}:  {Pi, [][]Component{{{LeftOperand, true}}}},
+	{lexer.DIAMOND, false}: {Nu, [][]Component{{{LeftOperand, false}}}},
+}
+
+// intuitionisticRules replace rules in intuitionistic logic. T: formulas
+// stay true in every world a world sees, F: formulas need not. So T: ~X
+// and T: X > Y are like ν formulas, F: ~X and F: X > Y like π formulas,
+// and T: X > Y splits a branch in every world its world sees. X = Y
+// is (X > Y) & (Y > X).
+var intuitionisticRules = map[ruleKey]Rule{
+	{lexer.NOT, true}:  {Nu, [][]Component{{{LeftOperand, false}}}},
+	{lexer.NOT, false}: {Pi, [][]Component{{{LeftOperand, true}}}},
+
+	{lexer.IMPLIES, true}:  {Nu, [][]Component{{{LeftOperand, false}}, {{RightOperand, true}}}},
+	{lexer.IMPLIES, false}: {Pi, [][]Component{{{LeftOperand, true}, {RightOperand, false}}}},
+
+	{lexer.EQUIV, true}:  {Alpha, [][]Component{{{Forward, true}, {Backward, true}}}},
+	{lexer.EQUIV, false}: {Beta, [][]Component{{{Forward, false}}, {{Backward, false}}}},
+}
+
+// qbfRules replace the quantifier rules for quantified boolean
+// formulas. A variable is either true or false, so ∀p X is like
+// X(⊤) & X(⊥), and ∃p X like X(⊤) | X(⊥).
+var qbfRules = map[ruleKey]Rule{
+	{lexer.FORALL, true}:  {Alpha, [][]Component{{{TrueInstance, true}, {FalseInstance, true}}}},
+	{lexer.FORALL, false}: {Beta, [][]Component{{{TrueInstance, false}}, {{FalseInstance, false}}}},
+	{lexer.EXISTS, true}:  {Beta, [][]Component{{{TrueInstance, true}}, {{FalseInstance, true}}}},
+	{lexer.EXISTS, false}: {Alpha, [][]Component{{{TrueInstance, false}, {FalseInstance, false}}}},
+}
+
+// rule finds the rule for formulas of tableau t with connective op
+// signed sign, the way LookupRule does, in t's logic.
+func (t *tableau) rule(op lexer.TokenType, sign bool) (Rule, bool) {
+	if t.opts.Modal == Intuitionistic {
+		if rule, ok := intuitionisticRules[ruleKey{op, sign}]; ok {
+			return rule, true
+		}
+	}
+	if t.opts.QBF {
+		if rule, ok := qbfRules[ruleKey{op, sign}]; ok {
+			return rule, true
+		}
+	}
+	return LookupRule(op, sign)
+}
+
+// RegisterRule adds or replaces the rule for formulas with connective
+// op signed sign. The lexer and parser have to know about a connective
+// before any formula can have it. It's safe to call while proofs go
+// on, though they might not all use the same rule.
+func RegisterRule(op lexer.TokenType, sign bool, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules[ruleKey{op, sign}] = rule
+}
+
+// LookupRule finds the rule for formulas with connective op signed
+// sign, giving back false if there's no such rule, as for identifiers.
+func LookupRule(op lexer.TokenType, sign bool) (Rule, bool) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	rule, ok := rules[ruleKey{op, sign}]
+	return rule, ok
+}
@@ -0,0 +1,79 @@
+package tableaux
+
+import (
+	"sync"
+	"testing"
+
+	"tableaux-in-go/src/lexer"
+)
+
+// Smullyan's types for the classical connectives, and how many
+// components each branch gets.
+var ruleTests = []struct {
+	op         lexer.TokenType
+	sign       bool
+	typ        RuleType
+	components []int
+}{
+	{lexer.AND, true, Alpha, []int{2}},
+	{lexer.AND, false, Beta, []int{1, 1}},
+	{lexer.OR, true, Beta, []int{1, 1}},
+	{lexer.OR, false, Alpha, []int{2}},
+	{lexer.IMPLIES, true, Beta, []int{1, 1}},
+	{lexer.IMPLIES, false, Alpha, []int{2}},
+	{lexer.NOT, true, Alpha, []int{1}},
+	{lexer.NOT, false, Alpha, []int{1}},
+	{lexer.FORALL, true, Gamma, []int{1}},
+	{lexer.EXISTS, true, Delta, []int{1}},
+	{lexer.BOX, true, Nu, []int{1}},
+	{lexer.DIAMOND, true, Pi, []int{1}},
+}
+
+func TestLookupRule(t *testing.T) {
+	for _, test := range ruleTests {
+		rule, ok := LookupRule(test.op, test.sign)
+		if !ok {
+			t.Errorf("no rule for %v: %s", test.sign, lexer.TokenName(test.op))
+			continue
+		}
+		if rule.Type != test.typ || len(rule.Components) != len(test.components) {
+			t.Errorf("%v: %s is %s with %d branches, want %s with %d",
+				test.sign, lexer.TokenName(test.op), rule.Type, len(rule.Components), test.typ, len(test.components))
+			continue
+		}
+		for i, components := range rule.Components {
+			if len(components) != test.components[i] {
+				t.Errorf("%v: %s has %d components on branch %d, want %d",
+					test.sign, lexer.TokenName(test.op), len(components), i, test.components[i])
+			}
+		}
+	}
+	if _, ok := LookupRule(lexer.IDENT, true); ok {
+		t.Errorf("identifiers have a rule")
+	}
+}
+
+// RegisterRule can go on while proofs do, as in the HTTP service.
+// go test -race finds it if that isn't so.
+func TestRegisterRuleConcurrently(t *testing.T) {
+	rule, _ := LookupRule(lexer.AND, true)
+	trees := parseAll(t, []string{"((p & q) & r) > (p & (q & r))"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if root, _ := Setup(trees, Options{}); !root.Expand() {
+					t.Errorf("tableau didn't close")
+					return
+				}
+			}
+		}()
+	}
+	for j := 0; j < 50; j++ {
+		RegisterRule(lexer.AND, true, rule)
+	}
+	wg.Wait()
+}
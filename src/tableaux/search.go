@@ -0,0 +1,453 @@
+package tableaux
+
+// Depth-first, backtracking proof search. Instead of subjoining
+// inferences to every open leaf below a formula, it works on one branch
+// at a time, keeping a stack of β alternatives still to try. Without a
+// tableau to show, it only ever holds a single branch in memory, and it
+// stops at the first open branch it completes. Given more than one
+// worker, it hands the second branch of a β split to another goroutine
+// whenever one is free, so sibling subtrees get explored at the same time.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/truthtable"
+)
+
+// SearchResult is what DepthFirst finds out.
+type SearchResult struct {
+	Result       Result
+	Valuation    map[string]bool // From the first open branch, if any
+	Size         Size            // Formulas created, branches explored, longest branch
+	Saved        int             // Formulas a regular search didn't add
+	Stats        Stats           // All but memory and time
+	Root         *Tnode          // The tableau, if DepthFirst kept it
+	FinalFormula *Tnode          // Consequence in the kept tableau, nil for a single formula
+}
+
+// formula is one signed formula on the branch being explored.
+type formula struct {
+	sign  bool
+	tree  *node.Node
+	key   string // Sign and formula, for finding duplicates and contradictions
+	tnode *Tnode // Kept tableau's Tnode for this formula, if keeping one
+}
+
+// alternative is the second branch of a β rule, to try after
+// finishing with the first.
+type alternative struct {
+	branchLen  int         // Formulas on the branch at the split
+	unexpanded []int       // Branch indexes of formulas not yet expanded at the split
+	premise    int         // Branch index of the β formula
+	components []Component // Components for the second branch
+	split      *Tnode      // Tnode that gets the second branch as its Right
+}
+
+// searcher explores one branch at a time. A parallel search has one
+// searcher per worker, each with a branch of its own.
+type searcher struct {
+	opts   Options
+	limits Limits
+	keep   bool
+	shared *shared
+
+	branch     []formula
+	onBranch   map[string]int // Index of each key's first appearance on the branch
+	unexpanded []int
+	stack      []alternative
+	result     Result // What search found out, Open if its branch stayed open
+}
+
+// shared holds what all the searchers of one search have in common.
+type shared struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex // Guards the fields below, and the kept tableau
+	size      Size
+	saved     int
+	counts    counts
+	closed    int         // Branches that closed
+	tableau   *tableau    // Shared by the kept Tnodes
+	open      *searcher   // First searcher to complete an open branch
+	err       error       // Why the search stopped early, if it did
+	searchers []*searcher // Every searcher, for finishing a kept tableau
+
+	workers chan struct{} // One token per worker, nil for a sequential search
+	wg      sync.WaitGroup
+}
+
+// DepthFirst decides the problem in trees, a single formula or
+// hypotheses and a consequence, the way Setup and ExpandContext would,
+// by depth-first search with backtracking. It stops at the first open
+// branch it completes. With keep true, it builds the tableau it explores,
+// numbering lines in the order it visits them, and gives back its root.
+func DepthFirst(ctx context.Context, trees []*node.Node, opts Options, limits Limits, keep bool) (*SearchResult, error) {
+	return Parallel(ctx, trees, opts, limits, keep, 1)
+}
+
+// Parallel searches depth-first the way DepthFirst does, with up to
+// workers goroutines exploring sibling subtrees at the same time. The
+// first worker to complete an open branch stops all the others. It
+// decides the same as DepthFirst, but which open branch it finds first
+// can change from run to run. A kept tableau gets its lines numbered
+// top to bottom, left branch before right, once the search is over.
+func Parallel(ctx context.Context, trees []*node.Node, opts Options, limits Limits, keep bool, workers int) (*SearchResult, error) {
+	for _, tree := range trees {
+		if tree.FirstOrder() || tree.Modal() || tree.Temporal() || opts.Modal != NonModal || opts.Logic != truthtable.Classical {
+			return &SearchResult{Result: Unknown}, errors.New("depth-first search only does classical propositional logic")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sh := &shared{ctx: ctx, cancel: cancel}
+	if workers > 1 {
+		sh.workers = make(chan struct{}, workers)
+		sh.workers <- struct{}{} // This goroutine's token
+	}
+	s := sh.newSearcher(opts, limits, keep)
+	sr := &SearchResult{}
+
+	// Like Start, any formula can close the branch by contradicting
+	// one above it, in an unsigned tableau, and the rest don't matter.
+	closed := false
+	if keep {
+		sr.Root, sr.FinalFormula = Start(trees, opts)
+		sh.tableau = sr.Root.tableau
+		for t := sr.Root; t != nil && !closed; t = t.Left {
+			closed = s.add(formula{sign: t.Sign, tree: t.Tree, tnode: t}) >= 0
+		}
+	} else {
+		for i, tree := range trees {
+			if closed = s.add(formula{sign: len(trees) > 1 && i < len(trees)-1, tree: tree}) >= 0; closed {
+				break
+			}
+		}
+	}
+	sh.size.Branches = 1
+	if closed {
+		sh.closed++
+	}
+
+	sh.run(s, closed)
+	sh.wg.Wait()
+
+	sr.Result = Closed
+	switch {
+	case sh.open != nil:
+		sr.Result = Open
+		sr.Valuation = sh.open.valuation()
+	case sh.err != nil:
+		sr.Result = Unknown
+	}
+	if keep && sr.Result != Closed {
+		for _, s := range sh.searchers {
+			s.finish()
+		}
+	}
+	if keep && workers > 1 {
+		sr.Root.renumber()
+	}
+	sr.Size = sh.size
+	var limit *LimitError
+	if errors.As(sh.err, &limit) {
+		// Other workers went on adding formulas until they
+		// noticed, and finishing a kept tableau adds more.
+		limit.Size = sh.size
+	}
+	sr.Saved = sh.saved
+	if keep {
+		sr.Root.tableau.counts = sh.counts
+		sr.Stats = sr.Root.Stats()
+	} else {
+		sr.Stats = Stats{
+			Nodes:          sh.size.Nodes,
+			Branches:       sh.size.Branches,
+			ClosedBranches: sh.closed,
+			MaxDepth:       sh.size.Depth,
+		}
+		if sr.Result == Open {
+			sr.Stats.OpenBranches = 1
+		}
+		sh.counts.fill(&sr.Stats)
+	}
+	if sr.Result != Unknown {
+		return sr, nil
+	}
+	return sr, sh.err
+}
+
+// normalize puts a formula the way the branch keeps it, as Tnodes do.
+func (s *searcher) normalize(tree *node.Node, sign bool) formula {
+	if s.opts.Unsigned && sign && tree.Op == lexer.NOT {
+		sign, tree = false, tree.Left
+	}
+	f := formula{sign: sign, tree: tree, key: "F: " + node.ExpressionToString(tree)}
+	if sign {
+		f.key = "T: " + f.key[3:]
+	}
+	return f
+}
+
+func complement(key string) string {
+	if key[0] == 'T' {
+		return "F" + key[1:]
+	}
+	return "T" + key[1:]
+}
+
+// add puts a formula at the end of the branch, giving back the branch
+// index of a formula it contradicts, or -1 if it contradicts nothing.
+func (s *searcher) add(f formula) int {
+	n := s.normalize(f.tree, f.sign)
+	n.tnode = f.tnode
+
+	sh := s.shared
+	sh.mu.Lock()
+	sh.size.Nodes++
+	sh.counts.closureChecks++
+	if len(s.branch)+1 > sh.size.Depth {
+		sh.size.Depth = len(s.branch) + 1
+	}
+	sh.mu.Unlock()
+
+	other, contradiction := s.onBranch[complement(n.key)]
+	if _, ok := s.onBranch[n.key]; !ok {
+		s.onBranch[n.key] = len(s.branch)
+	}
+	s.branch = append(s.branch, n)
+	if n.tree.Op != lexer.IDENT {
+		s.unexpanded = append(s.unexpanded, len(s.branch)-1)
+	}
+
+	if !contradiction {
+		return -1
+	}
+	return other
+}
+
+// truncate takes formulas off the end of the branch, back to length n.
+func (s *searcher) truncate(n int) {
+	for i := n; i < len(s.branch); i++ {
+		if s.onBranch[s.branch[i].key] == i {
+			delete(s.onBranch, s.branch[i].key)
+		}
+	}
+	s.branch = s.branch[:n]
+}
+
+// subjoin adds components of the formula at branch index premise
+// one below the other, stopping at a contradiction. Below tells what
+// kept Tnode the first component goes below, right says whether it's
+// the second branch of a β rule.
+func (s *searcher) subjoin(premise int, components []Component, below *Tnode, right bool) bool {
+	from := s.branch[premise]
+	for _, c := range components {
+		tree := c.of(from.tree)
+		if s.opts.Regular {
+			if _, ok := s.onBranch[s.normalize(tree, c.Sign).key]; ok {
+				s.shared.addSaved(1)
+				continue
+			}
+		}
+		f := formula{sign: c.Sign, tree: tree}
+		if s.keep {
+			s.shared.mu.Lock()
+			f.tnode = New(tree, c.Sign, below)
+			f.tnode.inferredFrom = from.tnode
+			if right {
+				below.Right = f.tnode
+				right = false
+			} else {
+				below.Left = f.tnode
+			}
+			below = f.tnode
+			s.shared.mu.Unlock()
+		}
+		if other := s.add(f); other >= 0 {
+			s.shared.mu.Lock()
+			s.shared.closed++
+			if f.tnode != nil {
+				f.tnode.closed = true
+				f.tnode.Contradictory = s.branch[other].tnode
+			}
+			s.shared.mu.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+// subsumed returns true if the branch has all the components of one
+// alternative of rule for the formula at branch index i.
+func (s *searcher) subsumed(i int, rule Rule) bool {
+	for _, components := range rule.Components {
+		all := true
+		for _, c := range components {
+			if _, ok := s.onBranch[s.normalize(c.of(s.branch[i].tree), c.Sign).key]; !ok {
+				all = false
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+// search explores branches until one stays open with nothing left to
+// expand, or every branch closes.
+func (s *searcher) search(closed bool) (Result, error) {
+	for {
+		if closed {
+			if len(s.stack) == 0 {
+				return Closed, nil
+			}
+			closed = s.backtrack()
+			continue
+		}
+		if len(s.unexpanded) == 0 {
+			return Open, nil
+		}
+		if err := s.check(); err != nil {
+			return Unknown, err
+		}
+
+		i := s.unexpanded[0]
+		s.unexpanded = s.unexpanded[1:]
+		from := s.branch[i]
+		rule, ok := LookupRule(from.tree.Op, from.sign)
+		if !ok {
+			panic(fmt.Sprintf("No rule for %s", from.key))
+		}
+		if from.tnode != nil {
+			// Other workers' branches can have the same Tnode.
+			s.shared.mu.Lock()
+			from.tnode.Used = true
+			s.shared.mu.Unlock()
+		}
+		if s.opts.Regular && s.subsumed(i, rule) {
+			for _, components := range rule.Components {
+				s.shared.addSaved(len(components))
+			}
+			continue
+		}
+		s.shared.mu.Lock()
+		s.shared.counts.expanded(from.tree.Op, rule)
+		s.shared.mu.Unlock()
+
+		leaf := s.leaf()
+		if rule.Type == Beta {
+			a := alternative{
+				branchLen:  len(s.branch),
+				unexpanded: append([]int(nil), s.unexpanded...),
+				premise:    i,
+				components: rule.Components[1],
+				split:      leaf,
+			}
+			s.shared.mu.Lock()
+			s.shared.size.Branches++
+			s.shared.mu.Unlock()
+			if !s.shared.handOff(s, a) {
+				s.stack = append(s.stack, a)
+			}
+		}
+		closed = s.subjoin(i, rule.Components[0], leaf, false)
+	}
+}
+
+// backtrack takes the branch back to the most recent β split, and
+// starts on its second alternative, giving back true if that
+// closes right away.
+func (s *searcher) backtrack() bool {
+	a := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	return s.take(a)
+}
+
+// take starts on alternative a, giving back true if
+// its branch closes right away.
+func (s *searcher) take(a alternative) bool {
+	s.truncate(a.branchLen)
+	s.unexpanded = a.unexpanded
+	if a.split != nil {
+		s.shared.mu.Lock()
+		s.shared.tableau.branches++
+		s.shared.mu.Unlock()
+	}
+	return s.subjoin(a.premise, a.components, a.split, true)
+}
+
+// finish gives every β split in a kept tableau its second branch,
+// without expanding anything further, after the search stopped at an
+// open branch or gave up. Branches it didn't get to the end of get
+// marked unexplored, so they don't look open.
+func (s *searcher) finish() {
+	if s.result != Open {
+		s.unexplored()
+	}
+	for len(s.stack) > 0 {
+		if !s.backtrack() {
+			s.unexplored()
+		}
+	}
+}
+
+// unexplored marks the kept Tnode at the end of the branch unexplored,
+// unless the branch closed.
+func (s *searcher) unexplored() {
+	if leaf := s.leaf(); leaf.Left == nil && leaf.Right == nil && !leaf.closed {
+		leaf.unexplored = true
+	}
+}
+
+// leaf gives back the kept Tnode at the end of the branch, nil if
+// not keeping a tableau.
+func (s *searcher) leaf() *Tnode {
+	if !s.keep {
+		return nil
+	}
+	return s.branch[len(s.branch)-1].tnode
+}
+
+// check gives back a *LimitError if the search has gone past its limits.
+func (s *searcher) check() error {
+	limits := s.limits
+	s.shared.mu.Lock()
+	size := s.shared.size
+	s.shared.mu.Unlock()
+	reason := ""
+	switch {
+	case limits.MaxNodes > 0 && size.Nodes > limits.MaxNodes:
+		reason = fmt.Sprintf("max nodes %d", limits.MaxNodes)
+	case limits.MaxBranches > 0 && size.Branches > limits.MaxBranches:
+		reason = fmt.Sprintf("max branches %d", limits.MaxBranches)
+	case limits.MaxDepth > 0 && size.Depth > limits.MaxDepth:
+		reason = fmt.Sprintf("max depth %d", limits.MaxDepth)
+	}
+	if reason != "" {
+		return &LimitError{Reason: reason, Size: size}
+	}
+	if err := s.shared.ctx.Err(); err != nil {
+		return &LimitError{Reason: err.Error(), Size: size, err: err}
+	}
+	return nil
+}
+
+// valuation gives back the truth values the signed identifiers
+// on the branch give those identifiers.
+func (s *searcher) valuation() map[string]bool {
+	valuation := make(map[string]bool)
+	for _, f := range s.branch {
+		if f.tree.Op == lexer.IDENT {
+			valuation[f.tree.Ident] = f.sign
+		}
+	}
+	return valuation
+}
@@ -0,0 +1,35 @@
+package tableaux
+
+import (
+	"context"
+	"testing"
+)
+
+// Depth-first search decides the same as Expand, keeping a tableau or
+// not, signed or unsigned, regular or not.
+func TestDepthFirst(t *testing.T) {
+	for _, opts := range []Options{{}, {Unsigned: true}, {Regular: true}} {
+		for _, keep := range []bool{false, true} {
+			for _, test := range consequenceTests {
+				search, err := DepthFirst(context.Background(), parseAll(t, test.formulas), opts, Limits{}, keep)
+				if err != nil {
+					t.Fatalf("%q: %v", test.formulas, err)
+				}
+				if closed := search.Result == Closed; closed != test.closed {
+					t.Errorf("%q, %+v, keep %v: closed %v, want %v", test.formulas, opts, keep, closed, test.closed)
+				}
+				if !keep {
+					continue
+				}
+				// Only the open branch it stopped at, the rest unexplored.
+				want := 1
+				if test.closed {
+					want = 0
+				}
+				if open := len(search.Root.FindUnclosedLeaf()); open != want {
+					t.Errorf("%q, %+v: kept tableau has %d open branches, want %d", test.formulas, opts, open, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package tableaux
+
+// Sequent calculus derivations from closed tableaux. A branch of a
+// signed tableau is a sequent turned upside down: its T: formulas go
+// left of ⊢, its F: formulas right. Each rule application that
+// subjoins components to a branch is a rule of Gentzen's cut-free
+// sequent calculus, read from the conclusion up: the branch before is
+// the conclusion, the branch or branches after are the premises. A
+// closed branch is an axiom, the same formula on both sides.
+//
+// The derivations are G3c derivations: the premises of a rule don't
+// have its principal formula any more, except for ∀L and ∃R, the γ
+// rules, which keep it around for more instances. Axioms have any
+// formulas at all in their contexts, and the formula on both sides
+// needn't be atomic, which G3c allows as derived rules. A branch can
+// close with a formula the tableau expanded already, so a principal
+// formula that closes a branch stays in the premises too, the way
+// Kleene's G3 keeps it; G3c gets the same with contraction.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/truthtable"
+)
+
+// Derivation is a sequent, Antecedent ⊢ Succedent, the name of the
+// rule that derives it, like ∧L, or Ax for an axiom, and derivations
+// of the rule's premises.
+type Derivation struct {
+	Antecedent []*node.Node
+	Succedent  []*node.Node
+	Rule       string
+	Premises   []*Derivation
+
+	latexRule string // Rule for a bussproofs label
+}
+
+// connectiveNames name sequent rules, ∧L for T: X & Y, ∧R for F: X & Y,
+// in text, then in LaTeX.
+var connectiveNames = map[lexer.TokenType][2]string{
+	lexer.NOT:     {"¬", `\lnot`},
+	lexer.AND:     {"∧", `\land`},
+	lexer.OR:      {"∨", `\lor`},
+	lexer.IMPLIES: {"→", `\to`},
+	lexer.EQUIV:   {"↔", `\leftrightarrow`},
+	lexer.FORALL:  {"∀", `\forall`},
+	lexer.EXISTS:  {"∃", `\exists`},
+}
+
+// Derivation gives back the sequent calculus derivation of the closed
+// tableau rooted at root, signed or unsigned, propositional or first
+// order. Its end sequent has the formulas the tableau started with.
+func (root *Tnode) Derivation() (*Derivation, error) {
+	opts := root.tableau.opts
+	if opts.Modal != NonModal || opts.Logic != truthtable.Classical || opts.QBF {
+		return nil, errors.New("sequent calculus derivations come from tableaux of classical logic")
+	}
+	// The formulas the tableau started with come one below the other.
+	active := []*Tnode{root}
+	n := root
+	for n.Left != nil && n.Left.inferredFrom == nil {
+		n = n.Left
+		active = append(active, n)
+	}
+	// Any that contradicting hypotheses kept out of the tableau
+	// stay in every sequent's context.
+	active = append(active, root.tableau.skipped...)
+	// Formulas that close branches, which axioms need.
+	closers := make(map[*Tnode]bool)
+	var walk func(n *Tnode)
+	walk = func(n *Tnode) {
+		for ; n != nil; n = n.Left {
+			if n.closed {
+				closers[n], closers[n.Contradictory] = true, true
+			}
+			walk(n.Right)
+		}
+	}
+	walk(root)
+	return n.derivation(active, closers)
+}
+
+// derivation gives back the derivation of the sequent of active
+// formulas on the branch ending at n, so far.
+func (n *Tnode) derivation(active []*Tnode, closers map[*Tnode]bool) (*Derivation, error) {
+	d := &Derivation{}
+	for _, p := range active {
+		if p.Sign {
+			d.Antecedent = append(d.Antecedent, p.Tree)
+		} else {
+			d.Succedent = append(d.Succedent, p.Tree)
+		}
+	}
+	if n.closed {
+		d.Rule, d.latexRule = "Ax", "Ax"
+		return d, nil
+	}
+	if n.Left == nil {
+		return nil, fmt.Errorf("open branch at line %d, no derivation", n.LineNumber)
+	}
+
+	from := n.Left.inferredFrom
+	rule, ok := from.tableau.rule(from.Tree.Op, from.Sign)
+	if !ok || (rule.Type == Beta) != (n.Right != nil) {
+		return nil, fmt.Errorf("line %d doesn't finish a rule application", n.LineNumber)
+	}
+	side := "R"
+	if from.Sign {
+		side = "L"
+	}
+	names := connectiveNames[from.Tree.Op]
+	d.Rule = names[0] + side
+	d.latexRule = "$" + names[1] + "$" + side
+
+	// The principal formula goes, unless it's a γ formula, or closes a
+	// branch.
+	var context []*Tnode
+	for _, p := range active {
+		if p != from || rule.Type == Gamma || closers[from] {
+			context = append(context, p)
+		}
+	}
+	for i, first := range []*Tnode{n.Left, n.Right} {
+		if first == nil {
+			continue
+		}
+		premise := append([]*Tnode(nil), context...)
+		last := first
+		if rule.Type == Gamma || rule.Type == Delta {
+			premise = append(premise, first)
+		} else {
+			premise, last = from.components(premise, rule.Components[i], first)
+		}
+		p, err := last.derivation(premise, closers)
+		if err != nil {
+			return nil, err
+		}
+		d.Premises = append(d.Premises, p)
+	}
+	return d, nil
+}
+
+// components adds the components of principal formula n to premise,
+// the ones subjoined one below the other starting at first, and gives
+// back the last one subjoined. A tableau subjoins no more components
+// once one closes the branch, and a regular one leaves out the ones
+// already on it, but the rule's premise has them all, so components
+// makes up Tnodes for those.
+func (n *Tnode) components(premise []*Tnode, components []Component, first *Tnode) ([]*Tnode, *Tnode) {
+	last, next := first, first
+	for _, c := range components {
+		tree, sign := n.tableau.normalize(c.of(n.Tree), c.Sign)
+		if next == nil || next.inferredFrom != n || next.Sign != sign ||
+			node.ExpressionToString(next.Tree) != node.ExpressionToString(tree) {
+			premise = append(premise, &Tnode{Tree: tree, Sign: sign})
+			continue
+		}
+		premise = append(premise, next)
+		last, next = next, nil
+		if !last.closed && last.Right == nil {
+			next = last.Left
+		}
+	}
+	return premise, last
+}
+
+// Sequent gives back d's sequent, like p, p > q ⊢ q.
+func (d *Derivation) Sequent() string {
+	return sequent(d, node.ExpressionToString, "⊢")
+}
+
+// sequent writes out d's sequent with text for formulas.
+func sequent(d *Derivation, text func(*node.Node) string, turnstile string) string {
+	join := func(formulas []*node.Node) string {
+		var texts []string
+		for _, f := range formulas {
+			texts = append(texts, text(f))
+		}
+		return strings.Join(texts, ", ")
+	}
+	s := turnstile
+	if len(d.Antecedent) > 0 {
+		s = join(d.Antecedent) + " " + s
+	}
+	if len(d.Succedent) > 0 {
+		s += " " + join(d.Succedent)
+	}
+	return s
+}
+
+// Print writes d as indented text, the end sequent first, each
+// premise indented under the sequent it's a premise of.
+func (d *Derivation) Print(w io.Writer) {
+	d.print(w, "")
+}
+
+func (d *Derivation) print(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%s%s  (%s)\n", indent, d.Sequent(), d.Rule)
+	for _, p := range d.Premises {
+		p.print(w, indent+"  ")
+	}
+}
+
+// LaTeX writes d as a LaTeX bussproofs prooftree environment,
+// which needs \usepackage{bussproofs}.
+func (d *Derivation) LaTeX(w io.Writer) {
+	fmt.Fprintf(w, "\\begin{prooftree}\n")
+	d.latex(w)
+	fmt.Fprintf(w, "\\end{prooftree}\n")
+}
+
+// latex writes the bussproofs commands for d. Premises come first,
+// then the inference, the way bussproofs keeps a stack of them.
+func (d *Derivation) latex(w io.Writer) {
+	text := "$" + sequent(d, node.LaTeXString, `\vdash`) + "$"
+	if len(d.Premises) == 0 {
+		fmt.Fprintf(w, "\\AxiomC{%s}\n", text)
+		return
+	}
+	for _, p := range d.Premises {
+		p.latex(w)
+	}
+	inference := "UnaryInfC"
+	if len(d.Premises) == 2 {
+		inference = "BinaryInfC"
+	}
+	fmt.Fprintf(w, "\\RightLabel{\\scriptsize %s}\n", d.latexRule)
+	fmt.Fprintf(w, "\\%s{%s}\n", inference, text)
+}
@@ -0,0 +1,122 @@
+package tableaux
+
+import (
+	"strings"
+	"testing"
+
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/truthtable"
+)
+
+// Closed tableaux, and the end sequents of their derivations.
+var sequentTests = []struct {
+	formulas []string
+	opts     Options
+	sequent  string
+}{
+	{[]string{"p > q", "q > r", "p > r"}, Options{}, "p > q, q > r ⊢ p > r"},
+	{[]string{"~(p & q) = (~p | ~q)"}, Options{}, "⊢ ~(p & q) = (~p | ~q)"},
+	{[]string{"~(p & q) = (~p | ~q)"}, Options{Unsigned: true}, "⊢ ~(p & q) = (~p | ~q)"},
+	{[]string{"((p > q) > p) > p"}, Options{Regular: true}, "⊢ ((p > q) > p) > p"},
+	{[]string{"p", "~p", "q"}, Options{Unsigned: true}, "p ⊢ p, q"},
+	{[]string{"forall x.P(x) > P(c)"}, Options{}, "⊢ Ax P(x) > P(c)"},
+}
+
+func TestDerivation(t *testing.T) {
+	for _, test := range sequentTests {
+		root, _ := Setup(parseAll(t, test.formulas), test.opts)
+		if !root.Expand() {
+			t.Fatalf("%q: tableau didn't close", test.formulas)
+		}
+		d, err := root.Derivation()
+		if err != nil {
+			t.Errorf("%q, %+v: %v", test.formulas, test.opts, err)
+			continue
+		}
+		if s := d.Sequent(); s != test.sequent {
+			t.Errorf("%q, %+v: end sequent %q, want %q", test.formulas, test.opts, s, test.sequent)
+		}
+		d.walk(func(d *Derivation) {
+			if (d.Rule == "Ax") != (len(d.Premises) == 0) || len(d.Premises) > 2 {
+				t.Errorf("%q: %s by %s from %d premises", test.formulas, d.Sequent(), d.Rule, len(d.Premises))
+			}
+			if !d.firstOrder() && !d.valid() {
+				t.Errorf("%q: %s isn't valid", test.formulas, d.Sequent())
+			}
+		})
+		var text, latex strings.Builder
+		d.Print(&text)
+		d.LaTeX(&latex)
+		if !strings.Contains(text.String(), "(Ax)") || !strings.Contains(latex.String(), `\end{prooftree}`) {
+			t.Errorf("%q: prints as\n%s\n%s", test.formulas, text.String(), latex.String())
+		}
+	}
+}
+
+// Open tableaux, and tableaux that aren't of classical logic,
+// have no derivations.
+func TestDerivationErrors(t *testing.T) {
+	for _, test := range []struct {
+		formulas []string
+		opts     Options
+	}{
+		{[]string{"p > q"}, Options{}},
+		{[]string{"[]p > p"}, Options{Modal: SystemT}},
+		{[]string{"p > p"}, Options{Logic: truthtable.LP}},
+	} {
+		root, _ := Setup(parseAll(t, test.formulas), test.opts)
+		root.Expand()
+		if _, err := root.Derivation(); err == nil {
+			t.Errorf("%q, %+v: derivation", test.formulas, test.opts)
+		}
+	}
+}
+
+// walk calls fn on d and every derivation above it.
+func (d *Derivation) walk(fn func(*Derivation)) {
+	fn(d)
+	for _, p := range d.Premises {
+		p.walk(fn)
+	}
+}
+
+func (d *Derivation) firstOrder() bool {
+	for _, tree := range append(append([]*node.Node(nil), d.Antecedent...), d.Succedent...) {
+		if tree.FirstOrder() {
+			return true
+		}
+	}
+	return false
+}
+
+// valid returns true if no valuation makes all of d's antecedent true
+// and all of its succedent false.
+func (d *Derivation) valid() bool {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, tree := range append(append([]*node.Node(nil), d.Antecedent...), d.Succedent...) {
+		for _, id := range truthtable.Identifiers(tree) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	for bits := 0; bits < 1<<len(ids); bits++ {
+		valuation := make(map[string]bool)
+		for i, id := range ids {
+			valuation[id] = bits&(1<<i) != 0
+		}
+		counter := true
+		for _, tree := range d.Antecedent {
+			counter = counter && truthtable.Evaluate(tree, valuation)
+		}
+		for _, tree := range d.Succedent {
+			counter = counter && !truthtable.Evaluate(tree, valuation)
+		}
+		if counter {
+			return false
+		}
+	}
+	return true
+}
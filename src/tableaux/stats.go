@@ -0,0 +1,127 @@
+package tableaux
+
+// Numbers about a proof search, for comparing strategies,
+// and noticing when a change makes proofs bigger or slower.
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"tableaux-in-go/src/lexer"
+)
+
+// Stats describes a finished proof search. Whatever drives the search
+// has to fill in Allocated and WallTime.
+type Stats struct {
+	Nodes          int `json:"nodes"`
+	Branches       int `json:"branches"`
+	ClosedBranches int `json:"closed_branches"`
+	OpenBranches   int `json:"open_branches"`
+	MaxDepth       int `json:"max_depth"` // Formulas on the longest branch
+
+	// Expansions count rule applications: subjoining a formula's
+	// inferences to 3 leaf nodes counts as 3 expansions.
+	AlphaExpansions       int `json:"alpha_expansions"`
+	BetaExpansions        int `json:"beta_expansions"`
+	GammaExpansions       int `json:"gamma_expansions"`
+	DeltaExpansions       int `json:"delta_expansions"`
+	NuExpansions          int `json:"nu_expansions"`
+	PiExpansions          int `json:"pi_expansions"`
+	NegationExpansions    int `json:"negation_expansions"`
+	EquivalenceExpansions int `json:"equivalence_expansions"`
+	ClosureChecks         int `json:"closure_checks"` // New formulas checked for contradicting their branch
+
+	Allocated uint64        `json:"bytes_allocated"`
+	WallTime  time.Duration `json:"wall_time_ns"`
+}
+
+// counts is what a proof search counts as it goes, as opposed
+// to what a look at the finished tableau can find out.
+type counts struct {
+	alpha, beta, gamma, delta int
+	nu, pi                    int
+	negation, equivalence     int
+	closureChecks             int
+}
+
+// expanded counts an application of rule to a formula with connective op.
+// Negation and equivalence get counted apart from other α and β rules.
+func (c *counts) expanded(op lexer.TokenType, rule Rule) {
+	switch {
+	case op == lexer.NOT:
+		c.negation++
+	case op == lexer.EQUIV:
+		c.equivalence++
+	case rule.Type == Beta:
+		c.beta++
+	case rule.Type == Gamma:
+		c.gamma++
+	case rule.Type == Delta:
+		c.delta++
+	case rule.Type == Nu:
+		c.nu++
+	case rule.Type == Pi:
+		c.pi++
+	default:
+		c.alpha++
+	}
+}
+
+// fill puts counts c into stats.
+func (c counts) fill(stats *Stats) {
+	stats.AlphaExpansions = c.alpha
+	stats.BetaExpansions = c.beta
+	stats.GammaExpansions = c.gamma
+	stats.DeltaExpansions = c.delta
+	stats.NuExpansions = c.nu
+	stats.PiExpansions = c.pi
+	stats.NegationExpansions = c.negation
+	stats.EquivalenceExpansions = c.equivalence
+	stats.ClosureChecks = c.closureChecks
+}
+
+// Stats walks the tableau rooted at root, counting formulas and
+// branches, and adds what got counted while building it.
+func (root *Tnode) Stats() Stats {
+	var stats Stats
+	for _, leaf := range root.FindLeaves() {
+		stats.Branches++
+		if leaf.closed {
+			stats.ClosedBranches++
+		} else if !leaf.unexplored {
+			stats.OpenBranches++
+		}
+		if leaf.depth > stats.MaxDepth {
+			stats.MaxDepth = leaf.depth
+		}
+	}
+	var count func(n *Tnode)
+	count = func(n *Tnode) {
+		if n != nil {
+			stats.Nodes++
+			count(n.Left)
+			count(n.Right)
+		}
+	}
+	count(root)
+	root.tableau.counts.fill(&stats)
+	return stats
+}
+
+// PrintStats writes stats on w in a human readable form.
+func PrintStats(w io.Writer, stats Stats) {
+	fmt.Fprintf(w, "Statistics:\n")
+	fmt.Fprintf(w, "\t%d formulas, longest branch %d formulas\n", stats.Nodes, stats.MaxDepth)
+	fmt.Fprintf(w, "\t%d branches, %d closed, %d open\n", stats.Branches, stats.ClosedBranches, stats.OpenBranches)
+	fmt.Fprintf(w, "\texpansions: %d α, %d β, %d negation, %d equivalence\n",
+		stats.AlphaExpansions, stats.BetaExpansions, stats.NegationExpansions, stats.EquivalenceExpansions)
+	if stats.GammaExpansions+stats.DeltaExpansions > 0 {
+		fmt.Fprintf(w, "\tquantifier expansions: %d γ, %d δ\n", stats.GammaExpansions, stats.DeltaExpansions)
+	}
+	if stats.NuExpansions+stats.PiExpansions > 0 {
+		fmt.Fprintf(w, "\tmodal expansions: %d ν, %d π\n", stats.NuExpansions, stats.PiExpansions)
+	}
+	fmt.Fprintf(w, "\t%d closure checks\n", stats.ClosureChecks)
+	fmt.Fprintf(w, "\t%d bytes allocated, wall time %v\n", stats.Allocated, stats.WallTime)
+}
@@ -0,0 +1,48 @@
+package tableaux
+
+import (
+	"context"
+	"testing"
+)
+
+// Problems and their finished tableaux's stats, without Allocated
+// and WallTime, which depend on the machine.
+var statsTests = []struct {
+	formulas []string
+	opts     Options
+	stats    Stats
+}{
+	{[]string{"p > q"}, Options{}, Stats{
+		Nodes: 3, Branches: 1, OpenBranches: 1, MaxDepth: 3,
+		AlphaExpansions: 1, ClosureChecks: 2,
+	}},
+	{[]string{"p > q", "q > r", "p > r"}, Options{}, Stats{
+		Nodes: 13, Branches: 4, ClosedBranches: 4, MaxDepth: 7,
+		AlphaExpansions: 3, BetaExpansions: 3, ClosureChecks: 12,
+	}},
+	{[]string{"(p = q) > ~~(q = p)"}, Options{}, Stats{
+		Nodes: 17, Branches: 4, ClosedBranches: 4, MaxDepth: 9,
+		AlphaExpansions: 1, NegationExpansions: 4, EquivalenceExpansions: 3, ClosureChecks: 16,
+	}},
+	{[]string{"forall x.P(x) > P(c)"}, Options{}, Stats{
+		Nodes: 4, Branches: 1, ClosedBranches: 1, MaxDepth: 4,
+		AlphaExpansions: 1, GammaExpansions: 1, ClosureChecks: 3,
+	}},
+	{[]string{"[](p > q) > ([]p > []q)"}, Options{Modal: SystemK}, Stats{
+		Nodes: 10, Branches: 2, ClosedBranches: 2, MaxDepth: 9,
+		AlphaExpansions: 2, BetaExpansions: 1, NuExpansions: 2, PiExpansions: 1, ClosureChecks: 9,
+	}},
+}
+
+func TestStats(t *testing.T) {
+	for _, test := range statsTests {
+		root, _ := Setup(parseAll(t, test.formulas), test.opts)
+		root.ExpandContext(context.Background(), Limits{MaxInstances: DefaultMaxInstances})
+		if stats := root.Stats(); stats != test.stats {
+			t.Errorf("%q: %+v, want %+v", test.formulas, stats, test.stats)
+		}
+		if stats, size := root.Stats(), root.Size(); stats.Nodes != size.Nodes || stats.MaxDepth != size.Depth {
+			t.Errorf("%q: stats %+v, size %+v", test.formulas, stats, size)
+		}
+	}
+}
@@ -6,7 +6,10 @@ package tableaux
 // "Logical Labyrinths", CRC Press, 2009, chapter 11
 // "First Order Logic", Dover, xxxx, chapter N
 // for essentially the same explanation with slight variations.
-// This does signed tableaux.
+// This does signed tableaux, and unsigned tableaux by way of signed
+// ones: in an unsigned tableau, ~X acts as F: X, and any other X as T: X.
+// Modal tableaux follow Fitting, "Proof Methods for Modal and Intuitionistic
+// Logics", Reidel, 1983: every formula has a prefix naming a possible world.
 
 import (
 	"fmt"
@@ -14,6 +17,7 @@ import (
 
 	"tableaux-in-go/src/lexer"
 	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/truthtable"
 )
 
 // Tnode instances make up a tableau, one subexpression per Tnode
@@ -23,62 +27,222 @@ type Tnode struct {
 	LineNumber int
 	Sign       bool
 	Tree       *node.Node
-	Expression string // element Tree as a string.
+	Expression string         // element Tree as a string, or ~Tree for F in an unsigned tableau.
+	World      string         // Prefix of a formula in a modal tableau, like 1.2, "" otherwise.
+	Values     truthtable.Set // Sign of a formula in a many-signed tableau, 0 otherwise.
+	formula    string         // World and element Tree as a string, for finding contradictions.
 
 	// Changed during subjoining inferences, and initial setup.
 	Parent *Tnode
 	Left   *Tnode
 	Right  *Tnode
 
-	Used   bool // Have interence(s) of this expression been subjoined to leaf nodes?
-	closed bool // Does this expression contradict a predecessor in the tableau?
+	Used       bool // Have interence(s) of this expression been subjoined to leaf nodes?
+	closed     bool // Does this expression contradict a predecessor in the tableau?
+	unexplored bool // Does this leaf end a branch depth-first search never got to?
 
 	// Other nodes in tableau special to this one
 	Contradictory *Tnode
 	inferredFrom  *Tnode
+
+	depth int // Number of Tnodes on the branch from the root down to this one
+
+	// Shared by all Tnodes of a tableau.
+	tableau *tableau
 }
 
-var serialNumber int
+// Options say what kind of tableau to build.
+type Options struct {
+	Unsigned bool             // Unsigned tableau, closing branches on X and ~X
+	Regular  bool             // Never subjoin a formula already on the branch
+	Modal    System           // Modal logic for formulas with □ and ◇, which need one
+	Logic    truthtable.Logic // Three-valued logic, for a many-signed tableau
+	QBF      bool             // Quantifiers bind propositional identifiers
+}
+
+// tableau holds what all the Tnodes of a single tableau share. It hands
+// out line numbers, counting up from 0, so that separate tableaux can get
+// built at the same time.
+type tableau struct {
+	next     int
+	opts     Options
+	saved    int // Formulas a regular tableau didn't subjoin
+	branches int // Leaf nodes, closed or open
+	maxDepth int // Tnodes on the longest branch
+	counts   counts
+
+	skipped []*Tnode // Problem formulas Start didn't subjoin, the branch closed already
+
+	names      map[string]bool // Every identifier in the tableau, for making up parameters
+	parameters int             // Parameters made up so far
+	rounds     bool            // Has γ, ν or π formulas, that ExpandContext applies in rounds
+}
+
+func (t *tableau) number() int {
+	n := t.next
+	t.next++
+	return n
+}
 
 // New should constitute the only way to create a Tnode instance.
+// A Tnode with a nil parent starts a new signed tableau, with its own
+// line numbers.
 func New(tree *node.Node, sign bool, parent *Tnode) *Tnode {
+	if parent == nil {
+		return Root(tree, sign, Options{})
+	}
+	return newTnode(tree, sign, parent, parent.tableau, parent.World)
+}
+
+// Root starts a new tableau, of the kind opts describes, with its own
+// line numbers. In an unsigned tableau, sign false means ~tree.
+func Root(tree *node.Node, sign bool, opts Options) *Tnode {
+	world := ""
+	if opts.Modal != NonModal {
+		world = "1"
+	}
+	return newTnode(tree, sign, nil, &tableau{opts: opts, branches: 1, names: make(map[string]bool)}, world)
+}
+
+func newTnode(tree *node.Node, sign bool, parent *Tnode, t *tableau, world string) *Tnode {
 	r := &Tnode{
-		LineNumber: serialNumber,
-		Tree:       tree,
+		LineNumber: t.number(),
+		World:      world,
 		Parent:     parent,
-		Sign:       sign,
-		Expression: node.ExpressionToString(tree),
+		tableau:    t,
+	}
+	r.setFormula(tree, sign)
+	r.setDepth()
+	return r
+}
+
+func (n *Tnode) setDepth() {
+	n.depth = 1
+	if n.Parent != nil {
+		n.depth = n.Parent.depth + 1
 	}
+	if n.depth > n.tableau.maxDepth {
+		n.tableau.maxDepth = n.depth
+	}
+}
 
-	if tree.Op == lexer.IDENT {
-		r.Used = true // No inferences to make from an identifier.
+// setFormula fills in a Tnode's signed formula. An unsigned tableau
+// keeps ~X as F: X, so that it can use the signed tableau rules.
+func (n *Tnode) setFormula(tree *node.Node, sign bool) {
+	unsigned := n.tableau.opts.Unsigned
+	tree, sign = n.tableau.normalize(tree, sign)
+
+	n.Tree = tree
+	n.Sign = sign
+	if logic := n.tableau.opts.Logic; logic != truthtable.Classical {
+		n.Values = logic.Designated()
+		if !sign {
+			n.Values = truthtable.All &^ n.Values
+		}
+	}
+	n.Expression = node.ExpressionToString(tree)
+	n.formula = prefixed(n.World, n.Expression)
+	if unsigned && !sign {
+		not := node.NewOpNode(lexer.NOT)
+		not.Left = tree
+		n.Expression = node.ExpressionToString(not)
 	}
-	serialNumber++
 
-	return r
+	node.Names(tree, n.tableau.names)
+
+	// No inferences to make from an identifier. γ, ν and π formulas
+	// get applied in rounds instead.
+	rule, ok := n.tableau.rule(tree.Op, sign)
+	inRounds := ok && (rule.Type == Gamma || rule.Type == Nu || rule.Type == Pi)
+	n.Used = tree.Op == lexer.IDENT || inRounds
+	if inRounds {
+		n.tableau.rounds = true
+	}
+}
+
+// prefixed gives back formula with world in front of it,
+// if there is a world.
+func prefixed(world, formula string) string {
+	if world == "" {
+		return formula
+	}
+	return world + " " + formula
+}
+
+// normalize gives back the signed formula that a Tnode of tableau t
+// would have for tree signed sign.
+func (t *tableau) normalize(tree *node.Node, sign bool) (*node.Node, bool) {
+	if t.opts.Unsigned && sign && tree.Op == lexer.NOT {
+		return tree.Left, false
+	}
+	return tree, sign
+}
+
+// Unsigned returns true if n is part of an unsigned tableau.
+func (n *Tnode) Unsigned() bool {
+	return n.tableau.opts.Unsigned
+}
+
+// signedExpression gives back the formula of n the way output shows
+// it: "F: p & q" in a signed tableau, "~(p & q)" in an unsigned one,
+// "{U,F}: p & q" in a many-signed one.
+func (n *Tnode) signedExpression() string {
+	if n.Unsigned() {
+		return prefixed(n.World, n.Expression)
+	}
+	if n.Values != 0 {
+		return n.Values.String() + ": " + n.Expression
+	}
+	if n.Sign {
+		return prefixed(n.World, "T: "+n.Expression)
+	}
+	return prefixed(n.World, "F: "+n.Expression)
 }
 
 // FindUnclosedLeaf - Find all unclosed leaf node(s) below the receiver in
 // a tableau. Leaf might be marked "used" if it's just an identifier,
-// also this can return zero-len array if all leaf nodes marked closed
+// also this can return zero-len array if all leaf nodes marked closed.
+// Leaves of branches depth-first search never explored aren't open.
 func (n *Tnode) FindUnclosedLeaf() []*Tnode {
-	var a []*Tnode
+	return n.unclosedLeaves(nil)
+}
+
+// unclosedLeaves appends the unclosed leaf nodes below n to a.
+func (n *Tnode) unclosedLeaves(a []*Tnode) []*Tnode {
+	if n.Left == nil && n.Right == nil && !n.closed && !n.unexplored {
+		a = append(a, n)
+	}
+	if n.Left != nil {
+		a = n.Left.unclosedLeaves(a)
+	}
+	if n.Right != nil {
+		a = n.Right.unclosedLeaves(a)
+	}
+	return a
+}
+
+// FindLeaves finds all leaf nodes below the receiver in a tableau,
+// closed or not.
+func (n *Tnode) FindLeaves() []*Tnode {
 	if n.Left == nil && n.Right == nil {
-		if !n.closed {
-			a = append(a, n)
-		}
+		return []*Tnode{n}
 	}
+	var a []*Tnode
 	if n.Left != nil {
-		t := n.Left.FindUnclosedLeaf()
-		a = append(a, t...)
+		a = append(a, n.Left.FindLeaves()...)
 	}
 	if n.Right != nil {
-		t := n.Right.FindUnclosedLeaf()
-		a = append(a, t...)
+		a = append(a, n.Right.FindLeaves()...)
 	}
 	return a
 }
 
+// Closed returns true if the receiver is the leaf node of a branch
+// closed by a contradiction.
+func (n *Tnode) Closed() bool {
+	return n.closed
+}
+
 // FindTallestUnused finds a formula which has not had its inferences subjoined
 // above an unclosed leaf node by following the Tnode.Parent links up a branch.
 func (n *Tnode) FindTallestUnused() *Tnode {
@@ -102,10 +266,38 @@ func (n *Tnode) FindTallestUnused() *Tnode {
 // instance n by following Tnode.Parent links all the way up a branch of a
 // tableau Not recursive, so the receiver n is the expression possibly
 // contradicted by
-// element further back up the tableau branch.
+// element further back up the tableau branch. In intuitionistic logic,
+// T: X contradicts F: X in any world that T: X's world sees. In a
+// many-signed tableau, n contradicts the formula that leaves n's
+// signs on the branch with no value in common. T: ⊥ and F: ⊤, which
+// turn up in QBF tableaux, contradict themselves.
 func (n *Tnode) CheckForContradictions() bool {
+	n.tableau.counts.closureChecks++
+	if value, ok := n.Tree.Constant(); ok && value != n.Sign {
+		n.Contradictory = n
+		n.closed = true
+		return true
+	}
+	if n.tableau.opts.Logic != truthtable.Classical {
+		values := n.Values
+		for p := n.Parent; p != nil; p = p.Parent {
+			if p.formula == n.formula {
+				values &= p.Values
+			}
+			if values == 0 {
+				n.Contradictory = p
+				n.closed = true
+				return true
+			}
+		}
+		return false
+	}
+	intuitionistic := n.tableau.opts.Modal == Intuitionistic
 	for p := n.Parent; p != nil; p = p.Parent {
-		if n.Sign != p.Sign && n.Expression == p.Expression {
+		if n.Sign == p.Sign {
+			continue
+		}
+		if n.formula == p.formula || intuitionistic && n.Expression == p.Expression && contradicts(n, p) {
 			n.Contradictory = p
 			n.closed = true
 			return true
@@ -114,207 +306,148 @@ func (n *Tnode) CheckForContradictions() bool {
 	return false
 }
 
-func (parent *Tnode) impliesBetaInference(from *Tnode) {
-	immediate := New(from.Tree.Left, false, parent)
-	parent.Left = immediate
-	immediate.inferredFrom = from
-
-	immediate.CheckForContradictions()
-
-	immediate2 := New(from.Tree.Right, true, parent)
-	parent.Right = immediate2
-	immediate2.inferredFrom = from
-
-	immediate2.CheckForContradictions()
-}
-
-func (parent *Tnode) betaInference(from *Tnode) {
-	immediate := New(from.Tree.Left, from.Sign, parent)
-	parent.Left = immediate
-	immediate.inferredFrom = from
-
-	immediate.CheckForContradictions()
-
-	immediate2 := New(from.Tree.Right, from.Sign, parent)
-	parent.Right = immediate2
-	immediate2.inferredFrom = from
-
-	immediate2.CheckForContradictions()
-}
-
-func (parent *Tnode) equivalenceInference(from *Tnode) {
-	var sign1, sign2, sign3, sign4 bool
-	if from.Sign {
-		sign1, sign2, sign3, sign4 = true, true, false, false
-	} else {
-		sign1, sign2, sign3, sign4 = true, false, false, true
-	}
-
-	immediate1 := New(from.Tree.Left, sign1, parent)
-	parent.Left = immediate1
-	immediate1.inferredFrom = from
-
-	if !immediate1.CheckForContradictions() {
-
-		immediate2 := New(from.Tree.Right, sign2, immediate1)
-		immediate1.Left = immediate2
-		immediate2.inferredFrom = from
-
-		immediate2.CheckForContradictions()
-	}
-
-	immediate3 := New(from.Tree.Left, sign3, parent)
-	parent.Right = immediate3
-	immediate3.inferredFrom = parent
-
-	if !immediate3.CheckForContradictions() {
-
-		immediate4 := New(from.Tree.Right, sign4, immediate3)
-		immediate4.inferredFrom = parent
-		immediate3.Left = immediate4
-
-		immediate4.CheckForContradictions()
-	}
-}
-
-func (parent *Tnode) alphaInference(from *Tnode) {
-	immediate := New(from.Tree.Left, from.Sign, parent)
-	immediate.inferredFrom = from
-	parent.Left = immediate
-
-	// Check 1st inference for contradictions, don't bother subjoining 2nd inference
-	// if 1st one has a contradction and closes the branch.
-	if !immediate.CheckForContradictions() {
-
-		immediate2 := New(from.Tree.Right, from.Sign, immediate)
-		immediate2.inferredFrom = from
-		immediate.Left = immediate2
-
-		immediate2.CheckForContradictions()
+// ruleName names the type of rule that AddInferences applies
+// to signed formula from, α or β.
+func ruleName(from *Tnode) string {
+	if from.tableau.opts.Logic != truthtable.Classical {
+		return valuedRuleType(from.valuedRule()).String()
 	}
-}
-
-// Material implication causes a special case: F: p>q means that T:p and
-// F:q get subjoined, preventing the general alpha-type code above from
-// working.
-func (parent *Tnode) implicationSpecialInference(from *Tnode) {
-	parent.Left = New(from.Tree.Left, true, parent)
-	parent.Left.inferredFrom = from
-	if !parent.Left.CheckForContradictions() {
-
-		parent.Left.Left = New(from.Tree.Right, false, parent.Left)
-		parent.Left.Left.inferredFrom = from
-		parent.Left.Left.CheckForContradictions()
+	rule, ok := from.tableau.rule(from.Tree.Op, from.Sign)
+	if !ok {
+		return ""
 	}
+	return rule.Type.String()
 }
 
-func (parent *Tnode) negationInference(from *Tnode) {
-	immediate := New(from.Tree.Left, !from.Sign, parent)
-	immediate.inferredFrom = from
-	parent.Left = immediate
-
-	parent.Left.CheckForContradictions()
-}
-
-// AddInferences subjoins inferences of from to Tnode instance named parent.
+// AddInferences subjoins inferences of from to Tnode instance named parent,
+// following the rule for from's connective and sign.
 func (parent *Tnode) AddInferences(from *Tnode) {
 
 	if from.Tree.Op == lexer.IDENT {
 		return
 	}
-
-	// Smullyan's beta-type, and logical equivalence. These create
-	// bifurcations in branches, so do them first. Alpha-type inferences,
-	// which just linearly extend a branch, push inferences down the branch.
-	// By doing beta-type, bifurcating inferences first, branches are made
-	// linearly as long as possible.
-
-	// Did two calls to betaInference() to avoid unweildy,
-	// unreadable conditions on the "if"
-	if (from.Tree.Op == lexer.AND && !from.Sign) || (from.Tree.Op == lexer.OR && from.Sign) {
-		parent.betaInference(from)
+	if parent.tableau.opts.Logic != truthtable.Classical {
+		parent.manyValued(from)
 		return
 	}
 
-	if from.Tree.Op == lexer.IMPLIES && from.Sign {
-		parent.impliesBetaInference(from)
-		return
+	rule, ok := parent.tableau.rule(from.Tree.Op, from.Sign)
+	if !ok {
+		// Don't think it should ever get here.
+		errString := fmt.Sprintf("Trying to add inferences of %v:%q to leaf node %v:%q\n", from.Sign, from.Expression, parent.Sign, parent.Expression)
+		panic(errString)
 	}
 
-	// Not actually a beta-type, and Smullyan probably would rather
-	// define equivalence as an abbreviation. It does create a new
-	// bifurcation in a branch, however.
-	if from.Tree.Op == lexer.EQUIV {
-		parent.equivalenceInference(from)
+	switch rule.Type {
+	case Gamma:
+		terms := parent.branchTerms()
+		if len(terms) == 0 {
+			terms = append(terms, parent.tableau.parameter())
+		}
+		parent.instantiate(from, terms, 0)
 		return
-	}
-
-	// Smullyan's alpha-type inferences. These just extend a branch, without bifurcating it.
-	// For AND, OR, IMPLIES alpha-type inferences, add the 2nd of two inferences immediately
-	// below the parent. Combined with doing beta-type, bifurcating inferencese first, this
-	// keeps the branch linear for as long as possible.
-
-	if from.Tree.Op == lexer.NOT {
-		parent.negationInference(from)
+	case Delta:
+		parent.tableau.counts.expanded(from.Tree.Op, rule)
+		c := rule.Components[0][0]
+		immediate := parent.infer(from, c.instance(from.Tree, parent.tableau.parameter()), c.Sign, from.World)
+		parent.Left = immediate
+		immediate.CheckForContradictions()
 		return
-	}
-
-	if (from.Tree.Op == lexer.AND && from.Sign) || (from.Tree.Op == lexer.OR && !from.Sign) {
-		parent.alphaInference(from)
+	case Nu:
+		parent.necessity(from)
+		return
+	case Pi:
+		parent.possibility(from)
 		return
 	}
 
-	// Material implication alpha inference needs to short-circuit
-	// subjoining one of the two terms in some cases.
-	if from.Tree.Op == lexer.IMPLIES && !from.Sign {
-		parent.implicationSpecialInference(from)
+	regular := parent.tableau.opts.Regular
+	if regular && parent.subsumes(from, rule) {
+		for _, components := range rule.Components {
+			parent.tableau.saved += len(components)
+		}
 		return
 	}
+	parent.tableau.counts.expanded(from.Tree.Op, rule)
+
+	// Components of an α rule, or of one branch of a β rule, go one
+	// below the other. Don't bother subjoining more components once one
+	// has a contradiction and closes the branch.
+	for i, components := range rule.Components {
+		p := parent
+		for _, c := range components {
+			operand := c.of(from.Tree)
+			if regular && p.onBranch(from.World, operand, c.Sign) {
+				parent.tableau.saved++
+				continue
+			}
+			immediate := p.infer(from, operand, c.Sign, from.World)
+			if i == 1 && p == parent {
+				parent.Right = immediate // 2nd branch of a β rule
+				parent.tableau.branches++
+			} else {
+				p.Left = immediate
+			}
+			p = immediate
 
-	// Don't think it should ever get here.
-	errString := fmt.Sprintf("Trying to add inferences of %v:%q to leaf node %v:%q\n", from.Sign, from.Expression, parent.Sign, parent.Expression)
-	panic(errString)
+			if immediate.CheckForContradictions() {
+				break
+			}
+		}
+	}
 }
 
-// Do the actual work of writing GraphViz digraph output to io.Writer w.
-// Another traverse of a tableau (binary tree of *Tnode instances),
-// with semantic irregularities causing some inorder and some postorder
-// operations.
-// The Tnode.Parent backlink can help in debugging.
-func (n *Tnode) graphTnode(w io.Writer) {
-	sign := "F"
-	if n.Sign {
-		sign = "T"
-	}
+// infer creates an inference of premise from, tree signed sign with
+// prefix world, as a child of n. The caller makes it n's Left or Right.
+func (n *Tnode) infer(from *Tnode, tree *node.Node, sign bool, world string) *Tnode {
+	immediate := newTnode(tree, sign, n, n.tableau, world)
+	immediate.inferredFrom = from
+	return immediate
+}
 
-	// Append a string to the formula, include 'U' for a formula
-	// whose inferences got subjoined to all it's leaf nodes,
-	// and 'C' for the leaf node of a closed branch.
-	extra := ""
-	if n.Used {
-		extra += "U"
-	}
-	if n.closed {
-		extra += "C"
+// onBranch returns true if tree signed sign, with prefix world, already
+// appears on the branch from n up to the root of the tableau. In
+// intuitionistic logic, a T: formula in a world that sees world counts.
+func (n *Tnode) onBranch(world string, tree *node.Node, sign bool) bool {
+	tree, sign = n.tableau.normalize(tree, sign)
+	expression := node.ExpressionToString(tree)
+	formula := prefixed(world, expression)
+	inherits := sign && n.tableau.opts.Modal == Intuitionistic
+	for p := n; p != nil; p = p.Parent {
+		if p.Sign != sign {
+			continue
+		}
+		if p.formula == formula || inherits && p.Expression == expression && Intuitionistic.sees(p.World, world) {
+			return true
+		}
 	}
+	return false
+}
 
-	fmt.Fprintf(w, "n%p [label=\"%s: %s%s\"];\n", n, sign, n.Expression, ", "+extra)
-
-	if n.Left != nil {
-		n.Left.graphTnode(w)
-		fmt.Fprintf(w, "n%p -> n%p;\n", n, n.Left)
-	}
-	if n.Right != nil {
-		n.Right.graphTnode(w)
-		fmt.Fprintf(w, "n%p -> n%p;\n", n, n.Right)
+// subsumes returns true if the branch ending at leaf n already has all
+// the components of from's α rule, or all the components of one branch
+// of from's β rule: applying the rule would add nothing new to n's
+// branch, or to one of the new branches.
+func (n *Tnode) subsumes(from *Tnode, rule Rule) bool {
+	for _, components := range rule.Components {
+		all := true
+		for _, c := range components {
+			if !n.onBranch(from.World, c.of(from.Tree), c.Sign) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
 	}
+	return false
 }
 
-// GraphTnode writes GraphViz directed graph dot input to argument w io.Writer.
-func (n *Tnode) GraphTnode(w io.Writer) {
-	fmt.Fprintf(w, "digraph g {\n")
-	n.graphTnode(w)
-	fmt.Fprintf(w, "}\n")
+// Saved gives back the number of formulas a regular tableau didn't
+// subjoin because they already appeared on their branches.
+func (n *Tnode) Saved() int {
+	return n.tableau.saved
 }
 
 // AppendLeaf appends argument n *Tnode to the leaf node of receiver p in a
@@ -328,6 +461,14 @@ func (n *Tnode) AppendLeaf(v *Tnode) {
 	}
 	leaf.Left = v
 	v.Parent = leaf
+	if v.tableau != leaf.tableau {
+		// v started out as the root of its own tableau
+		v.tableau = leaf.tableau
+		v.LineNumber = v.tableau.number()
+		v.World = leaf.World
+		v.setFormula(v.Tree, v.Sign)
+	}
+	v.setDepth()
 }
 
 // PrintTnode writes a Tnode instance's elements to stdout
@@ -368,13 +509,23 @@ func PrintTableaux(w io.Writer, root *Tnode) {
 		for p != nil {
 			var inferenceNote string
 			if p.inferredFrom != nil {
-				inferenceNote = fmt.Sprintf(" (%d)", p.inferredFrom.LineNumber)
+				inferenceNote = fmt.Sprintf(" (%d, %s)", p.inferredFrom.LineNumber, ruleName(p.inferredFrom))
+			}
+			if p.Unsigned() {
+				fmt.Fprintf(w, "%d. %s%s", p.LineNumber, prefixed(p.World, p.Expression), inferenceNote)
+			} else if p.Values != 0 {
+				fmt.Fprintf(w, "%d. %v: %s%s", p.LineNumber, p.Values, p.Expression, inferenceNote)
+			} else {
+				fmt.Fprintf(w, "%d. %s%s", p.LineNumber, prefixed(p.World, fmt.Sprintf("%v: %s", p.Sign, p.Expression)), inferenceNote)
 			}
-			fmt.Fprintf(w, "%d. %v: %s%s", p.LineNumber, p.Sign, p.Expression, inferenceNote)
-			if p.closed {
+			if p.closed && p.Contradictory == p {
+				fmt.Fprintf(w, " contradicts itself\n")
+			} else if p.closed {
 				fmt.Fprintf(w, " contradicts %d\n", p.Contradictory.LineNumber)
 			}
-			if p.Left == nil && p.Right == nil && !p.closed {
+			if p.unexplored {
+				fmt.Fprintf(w, " unexplored branch\n")
+			} else if p.Left == nil && p.Right == nil && !p.closed {
 				fmt.Fprintf(w, " open branch\n")
 			}
 			fmt.Fprintf(w, "\n")
@@ -0,0 +1,262 @@
+package truthtable
+
+// Quantified boolean formulas. ∀p X is X with p true and X with p
+// false, ∃p X is X with p true or X with p false, so a truth table
+// can expand quantifiers away. A closed QBF, one without free
+// identifiers, is just true or false.
+//
+// A true QBF has Skolem functions for its variables in existential
+// position, ∃ under an even number of negations, or ∀ under an odd
+// number: a value for each variable that keeps the formula true, given
+// the values of the variables in universal position outside it.
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+)
+
+// evaluateInstances evaluates what quantifier n quantifies with
+// n's variable true, then with it false.
+func evaluateInstances(n *node.Node, valuation map[string]bool) (t, f bool) {
+	restore := bind(valuation, n.Ident, true)
+	t = Evaluate(n.Left, valuation)
+	valuation[n.Ident] = false
+	f = Evaluate(n.Left, valuation)
+	restore()
+	return t, f
+}
+
+// bind gives variable value in valuation, giving back a func
+// that puts back whatever value variable had before.
+func bind(valuation map[string]bool, variable string, value bool) (restore func()) {
+	old, had := valuation[variable]
+	valuation[variable] = value
+	return func() {
+		if had {
+			valuation[variable] = old
+		} else {
+			delete(valuation, variable)
+		}
+	}
+}
+
+// Binding is a value for a quantified variable.
+type Binding struct {
+	Variable string
+	Value    bool
+}
+
+// SkolemRow gives the value of a Skolem function for values of the
+// variables in universal position it depends on.
+type SkolemRow struct {
+	Universals map[string]bool `json:"universals"`
+	Value      bool            `json:"value"`
+}
+
+// Skolem is the table of a Skolem function for Variable. Rows have
+// values for Universals, outermost first. A row can lack some of them
+// if a variable is in universal position for some values of outer
+// variables, and in existential position for others, as equivalences
+// can make it.
+type Skolem struct {
+	Variable   string      `json:"variable"`
+	Universals []string    `json:"universals"`
+	Rows       []SkolemRow `json:"rows"`
+}
+
+// Print writes the table of s, one column per variable, like a
+// truth table, a "-" where a row lacks a value.
+func (s *Skolem) Print(w io.Writer) {
+	fmt.Fprintf(w, "Skolem function for %s:\n", s.Variable)
+	for _, variable := range s.Universals {
+		fmt.Fprintf(w, "%5s ", variable)
+	}
+	fmt.Fprintf(w, "%5s\n", s.Variable)
+	for _, row := range s.Rows {
+		for _, variable := range s.Universals {
+			if value, ok := row.Universals[variable]; ok {
+				fmt.Fprintf(w, "%5v ", value)
+			} else {
+				fmt.Fprintf(w, "%5s ", "-")
+			}
+		}
+		fmt.Fprintf(w, "%5v\n", row.Value)
+	}
+}
+
+// Strategy collects the values a decision procedure chooses for
+// variables in existential position, into Skolem functions.
+type Strategy struct {
+	tables map[string]*Skolem
+}
+
+// NewStrategy creates a Strategy without any choices yet.
+func NewStrategy() *Strategy {
+	return &Strategy{tables: make(map[string]*Skolem)}
+}
+
+// Choose records value for variable, when the variables in universal
+// position outside it have the values universals. The first choice
+// for the same universals sticks.
+func (s *Strategy) Choose(variable string, universals []Binding, value bool) {
+	table, ok := s.tables[variable]
+	if !ok {
+		table = &Skolem{Variable: variable}
+		s.tables[variable] = table
+	}
+	row := SkolemRow{Universals: make(map[string]bool), Value: value}
+	for _, b := range universals {
+		if _, ok := row.Universals[b.Variable]; !ok && !table.has(b.Variable) {
+			table.Universals = append(table.Universals, b.Variable)
+		}
+		row.Universals[b.Variable] = b.Value
+	}
+	for _, r := range table.Rows {
+		if sameValues(r.Universals, row.Universals) {
+			return
+		}
+	}
+	table.Rows = append(table.Rows, row)
+}
+
+func (s *Skolem) has(variable string) bool {
+	for _, v := range s.Universals {
+		if v == variable {
+			return true
+		}
+	}
+	return false
+}
+
+func sameValues(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for variable, value := range a {
+		if other, ok := b[variable]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Skolem gives back the Skolem functions s has choices for, in the
+// order their quantifiers appear in formulas trees. Rows go in truth
+// table order, true before false, outermost variable first.
+func (s *Strategy) Skolem(trees ...*node.Node) []*Skolem {
+	var variables []string
+	for _, tree := range trees {
+		variables = quantified(tree, variables)
+	}
+	var functions []*Skolem
+	for _, variable := range variables {
+		table, ok := s.tables[variable]
+		if !ok {
+			continue
+		}
+		delete(s.tables, variable) // Renamed apart, but just in case.
+		sort.SliceStable(table.Rows, func(i, j int) bool {
+			return table.before(table.Rows[i], table.Rows[j])
+		})
+		functions = append(functions, table)
+	}
+	return functions
+}
+
+// before returns true if row a goes before row b.
+func (s *Skolem) before(a, b SkolemRow) bool {
+	for _, variable := range s.Universals {
+		x, xok := a.Universals[variable]
+		y, yok := b.Universals[variable]
+		if xok != yok {
+			return xok
+		}
+		if x != y {
+			return x
+		}
+	}
+	return false
+}
+
+// quantified appends the variables of tree's quantifiers to
+// variables, outermost first.
+func quantified(tree *node.Node, variables []string) []string {
+	if lexer.Quantifier(tree.Op) {
+		variables = append(variables, tree.Ident)
+	}
+	if tree.Left != nil {
+		variables = quantified(tree.Left, variables)
+	}
+	if tree.Right != nil {
+		variables = quantified(tree.Right, variables)
+	}
+	return variables
+}
+
+// SkolemFunctions gives back Skolem functions for the variables in
+// existential position of root, a closed QBF with its variables
+// renamed apart, if root is true. It finds them expanding quantifiers
+// the way Evaluate does, keeping the first value that works.
+func SkolemFunctions(root *node.Node) []*Skolem {
+	valuation := make(map[string]bool)
+	if !Evaluate(root, valuation) {
+		return nil
+	}
+	strategy := NewStrategy()
+	witness(root, true, valuation, nil, strategy)
+	return strategy.Skolem(root)
+}
+
+// witness chooses values for variables in existential position of n,
+// which has value want under valuation, that keep it that way. The
+// variables in universal position outside n have values universals.
+func witness(n *node.Node, want bool, valuation map[string]bool, universals []Binding, strategy *Strategy) {
+	switch n.Op {
+	case lexer.NOT:
+		witness(n.Left, !want, valuation, universals, strategy)
+	case lexer.AND, lexer.OR:
+		// Both operands have the value want, or one of them
+		// does and that does it.
+		if want == (n.Op == lexer.AND) {
+			witness(n.Left, want, valuation, universals, strategy)
+			witness(n.Right, want, valuation, universals, strategy)
+		} else if Evaluate(n.Left, valuation) == want {
+			witness(n.Left, want, valuation, universals, strategy)
+		} else {
+			witness(n.Right, want, valuation, universals, strategy)
+		}
+	case lexer.IMPLIES:
+		if !want {
+			witness(n.Left, true, valuation, universals, strategy)
+			witness(n.Right, false, valuation, universals, strategy)
+		} else if !Evaluate(n.Left, valuation) {
+			witness(n.Left, false, valuation, universals, strategy)
+		} else {
+			witness(n.Right, true, valuation, universals, strategy)
+		}
+	case lexer.EQUIV:
+		witness(n.Left, Evaluate(n.Left, valuation), valuation, universals, strategy)
+		witness(n.Right, Evaluate(n.Right, valuation), valuation, universals, strategy)
+	case lexer.FORALL, lexer.EXISTS:
+		if (n.Op == lexer.FORALL) == want {
+			// Universal position: every value has to work.
+			universals = universals[:len(universals):len(universals)]
+			for _, value := range []bool{true, false} {
+				restore := bind(valuation, n.Ident, value)
+				witness(n.Left, want, valuation, append(universals, Binding{n.Ident, value}), strategy)
+				restore()
+			}
+			return
+		}
+		t, _ := evaluateInstances(n, valuation)
+		value := t == want
+		strategy.Choose(n.Ident, universals, value)
+		restore := bind(valuation, n.Ident, value)
+		witness(n.Left, want, valuation, universals, strategy)
+		restore()
+	}
+}
@@ -0,0 +1,65 @@
+package truthtable
+
+import (
+	"testing"
+
+	"tableaux-in-go/src/node"
+	"tableaux-in-go/src/parser"
+)
+
+func parseQBF(t *testing.T, formula string) *node.Node {
+	t.Helper()
+	tree, err := parser.ParseString(formula)
+	if err != nil {
+		t.Fatalf("%q: %v", formula, err)
+	}
+	return node.NewRenamer().Rename(tree)
+}
+
+// Closed QBFs, whether they're true, and the variables in existential
+// position that get Skolem functions if they are.
+var qbfTests = []struct {
+	formula string
+	value   bool
+	skolem  []string
+}{
+	{"Ap (p | ~p)", true, nil},
+	{"Ap p", false, nil},
+	{"Ep p", true, []string{"p"}},
+	{"Ap Eq (p = q)", true, []string{"q"}},
+	{"Eq Ap (p = q)", false, nil},
+	{"~Ap ~p", true, []string{"p"}},
+}
+
+func TestEvaluateQBF(t *testing.T) {
+	for _, test := range qbfTests {
+		tree := parseQBF(t, test.formula)
+		if value := Evaluate(tree, make(map[string]bool)); value != test.value {
+			t.Errorf("%q: %v, want %v", test.formula, value, test.value)
+		}
+		table := New(tree)
+		if len(table.Rows) != 1 || table.Rows[0].Result != test.value {
+			t.Errorf("%q: truth table should have a single row, %v", test.formula, test.value)
+		}
+	}
+}
+
+// A true QBF without variables in existential position has no Skolem
+// functions, same as a false one: truth comes from Evaluate.
+func TestSkolemFunctions(t *testing.T) {
+	for _, test := range qbfTests {
+		var variables []string
+		for _, s := range SkolemFunctions(parseQBF(t, test.formula)) {
+			variables = append(variables, s.Variable)
+		}
+		if len(variables) != len(test.skolem) {
+			t.Errorf("%q: Skolem functions for %v, want %v", test.formula, variables, test.skolem)
+			continue
+		}
+		for i := range variables {
+			if variables[i] != test.skolem[i] {
+				t.Errorf("%q: Skolem functions for %v, want %v", test.formula, variables, test.skolem)
+			}
+		}
+	}
+}
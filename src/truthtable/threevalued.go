@@ -0,0 +1,263 @@
+package truthtable
+
+// Three-valued logics: a formula can be true, false, or neither, U.
+// Every logic here agrees with classical logic when no identifier is U:
+//
+//	K3: strong Kleene, U unless the other operand decides the value
+//	B3: weak Kleene, or Bochvar, U if any operand is U
+//	L3: Łukasiewicz, strong Kleene except that U > U and U = U are T
+//	LP: Priest's Logic of Paradox, strong Kleene with U designated too
+//
+// A formula is valid in a logic if it has a designated value, T, or
+// in LP either T or U, under every valuation.
+
+import (
+	"fmt"
+	"strings"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+)
+
+// Value is a truth value of three-valued logic, ordered F < U < T.
+type Value int
+
+const (
+	False   Value = iota // F
+	Unknown              // U, neither true nor false
+	True                 // T
+)
+
+// Values has every truth value, in the order truth table rows count
+// down through them.
+var Values = []Value{True, Unknown, False}
+
+func (v Value) String() string {
+	switch v {
+	case True:
+		return "T"
+	case Unknown:
+		return "U"
+	}
+	return "F"
+}
+
+// MarshalText makes JSON show a value as T, U or F.
+func (v Value) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// Set is a set of truth values. Many-signed tableaux sign formulas
+// with sets: {T,U}: X says X is T or U.
+type Set uint8
+
+// All has every truth value.
+const All = Set(1<<False | 1<<Unknown | 1<<True)
+
+// SetOf gives back the set of values.
+func SetOf(values ...Value) Set {
+	var s Set
+	for _, v := range values {
+		s |= 1 << v
+	}
+	return s
+}
+
+// Has returns true if v is in s.
+func (s Set) Has(v Value) bool {
+	return s&(1<<v) != 0
+}
+
+// String gives back s like {T,U}, values in the order T, U, F.
+func (s Set) String() string {
+	var values []string
+	for _, v := range Values {
+		if s.Has(v) {
+			values = append(values, v.String())
+		}
+	}
+	return "{" + strings.Join(values, ",") + "}"
+}
+
+// MarshalText makes JSON show a set the way String does.
+func (s Set) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSet reads a set written the way String writes it.
+func ParseSet(text string) (Set, error) {
+	if !strings.HasPrefix(text, "{") || !strings.HasSuffix(text, "}") {
+		return 0, fmt.Errorf("%q is not a set of truth values", text)
+	}
+	var s Set
+	for _, name := range strings.Split(text[1:len(text)-1], ",") {
+		switch strings.TrimSpace(name) {
+		case "T":
+			s |= SetOf(True)
+		case "U":
+			s |= SetOf(Unknown)
+		case "F":
+			s |= SetOf(False)
+		case "":
+		default:
+			return 0, fmt.Errorf("%q is not a set of truth values", text)
+		}
+	}
+	return s, nil
+}
+
+// Logic picks the truth tables of the connectives.
+type Logic int
+
+const (
+	Classical    Logic = iota // Two-valued
+	StrongKleene              // K3
+	WeakKleene                // B3
+	Lukasiewicz               // L3
+	LP                        // Priest's Logic of Paradox
+)
+
+func (l Logic) String() string {
+	switch l {
+	case StrongKleene:
+		return "K3"
+	case WeakKleene:
+		return "B3"
+	case Lukasiewicz:
+		return "L3"
+	case LP:
+		return "LP"
+	}
+	return ""
+}
+
+// MarshalText makes JSON show a logic by name.
+func (l Logic) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// ParseLogic gives back the logic called name: "K3", "B3", "L3" or
+// "LP", in either case. An empty name means classical logic.
+func ParseLogic(name string) (Logic, error) {
+	switch strings.ToUpper(name) {
+	case "":
+		return Classical, nil
+	case "K3":
+		return StrongKleene, nil
+	case "B3":
+		return WeakKleene, nil
+	case "L3", "Ł3":
+		return Lukasiewicz, nil
+	case "LP":
+		return LP, nil
+	}
+	return Classical, fmt.Errorf("unknown three-valued logic %q, want K3, B3, L3 or LP", name)
+}
+
+// Designated gives back the values that count as true in l.
+func (l Logic) Designated() Set {
+	if l == LP {
+		return SetOf(True, Unknown)
+	}
+	return SetOf(True)
+}
+
+// Apply gives back the value of connective op in l, for operand
+// values a and b. Negation ignores b.
+func (l Logic) Apply(op lexer.TokenType, a, b Value) Value {
+	if l == WeakKleene && (a == Unknown || op != lexer.NOT && b == Unknown) {
+		return Unknown
+	}
+	switch op {
+	case lexer.NOT:
+		return True - a
+	case lexer.AND:
+		return min(a, b)
+	case lexer.OR:
+		return max(a, b)
+	case lexer.IMPLIES:
+		return l.implies(a, b)
+	case lexer.EQUIV:
+		return min(l.implies(a, b), l.implies(b, a))
+	}
+	panic(fmt.Sprintf("Problem with node type %s (%d): shouldn't get here\n", lexer.TokenName(op), op))
+}
+
+// implies is ~a | b, except in L3, where U > U is T.
+func (l Logic) implies(a, b Value) Value {
+	if l == Lukasiewicz {
+		return min(True, True-a+b)
+	}
+	return max(True-a, b)
+}
+
+// Evaluate finds the value of the parse tree rooted at n in l,
+// given values for its identifiers.
+func (l Logic) Evaluate(n *node.Node, valuation map[string]Value) Value {
+	switch n.Op {
+	case lexer.IDENT:
+		return valuation[n.Ident]
+	case lexer.NOT:
+		return l.Apply(n.Op, l.Evaluate(n.Left, valuation), False)
+	}
+	return l.Apply(n.Op, l.Evaluate(n.Left, valuation), l.Evaluate(n.Right, valuation))
+}
+
+// ThreeValuedRow holds one valuation of a formula's identifiers, in
+// the same order as ThreeValuedTable.Identifiers, and the formula's
+// value under it.
+type ThreeValuedRow struct {
+	Values []Value
+	Result Value
+}
+
+// ThreeValuedTable is a complete truth table for a formula
+// in a three-valued logic.
+type ThreeValuedTable struct {
+	Logic       Logic
+	Identifiers []string
+	Rows        []ThreeValuedRow
+}
+
+// NewThreeValued creates the truth table in logic l of the formula
+// whose parse tree is root. Rows start with every identifier T, and
+// count down through U to every identifier F, the rightmost
+// identifier varying fastest.
+func NewThreeValued(root *node.Node, l Logic) *ThreeValuedTable {
+	identifiers := Identifiers(root)
+	table := &ThreeValuedTable{Logic: l, Identifiers: identifiers}
+
+	counter := make([]int, len(identifiers)) // Indexes into Values
+	for {
+		vals := make([]Value, len(identifiers))
+		valuation := make(map[string]Value)
+		for idx, id := range identifiers {
+			vals[idx] = Values[counter[idx]]
+			valuation[id] = vals[idx]
+		}
+		table.Rows = append(table.Rows, ThreeValuedRow{Values: vals, Result: l.Evaluate(root, valuation)})
+
+		var idx int
+		for idx = len(counter) - 1; idx >= 0; idx-- {
+			counter[idx] = (counter[idx] + 1) % len(Values)
+			if counter[idx] != 0 {
+				break
+			}
+		}
+		if idx < 0 {
+			break
+		}
+	}
+	return table
+}
+
+// Valid returns true if the formula has a designated value
+// in every row of the table.
+func (t *ThreeValuedTable) Valid() bool {
+	for _, row := range t.Rows {
+		if !t.Logic.Designated().Has(row.Result) {
+			return false
+		}
+	}
+	return true
+}
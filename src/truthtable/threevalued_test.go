@@ -0,0 +1,75 @@
+package truthtable
+
+import (
+	"testing"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/parser"
+)
+
+// Where the logics differ: U > U, and whether U poisons T.
+var applyTests = []struct {
+	logic Logic
+	op    lexer.TokenType
+	a, b  Value
+	want  Value
+}{
+	{StrongKleene, lexer.IMPLIES, Unknown, Unknown, Unknown},
+	{Lukasiewicz, lexer.IMPLIES, Unknown, Unknown, True},
+	{Lukasiewicz, lexer.IMPLIES, True, Unknown, Unknown},
+	{StrongKleene, lexer.OR, Unknown, True, True},
+	{WeakKleene, lexer.OR, Unknown, True, Unknown},
+	{WeakKleene, lexer.AND, False, Unknown, Unknown},
+	{LP, lexer.NOT, Unknown, False, Unknown},
+	{Classical, lexer.EQUIV, False, False, True},
+}
+
+func TestApply(t *testing.T) {
+	for _, test := range applyTests {
+		if v := test.logic.Apply(test.op, test.a, test.b); v != test.want {
+			t.Errorf("%s %s %s in %s: %s, want %s", test.a, lexer.TokenName(test.op), test.b, test.logic, v, test.want)
+		}
+	}
+}
+
+// Classical tautologies, and the three-valued logics they're valid in.
+// K3 and B3 have no valid formulas at all: every identifier U makes
+// any formula U.
+var threeValuedTests = []struct {
+	formula string
+	valid   []Logic
+}{
+	{"p | ~p", []Logic{LP}},
+	{"p > p", []Logic{Lukasiewicz, LP}},
+	{"~(p & ~p)", []Logic{LP}},
+	{"(p & ~p) > q", []Logic{LP}},
+	{"p > (q > p)", []Logic{Lukasiewicz, LP}},
+	{"((p > q) > p) > p", []Logic{LP}},
+	{"~(p & q) = (~p | ~q)", []Logic{Lukasiewicz, LP}},
+}
+
+func TestThreeValuedValid(t *testing.T) {
+	for _, test := range threeValuedTests {
+		tree, err := parser.ParseString(test.formula)
+		if err != nil {
+			t.Fatalf("%q: %v", test.formula, err)
+		}
+		for _, l := range []Logic{StrongKleene, WeakKleene, Lukasiewicz, LP} {
+			want := false
+			for _, v := range test.valid {
+				want = want || v == l
+			}
+			table := NewThreeValued(tree, l)
+			rows := 1
+			for range table.Identifiers {
+				rows *= len(Values)
+			}
+			if len(table.Rows) != rows {
+				t.Errorf("%q in %s: %d rows, want %d", test.formula, l, len(table.Rows), rows)
+			}
+			if valid := table.Valid(); valid != want {
+				t.Errorf("%q in %s: valid %v, want %v", test.formula, l, valid, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package truthtable evaluates propositional logic parse trees under
+// every combination of truth values of their identifiers.
+package truthtable
+
+import (
+	"fmt"
+	"sort"
+
+	"tableaux-in-go/src/lexer"
+	"tableaux-in-go/src/node"
+)
+
+// Row holds one valuation of a formula's identifiers, in the
+// same order as Table.Identifiers, and the formula's value under it.
+type Row struct {
+	Values []bool
+	Result bool
+}
+
+// Table is a complete truth table for a formula.
+type Table struct {
+	Identifiers []string
+	Rows        []Row
+}
+
+// New creates the truth table of the formula whose parse tree is root.
+// Rows start with every identifier true, and count down to every
+// identifier false, the rightmost identifier varying fastest.
+func New(root *node.Node) *Table {
+
+	identifiers := Identifiers(root)
+
+	max := len(identifiers)
+	var vals []bool
+	for i := 0; i < max; i++ {
+		vals = append(vals, true)
+	}
+
+	table := &Table{Identifiers: identifiers}
+
+	for {
+
+		valuation := make(map[string]bool)
+		for idx, id := range identifiers {
+			valuation[id] = vals[idx]
+		}
+		r := Evaluate(root, valuation)
+		table.Rows = append(table.Rows, Row{Values: append([]bool(nil), vals...), Result: r})
+
+		var idx int
+		for idx = max - 1; idx >= 0; idx-- {
+			vals[idx] = !vals[idx]
+			if !vals[idx] {
+				break
+			}
+		}
+		if idx < 0 {
+			break
+		}
+	}
+
+	return table
+}
+
+// Identifiers gives back the sorted, de-duplicated identifiers in the
+// parse tree rooted at n, except for those a quantifier binds.
+func Identifiers(n *node.Node) []string {
+
+	seen := make(map[string]bool)
+	node.FreeIdentifiers(n, seen)
+
+	var uniqIdentifiers []string
+	for id := range seen {
+		uniqIdentifiers = append(uniqIdentifiers, id)
+	}
+
+	sort.Strings(uniqIdentifiers)
+
+	return uniqIdentifiers
+}
+
+// Evaluate finds the truth value of the parse tree rooted at n,
+// given truth values for its identifiers. A quantifier evaluates
+// what it quantifies both ways, the way a truth table would.
+func Evaluate(n *node.Node, valuation map[string]bool) bool {
+	switch n.Op {
+	case lexer.NOT:
+		return !Evaluate(n.Left, valuation)
+	case lexer.AND:
+		return Evaluate(n.Left, valuation) && Evaluate(n.Right, valuation)
+	case lexer.OR:
+		return Evaluate(n.Left, valuation) || Evaluate(n.Right, valuation)
+	case lexer.IMPLIES:
+		p := Evaluate(n.Left, valuation)
+		q := Evaluate(n.Right, valuation)
+		if p && !q {
+			return false
+		}
+		return true
+	case lexer.EQUIV:
+		return Evaluate(n.Left, valuation) == Evaluate(n.Right, valuation)
+	case lexer.FORALL, lexer.EXISTS:
+		t, f := evaluateInstances(n, valuation)
+		if n.Op == lexer.FORALL {
+			return t && f
+		}
+		return t || f
+	case lexer.IDENT:
+		if value, ok := n.Constant(); ok {
+			return value
+		}
+		return valuation[n.Ident]
+	}
+	panic(fmt.Sprintf("Problem with node type %s (%d): shouldn't get here\n", lexer.TokenName(n.Op), n.Op))
+}
@@ -2,22 +2,154 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
 
+	"tableaux-in-go/src/checker"
 	"tableaux-in-go/src/lexer"
 	"tableaux-in-go/src/node"
 	"tableaux-in-go/src/parser"
+	"tableaux-in-go/src/repl"
+	"tableaux-in-go/src/server"
 	"tableaux-in-go/src/tableaux"
+	"tableaux-in-go/src/truthtable"
 )
 
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve(os.Args[2:])
+		return
+	}
+
 	graphVizOutputFilename := flag.String("g", "", "File name for graphviz output, no default")
+	leftToRight := flag.Bool("lr", false, "Lay out graphviz output left to right")
+	clusters := flag.Bool("clusters", false, "Put each branch segment of graphviz output in a cluster")
+	htmlOutputFilename := flag.String("html", "", "File name for HTML proof report, no default")
+	jsonOutputFilename := flag.String("json", "", "File name for JSON proof output, no default")
+	verifyFilename := flag.String("verify", "", "Check the JSON proof in the named file")
+	checkFilename := flag.String("check", "", "Grade the hand-written tableau in the named file")
+	fitchFilename := flag.String("fitch", "", "Check the Fitch-style natural deduction proof in the named file")
+	hilbertFilename := flag.String("hilbert", "", "Check the Hilbert-style axiomatic proof in the named file")
+	drawTree := flag.Bool("t", false, "Draw tableau as a text-art tree")
+	asciiTree := flag.Bool("ascii", false, "Draw text-art tree with plain ASCII characters")
+	treeWidth := flag.Int("w", terminalWidth(), "Terminal width for text-art tree")
+	interactive := flag.Bool("i", false, "Build a tableau interactively")
+	unsigned := flag.Bool("u", false, "Build unsigned tableaux")
+	regular := flag.Bool("regular", false, "Don't subjoin formulas already on a branch")
+	timeout := flag.Duration("timeout", 0, "Give up on proof search after this long, 0 for no limit")
+	maxNodes := flag.Int("max-nodes", 0, "Give up on proof search past this many formulas, 0 for no limit")
+	maxInstances := flag.Int("max-instances", tableaux.DefaultMaxInstances, "Give up on first order proof search past this many instances of a universal formula on a branch, 0 for no limit")
+	depthFirst := flag.Bool("dfs", false, "Depth-first proof search, stopping at the first open branch")
+	workers := flag.Int("parallel", 0, "Depth-first proof search with this many workers exploring branches at once")
+	printStats := flag.Bool("stats", false, "Print statistics about the proof search")
+	statsFilename := flag.String("stats-json", "", "File name for JSON proof search statistics, no default")
+	prune := flag.Bool("prune", false, "Remove formulas no closed branch needs from the finished tableau")
+	pruneSizes := flag.Bool("prune-sizes", false, "Prune, and print the tableau's size before and after")
+	modal := flag.String("modal", "", "Modal logic for formulas with [] and <>: K, T, S4 or S5")
+	intuitionistic := flag.Bool("intuitionistic", false, "Decide validity in intuitionistic logic")
+	kripkeFilename := flag.String("kripke", "", "File name for graphviz output of a modal or intuitionistic countermodel, no default")
+	threeValued := flag.String("logic", "", "Three-valued logic for a many-signed tableau: K3, B3, L3 or LP")
+	ltl := flag.Bool("ltl", false, "Decide whether linear temporal logic formulas are satisfiable together")
+	qbf := flag.Bool("qbf", false, "Decide whether closed quantified boolean formulas are true, with Skolem functions if they are")
+	interpolate := flag.Bool("interpolate", false, "Find a Craig interpolant between hypotheses and the consequence they entail")
+	sequent := flag.Bool("sequent", false, "Print the closed tableau as a sequent calculus derivation")
+	latexOutputFilename := flag.String("latex", "", "File name for LaTeX bussproofs output of the sequent calculus derivation, no default")
 	flag.Parse()
 
+	system, err := tableaux.ParseSystem(*modal)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if *intuitionistic {
+		if system != tableaux.NonModal || *unsigned {
+			fmt.Fprintf(os.Stderr, "-intuitionistic doesn't go with -modal or -u\n")
+			os.Exit(1)
+		}
+		system = tableaux.Intuitionistic
+	}
+	logic, err := truthtable.ParseLogic(*threeValued)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if logic != truthtable.Classical && (system != tableaux.NonModal || *unsigned || *prune || *pruneSizes) {
+		fmt.Fprintf(os.Stderr, "-logic doesn't go with -modal, -intuitionistic, -u or -prune\n")
+		os.Exit(1)
+	}
+	if *ltl && (system != tableaux.NonModal || logic != truthtable.Classical || *unsigned || *prune || *pruneSizes ||
+		*depthFirst || *workers > 0 || *drawTree || *jsonOutputFilename != "" || *htmlOutputFilename != "" || *interactive) {
+		fmt.Fprintf(os.Stderr, "-ltl doesn't go with -modal, -intuitionistic, -logic, -u, -prune, -dfs, -parallel, -t, -json, -html or -i\n")
+		os.Exit(1)
+	}
+	if *qbf && (system != tableaux.NonModal || logic != truthtable.Classical || *ltl || *unsigned || *regular || *prune || *pruneSizes ||
+		*depthFirst || *workers > 0 || *interactive || *jsonOutputFilename != "") {
+		fmt.Fprintf(os.Stderr, "-qbf doesn't go with -modal, -intuitionistic, -logic, -ltl, -u, -regular, -prune, -dfs, -parallel, -i or -json\n")
+		os.Exit(1)
+	}
+	if *interpolate && (system != tableaux.NonModal || logic != truthtable.Classical || *ltl || *qbf || *unsigned ||
+		*depthFirst || *workers > 0 || *interactive) {
+		fmt.Fprintf(os.Stderr, "-interpolate doesn't go with -modal, -intuitionistic, -logic, -ltl, -qbf, -u, -dfs, -parallel or -i\n")
+		os.Exit(1)
+	}
+	if (*sequent || *latexOutputFilename != "") && (system != tableaux.NonModal || logic != truthtable.Classical || *ltl || *qbf ||
+		*depthFirst || *workers > 0 || *interactive) {
+		fmt.Fprintf(os.Stderr, "-sequent and -latex don't go with -modal, -intuitionistic, -logic, -ltl, -qbf, -dfs, -parallel or -i\n")
+		os.Exit(1)
+	}
+	if (*prune || *pruneSizes) && (*depthFirst || *workers > 0) {
+		fmt.Fprintf(os.Stderr, "-prune doesn't go with -dfs or -parallel\n")
+		os.Exit(1)
+	}
+	if *interactive && system != tableaux.NonModal {
+		fmt.Fprintf(os.Stderr, "-i doesn't go with -modal or -intuitionistic\n")
+		os.Exit(1)
+	}
+	opts := tableaux.Options{Unsigned: *unsigned, Regular: *regular, Modal: system, Logic: logic, QBF: *qbf}
+
+	if *verifyFilename != "" {
+		verify(*verifyFilename)
+		return
+	}
+
+	if *checkFilename != "" {
+		grade(*checkFilename)
+		return
+	}
+
+	if *fitchFilename != "" {
+		checkFitch(*fitchFilename)
+		return
+	}
+
+	if *hilbertFilename != "" {
+		checkHilbert(*hilbertFilename)
+		return
+	}
+
+	if *interactive {
+		var initial []string
+		if flag.NArg() > 0 {
+			// Formulas on command line: hypotheses and consequence.
+			for _, formula := range flag.Args()[:flag.NArg()-1] {
+				initial = append(initial, "assume "+formula)
+			}
+			initial = append(initial, "prove "+flag.Arg(flag.NArg()-1))
+		}
+		repl.New(os.Stdout, *treeWidth, opts).Run(os.Stdin, initial)
+		return
+	}
+
 	var expressions []string
 
 	if flag.NArg() > 0 {
@@ -31,12 +163,14 @@ func main() {
 
 	// Parse expression(s) on cmd line into *node.Node objects
 	var trees []*node.Node
+	var descriptions []string // For the HTML report
 
 	expressionCount := len(expressions)
 	denotation := "Expression"
-	if expressionCount > 1 {
+	if expressionCount > 1 && !*ltl && !*qbf {
 		denotation = "Hypothesis"
 	}
+	renamer := node.NewRenamer()
 
 	for idx, expression := range expressions {
 		var lxr *lexer.Lexer
@@ -44,65 +178,120 @@ func main() {
 		lxr = lexer.NewFromFile(expr)
 		psr := parser.New(lxr)
 		tree := psr.Parse()
-		fmt.Printf("%s: %q\n", denotation, node.ExpressionToString(tree))
+		if tree == nil {
+			fmt.Fprintf(os.Stderr, "Problem parsing %q\n", expression)
+			os.Exit(1)
+		}
+		if tree.Temporal() && !*ltl {
+			fmt.Fprintf(os.Stderr, "%q has temporal operators, decide it with -ltl\n", expression)
+			os.Exit(1)
+		}
+		if (tree.Modal() || tree.FirstOrder()) && *ltl {
+			fmt.Fprintf(os.Stderr, "Linear temporal logic is propositional, without modal operators, not %q\n", expression)
+			os.Exit(1)
+		}
+		if *qbf && !tree.QBF() {
+			fmt.Fprintf(os.Stderr, "Quantified boolean formulas are propositional, without modal operators or predicates, not %q\n", expression)
+			os.Exit(1)
+		}
+		if free := truthtable.Identifiers(tree); *qbf && len(free) > 0 {
+			fmt.Fprintf(os.Stderr, "%q has free identifiers %v, -qbf decides closed formulas\n", expression, free)
+			os.Exit(1)
+		}
+		if *qbf {
+			tree = renamer.Rename(tree)
+		}
+		if tree.FirstOrder() && *interpolate {
+			fmt.Fprintf(os.Stderr, "Interpolants are for propositional logic, not %q\n", expression)
+			os.Exit(1)
+		}
+		if tree.Modal() && system == tableaux.NonModal {
+			fmt.Fprintf(os.Stderr, "%q has modal operators, pick a modal logic with -modal\n", expression)
+			os.Exit(1)
+		}
+		if (tree.Modal() || tree.FirstOrder()) && system == tableaux.Intuitionistic {
+			fmt.Fprintf(os.Stderr, "Intuitionistic logic is propositional, without modal operators, not %q\n", expression)
+			os.Exit(1)
+		}
+		if (tree.Modal() || tree.FirstOrder()) && logic != truthtable.Classical {
+			fmt.Fprintf(os.Stderr, "Three-valued logic is propositional, without modal operators, not %q\n", expression)
+			os.Exit(1)
+		}
+		description := fmt.Sprintf("%s: %q", denotation, node.ExpressionToString(tree))
+		fmt.Printf("%s\n", description)
+		descriptions = append(descriptions, description)
 		trees = append(trees, tree)
-		if idx == expressionCount-2 {
+		if idx == expressionCount-2 && !*ltl && !*qbf {
 			denotation = "Consequence"
 		}
 	}
 
-	// tblx will become the entire tableau, below
-	tblx := tableaux.New(trees[0], false, nil)
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	limits := tableaux.Limits{MaxNodes: *maxNodes, MaxInstances: *maxInstances}
 
-	var finalFormula *tableaux.Tnode
-	if len(trees) > 1 {
-		// More than 1 PL formula, put them together for deciding
-		// logical consequence - all signed T except that last one F.
-		tblx.Sign = true
-		for _, tree := range trees[1:] {
-			finalFormula = tableaux.New(tree, true, nil) // All signed T
-			tblx.AppendLeaf(finalFormula)
-		}
-		finalFormula.Sign = false // Except final one, signed F
+	if *ltl {
+		decideLTL(ctx, trees, limits, *graphVizOutputFilename)
+		return
+	}
+
+	// tblx will become the entire tableau, except for a depth-first
+	// search without any proof output, which keeps no tableau.
+	var tblx, finalFormula *tableaux.Tnode
+	var result tableaux.Result
+	var search *tableaux.SearchResult
+	var stats tableaux.Stats
+	saved := 0
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	if *qbf {
+		tblx = tableaux.SetupQBF(trees, opts)
+		result, err = tblx.ExpandContext(ctx, limits)
+		stats = tblx.Stats()
+	} else if *depthFirst || *workers > 0 {
+		keep := *drawTree || *graphVizOutputFilename != "" || *jsonOutputFilename != "" || *htmlOutputFilename != ""
+		search, err = tableaux.Parallel(ctx, trees, opts, limits, keep, *workers)
+		tblx, finalFormula, result, saved = search.Root, search.FinalFormula, search.Result, search.Saved
+		stats = search.Stats
 	} else {
-		// Single expression. Subjoin its own inferences.
-		tblx.AddInferences(tblx)
-		tblx.Used = true
-	}
-
-	tautological := false // The answer we're looking for.
-	foundUnused := true   // Found a formula with no previously subjoined inferences
-
-	for foundUnused {
-		unclosedLeaves := tblx.FindUnclosedLeaf()
-		if len(unclosedLeaves) == 0 {
-			tautological = true
-			break
-		}
-		foundUnused = false
-		for _, leaf := range unclosedLeaves {
-
-			unusedFormula := leaf.FindTallestUnused()
-			if unusedFormula != nil {
-				uncl := unusedFormula.FindUnclosedLeaf()
-				// uncl will have leaf as an element, but subjoin inferences
-				// to all leaf nodes under unusedFormula
-				for _, leafNode := range uncl {
-					leafNode.AddInferences(unusedFormula)
-				}
-				foundUnused = true
-				unusedFormula.Used = true
-				// Just subjoined inferences to each unclosed leaf node
-				// in the branch below unusedFormula, so uncl and unclosedLeaves
-				// now don't have all unclosed leaf nodes in them.
-				break
-			}
-		}
+		tblx, finalFormula = tableaux.Setup(trees, opts)
+		result, err = tblx.ExpandContext(ctx, limits)
+		saved = tblx.Saved()
+		stats = tblx.Stats()
+	}
+	stats.WallTime = time.Since(start)
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	stats.Allocated = after.TotalAlloc - before.TotalAlloc
+	tautological := result == tableaux.Closed // The answer we're looking for.
+
+	var unpruned tableaux.Size
+	if (*prune || *pruneSizes) && tblx != nil && result != tableaux.Unknown {
+		unpruned = tblx.Size()
+		tblx.Prune()
 	}
 
 	fmt.Printf("/*\n")
 
-	tableaux.PrintTableaux(os.Stdout, tblx)
+	if *drawTree {
+		fmt.Printf("\n")
+		tableaux.DrawTableaux(os.Stdout, tblx, tableaux.DrawOptions{Width: *treeWidth, ASCII: *asciiTree})
+		fmt.Printf("\n")
+	} else if tblx != nil {
+		tableaux.PrintTableaux(os.Stdout, tblx)
+	} else {
+		size := search.Size
+		fmt.Printf("\nDepth-first search: %d formulas, %d branches, longest branch %d formulas\n", size.Nodes, size.Branches, size.Depth)
+		if search.Valuation != nil {
+			fmt.Printf("First open branch:%s\n", valuationString(search.Valuation))
+		}
+		fmt.Printf("\n")
+	}
 
 	var modifier string
 	if !tautological {
@@ -111,14 +300,87 @@ func main() {
 		modifier = ""
 	}
 
-	if finalFormula == nil {
-		fmt.Printf("Formula is%s a tautology\n", modifier)
+	var verdict string
+	if result == tableaux.Unknown {
+		verdict = fmt.Sprintf("Unknown: %v", err)
+	} else if logic != truthtable.Classical && len(trees) == 1 {
+		verdict = fmt.Sprintf("Formula is%s valid in %s", modifier, logic)
+	} else if logic != truthtable.Classical {
+		verdict = fmt.Sprintf("%s is%s a logical consequence of hypotheses in %s", node.ExpressionToString(trees[len(trees)-1]), modifier, logic)
+	} else if system != tableaux.NonModal && len(trees) == 1 {
+		verdict = fmt.Sprintf("Formula is%s valid in %s", modifier, logicName(system))
+	} else if system != tableaux.NonModal {
+		verdict = fmt.Sprintf("%s is%s a logical consequence of hypotheses in %s", node.ExpressionToString(trees[len(trees)-1]), modifier, logicName(system))
+	} else if *qbf {
+		subject, value := "Formula is", "false"
+		if len(trees) > 1 {
+			subject = "Formulas are"
+		}
+		if result == tableaux.Open {
+			value = "true"
+		}
+		verdict = fmt.Sprintf("%s %s", subject, value)
+	} else if len(trees) == 1 {
+		verdict = fmt.Sprintf("Formula is%s a tautology", modifier)
 	} else {
-		fmt.Printf("%s is%s a logical consequence of hypotheses\n", finalFormula.Expression, modifier)
+		verdict = fmt.Sprintf("%s is%s a logical consequence of hypotheses", node.ExpressionToString(trees[len(trees)-1]), modifier)
+	}
+	fmt.Printf("%s\n", verdict)
+	if *regular {
+		fmt.Printf("Regular tableau: %d formula(s) already on their branches not subjoined\n", saved)
+	}
+	if *pruneSizes && tblx != nil && result != tableaux.Unknown {
+		size := tblx.Size()
+		fmt.Printf("Pruned tableau: %d formulas, %d branches, from %d formulas, %d branches\n",
+			size.Nodes, size.Branches, unpruned.Nodes, unpruned.Branches)
+	}
+	var kripke *tableaux.Kripke
+	if system != tableaux.NonModal && result == tableaux.Open && tblx != nil {
+		kripke = tblx.FindUnclosedLeaf()[0].Kripke()
+		kripke.Print(os.Stdout)
+	}
+	if *interpolate {
+		printInterpolant(tblx, finalFormula, trees, result)
+	}
+	var derivation *tableaux.Derivation
+	if (*sequent || *latexOutputFilename != "") && result != tableaux.Unknown {
+		derivation = sequentDerivation(tblx, result)
+	}
+	if *sequent && derivation != nil {
+		fmt.Printf("Sequent calculus derivation:\n")
+		derivation.Print(os.Stdout)
+	}
+	if *qbf && result == tableaux.Open {
+		for _, skolem := range tblx.FindUnclosedLeaf()[0].Skolem() {
+			skolem.Print(os.Stdout)
+		}
+	}
+	if logic != truthtable.Classical && result == tableaux.Open && tblx != nil {
+		fmt.Printf("Countermodel, %s:%s\n", logic, threeValuedString(tblx.FindUnclosedLeaf()[0].ThreeValued()))
+	}
+	if *printStats {
+		tableaux.PrintStats(os.Stdout, stats)
 	}
 
 	fmt.Printf("*/\n")
 
+	if *statsFilename != "" {
+		buf, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			log.Printf("Problem encoding JSON: %s\n", err)
+			os.Exit(1)
+		}
+		if err := ioutil.WriteFile(*statsFilename, append(buf, '\n'), 0666); err != nil {
+			log.Printf("Problem writing JSON to %q: %s\n", *statsFilename, err)
+			os.Exit(1)
+		}
+	}
+
+	if result == tableaux.Unknown {
+		// No proof, so no proof output.
+		os.Exit(3)
+	}
+
 	if *graphVizOutputFilename != "" {
 		fout, err := os.OpenFile(*graphVizOutputFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 		if err != nil {
@@ -126,6 +388,323 @@ func main() {
 			os.Exit(1)
 		}
 		defer fout.Close()
-		tblx.GraphTnode(fout)
+		tableaux.GraphTableaux(fout, tblx, tableaux.GraphOptions{LeftToRight: *leftToRight, Clusters: *clusters})
+	}
+
+	if *latexOutputFilename != "" && derivation != nil {
+		fout, err := os.OpenFile(*latexOutputFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			log.Printf("Problem opening %q write-only: %s\n", *latexOutputFilename, err)
+			os.Exit(1)
+		}
+		defer fout.Close()
+		derivation.LaTeX(fout)
+	}
+
+	if *kripkeFilename != "" && kripke != nil {
+		fout, err := os.OpenFile(*kripkeFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			log.Printf("Problem opening %q write-only: %s\n", *kripkeFilename, err)
+			os.Exit(1)
+		}
+		defer fout.Close()
+		kripke.Graph(fout)
+	}
+
+	if *jsonOutputFilename != "" {
+		proof := &tableaux.JSONProof{Unsigned: *unsigned, Modal: system.String(), Logic: logic.String(), Closed: tautological, Tableau: tblx.JSON()}
+		if finalFormula == nil {
+			proof.Formula = node.ExpressionToString(trees[0])
+		} else {
+			for _, tree := range trees[:len(trees)-1] {
+				proof.Hypotheses = append(proof.Hypotheses, node.ExpressionToString(tree))
+			}
+			proof.Consequence = node.ExpressionToString(trees[len(trees)-1])
+		}
+		buf, err := json.MarshalIndent(proof, "", "  ")
+		if err != nil {
+			log.Printf("Problem encoding JSON: %s\n", err)
+			os.Exit(1)
+		}
+		if err := ioutil.WriteFile(*jsonOutputFilename, append(buf, '\n'), 0666); err != nil {
+			log.Printf("Problem writing JSON to %q: %s\n", *jsonOutputFilename, err)
+			os.Exit(1)
+		}
+	}
+
+	if *htmlOutputFilename != "" {
+		fout, err := os.OpenFile(*htmlOutputFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			log.Printf("Problem opening %q write-only: %s\n", *htmlOutputFilename, err)
+			os.Exit(1)
+		}
+		defer fout.Close()
+		report := tableaux.HTMLReport{
+			Title:       "Analytic tableau",
+			Expressions: descriptions,
+			Verdict:     verdict,
+		}
+		if err := tableaux.WriteHTML(fout, tblx, report); err != nil {
+			log.Printf("Problem writing HTML to %q: %s\n", *htmlOutputFilename, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// printInterpolant prints a Craig interpolant between the hypotheses
+// and consequence of the logical consequence tableau rooted at tblx,
+// and whether it checks out, if the hypotheses entail the consequence.
+func printInterpolant(tblx, finalFormula *tableaux.Tnode, trees []*node.Node, result tableaux.Result) {
+	if len(trees) < 2 {
+		fmt.Printf("No interpolant without hypotheses\n")
+		return
+	}
+	if result != tableaux.Closed {
+		fmt.Printf("No interpolant, the consequence doesn't follow\n")
+		return
+	}
+	interpolant, err := tblx.Interpolant(finalFormula)
+	if err != nil {
+		fmt.Printf("No interpolant: %v\n", err)
+		return
+	}
+	fmt.Printf("Interpolant: ")
+	interpolant.Print(os.Stdout)
+	fmt.Printf("\n")
+	if err := tableaux.CheckInterpolant(trees[:len(trees)-1], trees[len(trees)-1], interpolant); err != nil {
+		fmt.Printf("Interpolant doesn't check out: %v\n", err)
+		return
+	}
+	fmt.Printf("Interpolant checks out: hypotheses entail it, it entails the consequence\n")
+}
+
+// sequentDerivation gives back the sequent calculus derivation of the
+// closed tableau rooted at tblx, or prints why there isn't one.
+func sequentDerivation(tblx *tableaux.Tnode, result tableaux.Result) *tableaux.Derivation {
+	if result != tableaux.Closed {
+		fmt.Printf("No sequent calculus derivation, the tableau didn't close\n")
+		return nil
+	}
+	derivation, err := tblx.Derivation()
+	if err != nil {
+		fmt.Printf("No sequent calculus derivation: %v\n", err)
+		return nil
+	}
+	return derivation
+}
+
+// decideLTL decides whether linear temporal logic formulas trees are
+// satisfiable together, printing the graph tableau, and a model if they
+// are. A graphVizOutputFilename gets the graph in GraphViz format.
+func decideLTL(ctx context.Context, trees []*node.Node, limits tableaux.Limits, graphVizOutputFilename string) {
+	tblx := tableaux.SetupLTL(trees)
+	result, err := tblx.ExpandContext(ctx, limits)
+
+	fmt.Printf("/*\n\n")
+	if result != tableaux.Unknown {
+		tblx.Print(os.Stdout)
+		fmt.Printf("\n")
+	}
+
+	subject := "Formula is"
+	if len(trees) > 1 {
+		subject = "Formulas are"
+	}
+	switch result {
+	case tableaux.Unknown:
+		fmt.Printf("Unknown: %v\n", err)
+	case tableaux.Closed:
+		fmt.Printf("%s not satisfiable\n", subject)
+	case tableaux.Open:
+		fmt.Printf("%s satisfiable\n", subject)
+		tblx.Model().Print(os.Stdout)
+	}
+	fmt.Printf("*/\n")
+
+	if result == tableaux.Unknown {
+		os.Exit(3)
+	}
+
+	if graphVizOutputFilename != "" {
+		fout, err := os.OpenFile(graphVizOutputFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			log.Printf("Problem opening %q write-only: %s\n", graphVizOutputFilename, err)
+			os.Exit(1)
+		}
+		defer fout.Close()
+		tblx.Graph(fout)
+	}
+}
+
+// valuationString gives back " p=true q=false" and so on,
+// in order of identifier.
+func valuationString(valuation map[string]bool) string {
+	var ids []string
+	for id := range valuation {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var s string
+	for _, id := range ids {
+		s += fmt.Sprintf(" %s=%v", id, valuation[id])
+	}
+	return s
+}
+
+// threeValuedString gives back " p=T q=U" and so on,
+// in order of identifier.
+func threeValuedString(valuation map[string]truthtable.Value) string {
+	var ids []string
+	for id := range valuation {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var s string
+	for _, id := range ids {
+		s += fmt.Sprintf(" %s=%v", id, valuation[id])
+	}
+	return s
+}
+
+// logicName gives back the name of a modal or intuitionistic logic
+// for a verdict: "S4", "intuitionistic logic".
+func logicName(system tableaux.System) string {
+	if system == tableaux.Intuitionistic {
+		return "intuitionistic logic"
+	}
+	return system.String()
+}
+
+// terminalWidth guesses at the width of the terminal from $COLUMNS,
+// which shells set but don't always export.
+func terminalWidth() int {
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
+
+// serve runs an HTTP server with a JSON API to the prover,
+// "tableaux serve [flags]" on the command line.
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "TCP address to listen on")
+	timeout := flags.Duration("timeout", server.DefaultConfig.Timeout, "Longest time to spend on a request")
+	maxBody := flags.Int64("max-body", server.DefaultConfig.MaxBodyBytes, "Largest request body, in bytes")
+	maxConcurrent := flags.Int("max-concurrent", server.DefaultConfig.MaxConcurrent, "Most requests worked on at once")
+	dotCommand := flags.String("dot", server.DefaultConfig.DotCommand, "GraphViz program for SVG rendering, empty to disable")
+	maxNodes := flags.Int("max-nodes", server.DefaultConfig.Limits.MaxNodes, "Most formulas in any one tableau, 0 for no limit")
+	maxInstances := flags.Int("max-instances", server.DefaultConfig.Limits.MaxInstances, "Most instances of a universal formula on a branch, 0 for no limit")
+	flags.Parse(args)
+
+	srv := server.New(server.Config{
+		MaxBodyBytes:  *maxBody,
+		Timeout:       *timeout,
+		MaxConcurrent: *maxConcurrent,
+		DotCommand:    *dotCommand,
+		Limits:        tableaux.Limits{MaxNodes: *maxNodes, MaxInstances: *maxInstances},
+	})
+	log.Fatal(srv.ListenAndServe(*addr))
+}
+
+// verify checks the proof in the named JSON file, without
+// trusting package tableaux.
+func verify(fileName string) {
+	fin, err := os.Open(fileName)
+	if err != nil {
+		log.Fatalf("Problem opening %q: %s\n", fileName, err)
+	}
+	defer fin.Close()
+
+	proof, err := checker.ReadJSON(fin)
+	if err != nil {
+		log.Fatalf("Problem reading proof from %q: %s\n", fileName, err)
+	}
+
+	if err := checker.Check(proof); err != nil {
+		fmt.Printf("%s: invalid tableau: %s\n", fileName, err)
+		os.Exit(2)
+	}
+	fmt.Printf("%s: valid tableau\n", fileName)
+}
+
+// grade checks a hand-written tableau in the named file,
+// reporting all the mistakes it finds.
+func grade(fileName string) {
+	fin, err := os.Open(fileName)
+	if err != nil {
+		log.Fatalf("Problem opening %q: %s\n", fileName, err)
+	}
+	defer fin.Close()
+
+	proof, err := checker.ReadText(fin)
+	if err != nil {
+		log.Fatalf("Problem reading tableau from %q: %s\n", fileName, err)
+	}
+
+	report := checker.Grade(proof)
+	for _, mistake := range report.Mistakes {
+		fmt.Printf("%s: %s\n", fileName, mistake)
+	}
+
+	if !report.Decided {
+		fmt.Printf("%s: %d mistake(s), verdict not graded\n", fileName, len(report.Mistakes))
+		os.Exit(2)
+	}
+	var right string
+	if !report.VerdictRight() {
+		right = " not"
+	}
+	fmt.Printf("%s: %d mistake(s), verdict is%s right\n", fileName, len(report.Mistakes), right)
+
+	if len(report.Mistakes) > 0 {
+		os.Exit(2)
+	}
+}
+
+// checkFitch checks the natural deduction proof in the named file,
+// printing every mistake it finds.
+func checkFitch(fileName string) {
+	fin, err := os.Open(fileName)
+	if err != nil {
+		log.Fatalf("Problem opening %q: %s\n", fileName, err)
+	}
+	defer fin.Close()
+
+	proof, err := checker.ReadFitch(fin)
+	if err != nil {
+		log.Fatalf("Problem reading natural deduction proof from %q: %s\n", fileName, err)
+	}
+
+	printMistakes(fileName, checker.CheckFitch(proof))
+}
+
+// checkHilbert checks the axiomatic proof in the named file,
+// printing every mistake it finds.
+func checkHilbert(fileName string) {
+	fin, err := os.Open(fileName)
+	if err != nil {
+		log.Fatalf("Problem opening %q: %s\n", fileName, err)
+	}
+	defer fin.Close()
+
+	proof, err := checker.ReadHilbert(fin)
+	if err != nil {
+		log.Fatalf("Problem reading axiomatic proof from %q: %s\n", fileName, err)
+	}
+
+	printMistakes(fileName, checker.CheckHilbert(proof))
+}
+
+// printMistakes prints the mistakes found in the proof in the named
+// file, exiting with status 2 if there are any.
+func printMistakes(fileName string, mistakes []*checker.StepError) {
+	for _, mistake := range mistakes {
+		fmt.Printf("%s: %s\n", fileName, mistake)
+	}
+	if len(mistakes) > 0 {
+		fmt.Printf("%s: %d mistake(s)\n", fileName, len(mistakes))
+		os.Exit(2)
 	}
+	fmt.Printf("%s: proof checks out\n", fileName)
 }
@@ -2,20 +2,28 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
-	"log"
 	"os"
-	"sort"
 
 	"tableaux-in-go/src/lexer"
 	"tableaux-in-go/src/node"
 	"tableaux-in-go/src/parser"
+	"tableaux-in-go/src/truthtable"
 )
 
 func main() {
+	logicName := flag.String("logic", "", "Three-valued logic: K3, B3, L3 or LP")
+	flag.Parse()
+	logic, err := truthtable.ParseLogic(*logicName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
 	var lxr *lexer.Lexer
-	if len(os.Args) > 1 {
-		expr := bytes.NewBufferString(os.Args[1] + "\n")
+	if flag.NArg() > 0 {
+		expr := bytes.NewBufferString(flag.Arg(0) + "\n")
 		lxr = lexer.NewFromFile(expr)
 	} else {
 		lxr = lexer.NewFromFile(os.Stdin)
@@ -25,21 +33,58 @@ func main() {
 
 	root := psr.Parse()
 
-	if root != nil {
+	if root != nil && !root.QBF() {
+		fmt.Fprintf(os.Stderr, "Truth tables are for propositional logic and quantified boolean formulas, not %q\n", node.ExpressionToString(root))
+		os.Exit(1)
+	}
+	if root != nil && root.FirstOrder() && logic != truthtable.Classical {
+		fmt.Fprintf(os.Stderr, "Three-valued truth tables don't do quantifiers, %q has them\n", node.ExpressionToString(root))
+		os.Exit(1)
+	}
+	if root != nil && logic != truthtable.Classical {
+		printThreeValuedTable(root, logic)
+	} else if root != nil && root.FirstOrder() {
+		printQBFTable(node.NewRenamer().Rename(root))
+	} else if root != nil {
 		printTruthTable(root)
 	}
 }
 
 func printTruthTable(root *node.Node) {
 
-	identifiers := findIdentifiers(root)
+	table := truthtable.New(root)
 
-	max := len(identifiers)
-	var vals []bool
-	for i := 0; i < max; i++ {
-		vals = append(vals, true)
+	printHeader(table.Identifiers, root)
+
+	for _, row := range table.Rows {
+		printRow(table.Identifiers, row.Values, row.Result)
 	}
+}
+
+// printQBFTable prints the truth table of quantified boolean formula
+// root, with a row for each valuation of its free identifiers. A closed
+// root has a single row, so it also prints whether root is true, and
+// if it is, Skolem functions for its variables in existential position.
+func printQBFTable(root *node.Node) {
+
+	printTruthTable(root)
 
+	if len(truthtable.Identifiers(root)) > 0 {
+		return
+	}
+	// No Skolem functions doesn't mean false: a true root might
+	// not have any variables in existential position.
+	if !truthtable.Evaluate(root, make(map[string]bool)) {
+		fmt.Printf("Formula is false\n")
+		return
+	}
+	fmt.Printf("Formula is true\n")
+	for _, s := range truthtable.SkolemFunctions(root) {
+		s.Print(os.Stdout)
+	}
+}
+
+func printHeader(identifiers []string, root *node.Node) {
 	for _, variable := range identifiers {
 		n := 5 - len(variable)
 		spacer := ""
@@ -50,86 +95,28 @@ func printTruthTable(root *node.Node) {
 	}
 	expression := node.ExpressionToString(root)
 	fmt.Printf("\t%s\n", expression)
-
-	for {
-
-		valuation := make(map[string]bool)
-		for idx, id := range identifiers {
-			valuation[id] = vals[idx]
-		}
-		r := evaluateExpression(root, valuation)
-		printRow(identifiers, vals, r)
-
-		var idx int
-		for idx = max - 1; idx >= 0; idx-- {
-			vals[idx] = !vals[idx]
-			if !vals[idx] {
-				break
-			}
-		}
-		if idx < 0 {
-			break
-		}
-	}
 }
 
-func findIdentifiers(n *node.Node) []string {
+// printThreeValuedTable prints the truth table of root in logic,
+// and whether root is valid in logic.
+func printThreeValuedTable(root *node.Node, logic truthtable.Logic) {
 
-	allIdentifiers := findAllIdentifiers(n)
+	table := truthtable.NewThreeValued(root, logic)
 
-	alreadySeen := make(map[string]bool)
-	var uniqIdentifiers []string
-	for _, id := range allIdentifiers {
-		if _, ok := alreadySeen[id]; !ok {
-			alreadySeen[id] = true
-			uniqIdentifiers = append(uniqIdentifiers, id)
-		}
-	}
+	printHeader(table.Identifiers, root)
 
-	sort.Strings(uniqIdentifiers)
-
-	return uniqIdentifiers
-}
-
-func findAllIdentifiers(n *node.Node) []string {
-
-	var identifiers []string
-	if n.Op == lexer.IDENT {
-		identifiers = append(identifiers, n.Ident)
-	}
-	if n.Left != nil {
-		ids := findAllIdentifiers(n.Left)
-		identifiers = append(identifiers, ids...)
-	}
-	if n.Right != nil {
-		ids := findAllIdentifiers(n.Right)
-		identifiers = append(identifiers, ids...)
+	for _, row := range table.Rows {
+		for _, v := range row.Values {
+			fmt.Printf("%5v ", v)
+		}
+		fmt.Printf("\t%v\n", row.Result)
 	}
-	return identifiers
-}
 
-func evaluateExpression(n *node.Node, valuation map[string]bool) bool {
-	switch n.Op {
-	case lexer.NOT:
-		return !evaluateExpression(n.Left, valuation)
-	case lexer.AND:
-		return evaluateExpression(n.Left, valuation) && evaluateExpression(n.Right, valuation)
-	case lexer.OR:
-		return evaluateExpression(n.Left, valuation) || evaluateExpression(n.Right, valuation)
-	case lexer.IMPLIES:
-		p := evaluateExpression(n.Left, valuation)
-		q := evaluateExpression(n.Right, valuation)
-		if p && !q {
-			return false
-		}
-		return true
-	case lexer.EQUIV:
-		return evaluateExpression(n.Left, valuation) == evaluateExpression(n.Right, valuation)
-	case lexer.IDENT:
-		return valuation[n.Ident]
+	modifier := ""
+	if !table.Valid() {
+		modifier = " not"
 	}
-	log.Fatalf("Problem with node type %s (%d): shouldn't get here\n", lexer.TokenName(n.Op), n.Op)
-	return false
+	fmt.Printf("Formula is%s valid in %s, designated values %v\n", modifier, logic, logic.Designated())
 }
 
 func printRow(identifiers []string, vals []bool, r bool) {